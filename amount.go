@@ -0,0 +1,141 @@
+package coin
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AmountDecimals is the number of decimal places kept by an Amount.
+const AmountDecimals = 9
+
+// Coin is the Amount of a whole coin.
+const Coin = Amount(1000000000)
+
+// TreasurePrice is the Amount a creator pays for each treasure of a game.
+const TreasurePrice = Coin / 10
+
+// Amount represents a fixed-point quantity of coins, in units of 10^-AmountDecimals coins.
+type Amount int64
+
+// ParseAmount parses a decimal amount of coins, like "1.5" or "-0.25".
+// Decimal places beyond AmountDecimals are truncated.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+
+	// read the sign.
+	neg := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if whole == "" && frac == "" {
+		return 0, ErrInvalidAmount
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	// pad or truncate the decimal places.
+	for _, r := range frac {
+		if r < '0' || r > '9' {
+			return 0, ErrInvalidAmount
+		}
+	}
+	if len(frac) > AmountDecimals {
+		frac = frac[:AmountDecimals]
+	}
+	frac += strings.Repeat("0", AmountDecimals-len(frac))
+
+	w, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil || w > uint64(maxAmount/Coin) {
+		return 0, ErrInvalidAmount
+	}
+	f, _ := strconv.ParseUint(frac, 10, 63)
+
+	a := Amount(w)*Coin + Amount(f)
+	if a < 0 {
+		return 0, ErrInvalidAmount
+	}
+	if neg {
+		a = -a
+	}
+	return a, nil
+}
+
+// maxAmount is the largest representable Amount.
+const maxAmount = Amount(1<<63 - 1)
+
+// Add returns the sum of both amounts.
+func (a Amount) Add(b Amount) Amount { return a + b }
+
+// Sub returns the difference of both amounts.
+func (a Amount) Sub(b Amount) Amount { return a - b }
+
+// Mul returns the amount multiplied by n.
+func (a Amount) Mul(n int64) Amount { return a * Amount(n) }
+
+// Neg returns the amount with the opposite sign.
+func (a Amount) Neg() Amount { return -a }
+
+// Cmp compares both amounts, returning -1, 0 or +1.
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount.
+func (a Amount) Sign() int { return a.Cmp(0) }
+
+// IsZero returns whether the amount is zero.
+func (a Amount) IsZero() bool { return a == 0 }
+
+// String formats the amount as a decimal, without trailing zeros.
+func (a Amount) String() string {
+	sign := ""
+	u := uint64(a)
+	if a < 0 {
+		sign = "-"
+		u = uint64(-a)
+	}
+
+	whole := strconv.FormatUint(u/uint64(Coin), 10)
+	frac := strconv.FormatUint(u%uint64(Coin), 10)
+	if frac == "0" {
+		return sign + whole
+	}
+	frac = strings.Repeat("0", AmountDecimals-len(frac)) + frac
+	return sign + whole + "." + strings.TrimRight(frac, "0")
+}
+
+// Signed formats the amount like String, prefixing positive amounts with a plus sign.
+func (a Amount) Signed() string {
+	if a > 0 {
+		return "+" + a.String()
+	}
+	return a.String()
+}
+
+// MarshalText encodes the amount as a decimal string.
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes the amount from a decimal string.
+func (a *Amount) UnmarshalText(text []byte) error {
+	v, err := ParseAmount(string(text))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
@@ -0,0 +1,65 @@
+package coin_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseAmount tests parsing decimal amounts of coins.
+func TestParseAmount(t *testing.T) {
+	for s, want := range map[string]coin.Amount{
+		"1":                    coin.Coin,
+		"0.1":                  coin.Coin / 10,
+		"+1.5":                 coin.Coin + coin.Coin/2,
+		"-0.25":                -coin.Coin / 4,
+		".5":                   coin.Coin / 2,
+		"0.000000001":          1,
+		"1.234567890123456789": 1234567890,
+	} {
+		a, err := coin.ParseAmount(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, want, a, s)
+	}
+
+	for _, s := range []string{"", "-", ".", "abc", "1.2.3", "1e3", "99999999999"} {
+		_, err := coin.ParseAmount(s)
+		assert.Equal(t, coin.ErrInvalidAmount, err, s)
+	}
+}
+
+// TestAmount_String tests formatting amounts of coins.
+func TestAmount_String(t *testing.T) {
+	assert.Equal(t, "0", coin.Amount(0).String())
+	assert.Equal(t, "0.1", coin.TreasurePrice.String())
+	assert.Equal(t, "-1.05", (-coin.Coin - coin.Coin/20).String())
+	assert.Equal(t, "0.000000001", coin.Amount(1).String())
+	assert.Equal(t, "+0.3", coin.TreasurePrice.Mul(3).Signed())
+	assert.Equal(t, "-0.3", coin.TreasurePrice.Mul(3).Neg().Signed())
+}
+
+// TestAmount_Arithmetic tests adding and comparing amounts without rounding errors.
+func TestAmount_Arithmetic(t *testing.T) {
+	var sum coin.Amount
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(coin.TreasurePrice)
+	}
+	assert.Equal(t, coin.Coin, sum)
+	assert.Equal(t, 0, sum.Cmp(coin.Coin))
+	assert.Equal(t, -1, coin.TreasurePrice.Cmp(sum))
+	assert.Equal(t, 1, sum.Sub(coin.TreasurePrice).Sign())
+	assert.True(t, sum.Sub(coin.Coin).IsZero())
+}
+
+// TestAmount_JSON tests encoding amounts as decimal strings.
+func TestAmount_JSON(t *testing.T) {
+	b, err := json.Marshal(map[string]coin.Amount{"amount": coin.TreasurePrice})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"amount":"0.1"}`, string(b))
+
+	var v struct{ Amount coin.Amount }
+	assert.Nil(t, json.Unmarshal([]byte(`{"Amount":"2.5"}`), &v))
+	assert.Equal(t, coin.Coin*5/2, v.Amount)
+}
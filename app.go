@@ -8,6 +8,78 @@ type User struct {
 	Username string
 	Password string
 	Wallet   string
+	Role     Role
+	Disabled bool
+
+	// external identity linked to the account.
+	IdentityIssuer  string
+	IdentitySubject string
+}
+
+// DeletedUser replaces the email of a deleted account in the games it created and the treasures it found.
+// It is not a valid email, so no account can be created with it and inherit those games.
+const DeletedUser = "deleted-user"
+
+// HasRole returns whether the user has at least the supplied role.
+func (u User) HasRole(role Role) bool {
+	return u.Role.Includes(role)
+}
+
+// Role represents the access level of a user.
+type Role string
+
+// user roles, from the least to the most privileged.
+const (
+	RolePlayer    = Role("player")
+	RoleCreator   = Role("creator")
+	RoleModerator = Role("moderator")
+	RoleAdmin     = Role("admin")
+)
+
+// DefaultRole is the role given to new users and to users stored without one.
+const DefaultRole = RoleCreator
+
+// Roles lists every role, from the least to the most privileged.
+var Roles = []Role{RolePlayer, RoleCreator, RoleModerator, RoleAdmin}
+
+// rank returns the privilege level of the role.
+func (r Role) rank() int {
+	if r == "" {
+		r = DefaultRole
+	}
+	for i, role := range Roles {
+		if role == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// Valid returns whether the role is a known role.
+func (r Role) Valid() bool {
+	return r != "" && r.rank() >= 0
+}
+
+// Includes returns whether the role grants the privileges of the supplied role.
+func (r Role) Includes(role Role) bool {
+	return r.rank() >= role.rank()
+}
+
+// String returns the role name.
+func (r Role) String() string {
+	if r == "" {
+		return string(DefaultRole)
+	}
+	return string(r)
+}
+
+// Identity represents a user identity verified by an external provider.
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
 }
 
 // Game represents the domain game structure.
@@ -17,7 +89,37 @@ type Game struct {
 	Description string
 	StartDate   time.Time
 	Creator     string
+	Hidden      bool
 	Treasures   map[string]Treasure
+
+	// payment transaction of the game creation and its status.
+	// Games stored without a status were paid for before transfers were tracked.
+	Transaction string
+	Payment     TransferStatus
+}
+
+// Active returns whether the payment of the game has been confirmed.
+func (g Game) Active() bool {
+	return g.Payment == "" || g.Payment == TransferComplete
+}
+
+// Finished returns whether every treasure of the game has been found.
+func (g Game) Finished() bool {
+	for _, t := range g.Treasures {
+		if !t.Found {
+			return false
+		}
+	}
+	return len(g.Treasures) > 0
+}
+
+// VisibleTo returns whether the game can be seen by the user.
+// Hidden games and games waiting for their payment are only visible to their creator and to moderators.
+func (g Game) VisibleTo(user User) bool {
+	if !g.Hidden && g.Active() {
+		return true
+	}
+	return user.Email != "" && (user.Email == g.Creator || user.HasRole(RoleModerator))
 }
 
 // Treasure represents the domain treasure structure.
@@ -31,13 +133,226 @@ type Treasure struct {
 	Found     bool
 	FoundDate time.Time
 	FoundUser string
+
+	// reward transaction of the discovery and its status.
+	Transaction string
+	Reward      TransferStatus
 }
 
 // Transaction represents the domain coin transfer event.
 type Transaction struct {
+	ID         string
 	FromWallet string
 	ToWallet   string
 	Event      string
 	Date       time.Time
-	Amount     string
+
+	// Amount is positive when the coins were received and negative when they were sent.
+	Amount Amount
+
+	// game and treasure the transaction relates to, if any.
+	Game         string
+	GameTitle    string
+	Treasure     string
+	TreasureName string
+
+	// settlement of the transaction, when tracked.
+	Status TransferStatus
+}
+
+// transaction events.
+const (
+	EventTreasureFound  = "Treasure Found"
+	EventGameCreated    = "Game Created"
+	EventAirdrop        = "Airdrop"
+	EventTokensReturned = "Tokens Returned"
+	EventTransfer       = "Transfer"
+	EventTip            = "Tip"
+)
+
+// Events lists every transaction event.
+var Events = []string{EventTreasureFound, EventGameCreated, EventAirdrop, EventTokensReturned, EventTransfer, EventTip}
+
+// TransferStatus represents the settlement of a transfer submitted to the wallet provider.
+type TransferStatus string
+
+// transfer statuses.
+const (
+	TransferPending  = TransferStatus("pending")
+	TransferComplete = TransferStatus("complete")
+	TransferFailed   = TransferStatus("failed")
+
+	// the wallet provider failed once the transfer was sent, so whether it went through must be checked with it.
+	TransferUnknown = TransferStatus("unknown")
+)
+
+// Settled returns whether the transfer reached its final status.
+func (s TransferStatus) Settled() bool {
+	return s == TransferComplete || s == TransferFailed
+}
+
+// Transfer represents a transfer submitted to the wallet provider, tracked until it settles.
+type Transfer struct {
+	ID     string
+	Event  string
+	Wallet string
+	Amount Amount
+	Status TransferStatus
+	Reason string
+
+	// wallet receiving the coins of a transfer between users.
+	Recipient string
+
+	// game and treasure the transfer pays for.
+	Game     string
+	Treasure string
+
+	CreatedDate time.Time
+	UpdatedDate time.Time
+}
+
+// OperationKind represents the kind of a deferred wallet operation.
+type OperationKind string
+
+// deferred wallet operations.
+const (
+	// creates the wallet of a user who signed up, if missing, and airdrops the signup coins.
+	OperationSignUp = OperationKind("sign_up")
+	// rewards the user who found a treasure.
+	OperationReward = OperationKind("reward")
+)
+
+// Operation represents a wallet operation deferred while the wallet provider is unavailable.
+// Operations are replayed in the order they were deferred once the provider recovers.
+type Operation struct {
+	ID   string
+	Kind OperationKind
+	// email of the user the operation is performed for.
+	User string
+	// coins airdropped to the user signing up.
+	Amount Amount
+
+	// game and treasure rewarded.
+	Game     string
+	Treasure string
+	// reward transaction, once sent; the reward is not sent again.
+	Transaction string
+
+	Attempts    int
+	LastError   string
+	CreatedDate time.Time
+}
+
+// Webhook represents an url notified of the game events, with payloads signed by its secret.
+type Webhook struct {
+	ID     string
+	URL    string
+	Secret string
+	// Game notified, or every game when empty.
+	Game string
+	// email of the creator or admin who registered the webhook.
+	Owner       string
+	CreatedDate time.Time
+}
+
+// DeliveryStatus represents the progress of the delivery of an event to a webhook.
+type DeliveryStatus string
+
+// delivery statuses.
+const (
+	DeliveryPending   = DeliveryStatus("pending")
+	DeliveryDelivered = DeliveryStatus("delivered")
+	DeliveryFailed    = DeliveryStatus("failed")
+)
+
+// Delivery represents an event sent to a webhook, retried until it is accepted or given up.
+type Delivery struct {
+	ID      string
+	Webhook string
+	// kind of the event and the game it happened in.
+	Event string
+	Game  string
+	// Payload is the JSON body posted.
+	Payload string
+	Status  DeliveryStatus
+
+	// attempts made, the response or error of the last one, and when the next is due.
+	Attempts    int
+	LastCode    int
+	LastError   string
+	NextAttempt time.Time
+
+	CreatedDate time.Time
+	UpdatedDate time.Time
+}
+
+// TransactionFilter selects transactions by date range and event.
+// Zero values match every transaction.
+type TransactionFilter struct {
+	From  time.Time
+	To    time.Time
+	Event string
+}
+
+// Match returns whether the transaction is selected by the filter.
+// The range includes From and excludes To.
+func (f TransactionFilter) Match(t Transaction) bool {
+	if !f.From.IsZero() && t.Date.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !t.Date.Before(f.To) {
+		return false
+	}
+	return f.Event == "" || f.Event == t.Event
+}
+
+// APIToken represents a personal access token used by scripts and mobile clients.
+type APIToken struct {
+	ID           string
+	Name         string
+	User         string
+	Hash         string
+	Scopes       []Scope
+	CreatedDate  time.Time
+	ExpiryDate   time.Time
+	LastUsedDate time.Time
+}
+
+// Expired returns whether the token can no longer be used.
+func (t APIToken) Expired(now time.Time) bool {
+	return !t.ExpiryDate.IsZero() && now.After(t.ExpiryDate)
+}
+
+// HasScope returns whether the token was granted the scope.
+func (t APIToken) HasScope(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope represents a permission granted to an API token.
+type Scope string
+
+// API token scopes.
+const (
+	ScopeGamesRead   = Scope("games:read")
+	ScopeGamesWrite  = Scope("games:write")
+	ScopeWalletRead  = Scope("wallet:read")
+	ScopeWalletWrite = Scope("wallet:write")
+)
+
+// Scopes lists every scope that can be granted to an API token.
+var Scopes = []Scope{ScopeGamesRead, ScopeGamesWrite, ScopeWalletRead, ScopeWalletWrite}
+
+// Valid returns whether the scope is a known scope.
+func (s Scope) Valid() bool {
+	for _, scope := range Scopes {
+		if scope == s {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,241 @@
+// Package breaker stops calling the wallet provider while it is unavailable.
+//
+// After Threshold consecutive failures the circuit opens and the calls fail fast with
+// coin.ErrWalletNotSent, without reaching the provider. Once the Cooldown elapses a single call probes the provider,
+// closing the circuit if it succeeds and opening it again otherwise.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// breaker defaults.
+const (
+	DefaultThreshold = 5
+	DefaultCooldown  = 30 * time.Second
+)
+
+// circuit states.
+const (
+	stateClosed = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// Config are the circuit breaker settings.
+type Config struct {
+	// Threshold is how many consecutive failures open the circuit, defaults to DefaultThreshold.
+	Threshold int
+	// Cooldown is how long the circuit stays open before probing the provider, defaults to DefaultCooldown.
+	Cooldown time.Duration
+}
+
+// Wallet represents a wallet service failing fast while the wallet provider is unavailable.
+type Wallet struct {
+	provider WalletService
+
+	logger *log.Entry
+
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	opened   time.Time
+}
+
+// NewWallet returns a new instance of Wallet guarding the wallet provider.
+func NewWallet(provider WalletService, config Config) *Wallet {
+	w := &Wallet{
+		provider:  provider,
+		logger:    log.WithFields(log.Fields{"package": "breaker"}),
+		threshold: config.Threshold,
+		cooldown:  config.Cooldown,
+	}
+	if w.threshold <= 0 {
+		w.threshold = DefaultThreshold
+	}
+	if w.cooldown <= 0 {
+		w.cooldown = DefaultCooldown
+	}
+	return w
+}
+
+// Degraded returns whether the wallet provider is considered unavailable.
+func (w *Wallet) Degraded() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.state != stateClosed
+}
+
+// CreateUser creates the wallet of a user.
+func (w *Wallet) CreateUser(ctx context.Context, user string) (id string, err error) {
+	err = w.do(func() error {
+		id, err = w.provider.CreateUser(ctx, user)
+		return err
+	})
+	return id, err
+}
+
+// GetUserBalance returns the balance of the user.
+func (w *Wallet) GetUserBalance(ctx context.Context, user string) (balance coin.Amount, err error) {
+	err = w.do(func() error {
+		balance, err = w.provider.GetUserBalance(ctx, user)
+		return err
+	})
+	return balance, err
+}
+
+// Airdrop adds coins to the user balance.
+func (w *Wallet) Airdrop(ctx context.Context, user string, amount coin.Amount) error {
+	return w.do(func() error {
+		return w.provider.Airdrop(ctx, user, amount)
+	})
+}
+
+// GetRewarded rewards the user for finding a treasure.
+func (w *Wallet) GetRewarded(ctx context.Context, user string) (tx string, err error) {
+	err = w.do(func() error {
+		tx, err = w.provider.GetRewarded(ctx, user)
+		return err
+	})
+	return tx, err
+}
+
+// MakePayment charges the user.
+func (w *Wallet) MakePayment(ctx context.Context, user string, amount coin.Amount) (tx string, err error) {
+	err = w.do(func() error {
+		tx, err = w.provider.MakePayment(ctx, user, amount)
+		return err
+	})
+	return tx, err
+}
+
+// Transfer sends coins between users.
+func (w *Wallet) Transfer(ctx context.Context, from, to string, amount coin.Amount) (tx string, err error) {
+	err = w.do(func() error {
+		tx, err = w.provider.Transfer(ctx, from, to, amount)
+		return err
+	})
+	return tx, err
+}
+
+// Tip sends coins to the creator of a game.
+func (w *Wallet) Tip(ctx context.Context, from, to string, amount coin.Amount) (tx string, err error) {
+	err = w.do(func() error {
+		tx, err = w.provider.Tip(ctx, from, to, amount)
+		return err
+	})
+	return tx, err
+}
+
+// GetUserTransactions returns the most recent transactions of the user.
+func (w *Wallet) GetUserTransactions(ctx context.Context, user string) (transactions []coin.Transaction, err error) {
+	err = w.do(func() error {
+		transactions, err = w.provider.GetUserTransactions(ctx, user)
+		return err
+	})
+	return transactions, err
+}
+
+// EachUserTransaction calls fn for every transaction of the user.
+func (w *Wallet) EachUserTransaction(ctx context.Context, user string, fn func(coin.Transaction) error) error {
+	return w.do(func() error {
+		return w.provider.EachUserTransaction(ctx, user, fn)
+	})
+}
+
+// DecreaseTokens returns coins of the user to the pool.
+func (w *Wallet) DecreaseTokens(ctx context.Context, user string, amount coin.Amount) error {
+	return w.do(func() error {
+		return w.provider.DecreaseTokens(ctx, user, amount)
+	})
+}
+
+// RemoveTokens returns the full balance of the user to the pool.
+func (w *Wallet) RemoveTokens(ctx context.Context, user string) error {
+	return w.do(func() error {
+		return w.provider.RemoveTokens(ctx, user)
+	})
+}
+
+// do calls the wallet provider unless the circuit is open, recording the outcome.
+func (w *Wallet) do(call func() error) error {
+	if !w.allow() {
+		return coin.ErrWalletNotSent
+	}
+	err := call()
+	w.record(err)
+	return err
+}
+
+// allow returns whether a call can reach the wallet provider.
+// Once the cooldown elapses the first call is let through to probe the provider.
+func (w *Wallet) allow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch w.state {
+	case stateClosed:
+		return true
+	case stateOpen:
+		if time.Since(w.opened) < w.cooldown {
+			return false
+		}
+		w.state = stateHalfOpen
+		return true
+	default:
+		// a probe is in flight.
+		return false
+	}
+}
+
+// record updates the circuit with the outcome of a call.
+func (w *Wallet) record(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		// the caller gave up, which tells nothing about the provider.
+		if w.state == stateHalfOpen {
+			w.state = stateOpen
+		}
+	case errors.Is(err, coin.ErrWalletUnavailable):
+		w.failures++
+		if w.state == stateHalfOpen || (w.state == stateClosed && w.failures >= w.threshold) {
+			w.logger.WithFields(log.Fields{"failures": w.failures, "cooldown": w.cooldown}).Warn("wallet provider is unavailable, opening the circuit")
+			w.state = stateOpen
+			w.opened = time.Now()
+		}
+	default:
+		// any answer from the provider shows it is reachable.
+		if w.state != stateClosed {
+			w.logger.Info("wallet provider recovered, closing the circuit")
+		}
+		w.state = stateClosed
+		w.failures = 0
+	}
+}
+
+// WalletService defines the interface of the wallet provider.
+type WalletService interface {
+	CreateUser(ctx context.Context, user string) (string, error)
+	GetUserBalance(ctx context.Context, user string) (coin.Amount, error)
+	Airdrop(ctx context.Context, user string, amount coin.Amount) error
+	GetRewarded(ctx context.Context, user string) (string, error)
+	MakePayment(ctx context.Context, user string, amount coin.Amount) (string, error)
+	Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error)
+	Tip(ctx context.Context, from, to string, amount coin.Amount) (string, error)
+	GetUserTransactions(ctx context.Context, user string) ([]coin.Transaction, error)
+	EachUserTransaction(ctx context.Context, user string, fn func(coin.Transaction) error) error
+	DecreaseTokens(ctx context.Context, user string, amount coin.Amount) error
+	RemoveTokens(ctx context.Context, user string) error
+}
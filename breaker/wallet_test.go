@@ -0,0 +1,108 @@
+package breaker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/breaker"
+	"github.com/stretchr/testify/assert"
+)
+
+// wallets is a fake wallet provider counting the calls that reach it.
+type wallets struct {
+	breaker.WalletService
+
+	mu    sync.Mutex
+	err   error
+	calls int
+}
+
+func (w *wallets) GetUserBalance(ctx context.Context, user string) (coin.Amount, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.calls++
+	return coin.Coin, w.err
+}
+
+// set changes the error of the fake provider.
+func (w *wallets) set(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = err
+}
+
+// count returns how many calls reached the fake provider.
+func (w *wallets) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.calls
+}
+
+// TestWallet_Open tests failing fast once the provider failed too many times in a row.
+func TestWallet_Open(t *testing.T) {
+	w := &wallets{err: coin.ErrWalletUnavailable}
+	b := breaker.NewWallet(w, breaker.Config{Threshold: 3, Cooldown: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		_, err := b.GetUserBalance(context.Background(), "luffy")
+		assert.Equal(t, coin.ErrWalletUnavailable, err)
+	}
+
+	// the calls refused are told apart from the calls that failed once sent.
+	for i := 0; i < 2; i++ {
+		_, err := b.GetUserBalance(context.Background(), "luffy")
+		assert.Equal(t, coin.ErrWalletNotSent, err)
+		assert.True(t, errors.Is(err, coin.ErrWalletUnavailable))
+	}
+	assert.Equal(t, 3, w.count())
+	assert.True(t, b.Degraded())
+}
+
+// TestWallet_Reachable tests errors answered by the provider keep the circuit closed.
+func TestWallet_Reachable(t *testing.T) {
+	w := &wallets{err: coin.ErrInvalidWallet}
+	b := breaker.NewWallet(w, breaker.Config{Threshold: 2, Cooldown: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		_, err := b.GetUserBalance(context.Background(), "luffy")
+		assert.Equal(t, coin.ErrInvalidWallet, err)
+	}
+	assert.Equal(t, 3, w.count())
+	assert.False(t, b.Degraded())
+
+	// the failures must be consecutive.
+	for _, err := range []error{coin.ErrWalletUnavailable, nil, coin.ErrWalletUnavailable} {
+		w.set(err)
+		b.GetUserBalance(context.Background(), "luffy")
+	}
+	assert.False(t, b.Degraded())
+}
+
+// TestWallet_Probe tests probing the provider once the cooldown elapses.
+func TestWallet_Probe(t *testing.T) {
+	w := &wallets{err: coin.ErrWalletUnavailable}
+	b := breaker.NewWallet(w, breaker.Config{Threshold: 1, Cooldown: 10 * time.Millisecond})
+
+	b.GetUserBalance(context.Background(), "luffy")
+	assert.True(t, b.Degraded())
+
+	// the failed probe opens the circuit again.
+	time.Sleep(20 * time.Millisecond)
+	_, err := b.GetUserBalance(context.Background(), "luffy")
+	assert.Equal(t, coin.ErrWalletUnavailable, err)
+	assert.Equal(t, 2, w.count())
+	b.GetUserBalance(context.Background(), "luffy")
+	assert.Equal(t, 2, w.count())
+
+	// the successful probe closes it.
+	w.set(nil)
+	time.Sleep(20 * time.Millisecond)
+	balance, err := b.GetUserBalance(context.Background(), "luffy")
+	assert.Nil(t, err)
+	assert.Equal(t, coin.Coin, balance)
+	assert.False(t, b.Degraded())
+}
@@ -0,0 +1,293 @@
+// Package cache keeps the balances and ledgers of the users in memory, in front of the wallet provider.
+//
+// Values younger than the TTL are served from memory. Older values are still served, up to MaxStale,
+// while they are refreshed in the background, so the pages render when the provider is slow or down.
+// The values of a user are dropped whenever the platform moves their coins, and again when the move settles.
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// cache defaults.
+const (
+	DefaultTTL      = 30 * time.Second
+	DefaultMaxStale = 10 * time.Minute
+
+	// refreshTimeout bounds the background refreshes, detached from the requests.
+	refreshTimeout = 30 * time.Second
+)
+
+// cached value kinds.
+const (
+	kindBalance      = "balance"
+	kindTransactions = "transactions"
+	kindLedger       = "ledger"
+)
+
+// Config are the cache settings.
+type Config struct {
+	// TTL is how long values are served without being refreshed, defaults to DefaultTTL.
+	TTL time.Duration
+	// MaxStale is how long values are served while they cannot be refreshed, defaults to DefaultMaxStale.
+	MaxStale time.Duration
+}
+
+// Wallet represents a wallet service caching the balances and ledgers of the users.
+// The methods it does not override are served by the wallet provider.
+type Wallet struct {
+	WalletService
+
+	logger *log.Entry
+
+	ttl      time.Duration
+	maxStale time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// bumped when the values of a wallet are dropped, discarding the loads started before.
+	epochs map[string]uint64
+	swept  time.Time
+}
+
+// entry represents a cached value.
+type entry struct {
+	value      interface{}
+	fetched    time.Time
+	refreshing bool
+}
+
+// NewWallet returns a new instance of Wallet caching the wallet provider.
+func NewWallet(wallets WalletService, config Config) *Wallet {
+	w := &Wallet{
+		WalletService: wallets,
+		logger:        log.WithFields(log.Fields{"package": "cache"}),
+		ttl:           config.TTL,
+		maxStale:      config.MaxStale,
+		entries:       make(map[string]*entry),
+		epochs:        make(map[string]uint64),
+		swept:         time.Now(),
+	}
+	if w.ttl <= 0 {
+		w.ttl = DefaultTTL
+	}
+	if w.maxStale < w.ttl {
+		w.maxStale = DefaultMaxStale
+	}
+	return w
+}
+
+// GetUserBalance returns the cached balance of the user.
+func (w *Wallet) GetUserBalance(ctx context.Context, user string) (coin.Amount, error) {
+	v, err := w.get(ctx, kindBalance, user, func(ctx context.Context) (interface{}, error) {
+		return w.WalletService.GetUserBalance(ctx, user)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return v.(coin.Amount), nil
+}
+
+// GetUserTransactions returns the cached most recent page of transactions of the user.
+func (w *Wallet) GetUserTransactions(ctx context.Context, user string) ([]coin.Transaction, error) {
+	v, err := w.get(ctx, kindTransactions, user, func(ctx context.Context) (interface{}, error) {
+		return w.WalletService.GetUserTransactions(ctx, user)
+	})
+	if err != nil {
+		return []coin.Transaction{}, err
+	}
+	return append([]coin.Transaction(nil), v.([]coin.Transaction)...), nil
+}
+
+// EachUserTransaction calls fn for every transaction in the cached ledger of the user, newest first.
+// The full ledger is loaded on a miss, and the first error of fn is returned as is.
+func (w *Wallet) EachUserTransaction(ctx context.Context, user string, fn func(coin.Transaction) error) error {
+	v, err := w.get(ctx, kindLedger, user, func(ctx context.Context) (interface{}, error) {
+		transactions := make([]coin.Transaction, 0)
+		err := w.WalletService.EachUserTransaction(ctx, user, func(t coin.Transaction) error {
+			transactions = append(transactions, t)
+			return nil
+		})
+		return transactions, err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, t := range v.([]coin.Transaction) {
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BalanceDate returns when the cached balance of the user was fetched, and whether it is being refreshed.
+// It returns the zero time if the balance is not cached.
+func (w *Wallet) BalanceDate(user string) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	e, ok := w.entries[key(kindBalance, user)]
+	if !ok {
+		return time.Time{}, false
+	}
+	return e.fetched, time.Since(e.fetched) >= w.ttl
+}
+
+// Airdrop adds coins to the user balance, dropping their cached values.
+func (w *Wallet) Airdrop(ctx context.Context, user string, amount coin.Amount) error {
+	defer w.Invalidate(user)
+	return w.WalletService.Airdrop(ctx, user, amount)
+}
+
+// GetRewarded rewards the user, dropping their cached values.
+func (w *Wallet) GetRewarded(ctx context.Context, user string) (string, error) {
+	defer w.Invalidate(user)
+	return w.WalletService.GetRewarded(ctx, user)
+}
+
+// MakePayment charges the user, dropping their cached values.
+func (w *Wallet) MakePayment(ctx context.Context, user string, amount coin.Amount) (string, error) {
+	defer w.Invalidate(user)
+	return w.WalletService.MakePayment(ctx, user, amount)
+}
+
+// Transfer sends coins between users, dropping the cached values of both.
+func (w *Wallet) Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	defer w.Invalidate(from, to)
+	return w.WalletService.Transfer(ctx, from, to, amount)
+}
+
+// Tip sends coins to the creator of a game, dropping the cached values of both users.
+func (w *Wallet) Tip(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	defer w.Invalidate(from, to)
+	return w.WalletService.Tip(ctx, from, to, amount)
+}
+
+// DecreaseTokens returns coins of the user to the pool, dropping their cached values.
+func (w *Wallet) DecreaseTokens(ctx context.Context, user string, amount coin.Amount) error {
+	defer w.Invalidate(user)
+	return w.WalletService.DecreaseTokens(ctx, user, amount)
+}
+
+// RemoveTokens returns the full balance of the user to the pool, dropping their cached values.
+func (w *Wallet) RemoveTokens(ctx context.Context, user string) error {
+	defer w.Invalidate(user)
+	return w.WalletService.RemoveTokens(ctx, user)
+}
+
+// Settled drops the cached values of the users whose coins the transfer moved, once it settles.
+func (w *Wallet) Settled(transfer coin.Transfer) {
+	users := []string{transfer.Wallet}
+	if transfer.Recipient != "" {
+		users = append(users, transfer.Recipient)
+	}
+	w.Invalidate(users...)
+}
+
+// Invalidate drops the cached values of the users.
+func (w *Wallet) Invalidate(users ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, u := range users {
+		for _, k := range []string{kindBalance, kindTransactions, kindLedger} {
+			delete(w.entries, key(k, u))
+		}
+		w.epochs[u]++
+	}
+}
+
+// get returns the cached value of the user, loading it when missing or too stale,
+// and refreshing it in the background when older than the TTL.
+func (w *Wallet) get(ctx context.Context, kind, user string, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	k := key(kind, user)
+
+	w.mu.Lock()
+	epoch := w.epochs[user]
+	if e, ok := w.entries[k]; ok {
+		age := time.Since(e.fetched)
+		if age < w.maxStale {
+			if age >= w.ttl && !e.refreshing {
+				e.refreshing = true
+				go w.refresh(k, user, epoch, load)
+			}
+			w.mu.Unlock()
+			return e.value, nil
+		}
+	}
+	w.mu.Unlock()
+
+	v, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.store(k, user, epoch, v)
+	return v, nil
+}
+
+// refresh reloads a stale value, keeping the stale one if the wallet provider fails.
+func (w *Wallet) refresh(k, user string, epoch uint64, load func(ctx context.Context) (interface{}, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+	defer cancel()
+
+	v, err := load(ctx)
+	if err != nil {
+		w.logger.WithFields(log.Fields{"key": k, "error": err}).Warn("failed to refresh cached value, serving it stale")
+		w.mu.Lock()
+		if e, ok := w.entries[k]; ok {
+			e.refreshing = false
+		}
+		w.mu.Unlock()
+		return
+	}
+	w.store(k, user, epoch, v)
+}
+
+// store caches a value loaded at the epoch, unless the values of the user were dropped since.
+func (w *Wallet) store(k, user string, epoch uint64, v interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.epochs[user] != epoch {
+		return
+	}
+	now := time.Now()
+	w.entries[k] = &entry{value: v, fetched: now}
+
+	// drop the values too stale to be served.
+	if now.Sub(w.swept) >= w.maxStale {
+		for k, e := range w.entries {
+			if now.Sub(e.fetched) >= w.maxStale {
+				delete(w.entries, k)
+			}
+		}
+		w.swept = now
+	}
+}
+
+// key returns the key of a cached value of the user.
+func key(kind, user string) string {
+	return kind + ":" + user
+}
+
+// WalletService defines the interface of the wallet provider.
+type WalletService interface {
+	CreateUser(ctx context.Context, user string) (string, error)
+	GetUserBalance(ctx context.Context, user string) (coin.Amount, error)
+	Airdrop(ctx context.Context, user string, amount coin.Amount) error
+	GetRewarded(ctx context.Context, user string) (string, error)
+	MakePayment(ctx context.Context, user string, amount coin.Amount) (string, error)
+	Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error)
+	Tip(ctx context.Context, from, to string, amount coin.Amount) (string, error)
+	GetUserTransactions(ctx context.Context, user string) ([]coin.Transaction, error)
+	EachUserTransaction(ctx context.Context, user string, fn func(coin.Transaction) error) error
+	DecreaseTokens(ctx context.Context, user string, amount coin.Amount) error
+	RemoveTokens(ctx context.Context, user string) error
+}
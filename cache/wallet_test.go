@@ -0,0 +1,225 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/cache"
+	"github.com/pmdcosta/treasure-coin/tracker"
+	"github.com/stretchr/testify/assert"
+)
+
+// wallets is a fake wallet provider counting the balance and ledger reads.
+type wallets struct {
+	cache.WalletService
+
+	mu      sync.Mutex
+	balance coin.Amount
+	err     error
+	reads   int
+}
+
+func (w *wallets) GetUserBalance(ctx context.Context, user string) (coin.Amount, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.reads++
+	return w.balance, w.err
+}
+
+func (w *wallets) EachUserTransaction(ctx context.Context, user string, fn func(coin.Transaction) error) error {
+	w.mu.Lock()
+	w.reads++
+	w.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		if err := fn(coin.Transaction{ID: string(rune('a' + i))}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *wallets) MakePayment(ctx context.Context, user string, amount coin.Amount) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.balance = w.balance.Sub(amount)
+	return "tx", nil
+}
+
+func (w *wallets) Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	return "", coin.ErrWalletUnavailable
+}
+
+// set changes the balance and error of the fake provider.
+func (w *wallets) set(balance coin.Amount, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.balance, w.err = balance, err
+}
+
+// count returns how many reads reached the fake provider.
+func (w *wallets) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reads
+}
+
+// TestWallet_Fresh tests serving fresh values from memory.
+func TestWallet_Fresh(t *testing.T) {
+	w := &wallets{balance: coin.Coin}
+	c := cache.NewWallet(w, cache.Config{TTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		b, err := c.GetUserBalance(context.Background(), "luffy")
+		assert.Nil(t, err)
+		assert.Equal(t, coin.Coin, b)
+	}
+	assert.Equal(t, 1, w.count())
+
+	date, stale := c.BalanceDate("luffy")
+	assert.WithinDuration(t, time.Now(), date, time.Second)
+	assert.False(t, stale)
+
+	// nothing is cached for other users.
+	date, _ = c.BalanceDate("zoro")
+	assert.True(t, date.IsZero())
+}
+
+// TestWallet_Stale tests serving stale values while they are refreshed in the background.
+func TestWallet_Stale(t *testing.T) {
+	w := &wallets{balance: coin.Coin}
+	c := cache.NewWallet(w, cache.Config{TTL: 10 * time.Millisecond, MaxStale: time.Minute})
+
+	_, err := c.GetUserBalance(context.Background(), "luffy")
+	assert.Nil(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	// the provider fails, the stale balance is served.
+	w.set(coin.Coin.Mul(2), coin.ErrWalletUnavailable)
+	b, err := c.GetUserBalance(context.Background(), "luffy")
+	assert.Nil(t, err)
+	assert.Equal(t, coin.Coin, b)
+	_, stale := c.BalanceDate("luffy")
+	assert.True(t, stale)
+	assert.Eventually(t, func() bool { return w.count() == 2 }, time.Second, time.Millisecond)
+
+	// the provider recovers, the next refresh stores the new balance.
+	w.set(coin.Coin.Mul(2), nil)
+	assert.Eventually(t, func() bool {
+		b, _ := c.GetUserBalance(context.Background(), "luffy")
+		return b == coin.Coin.Mul(2)
+	}, time.Second, 5*time.Millisecond)
+}
+
+// TestWallet_Expired tests loading values too stale to be served.
+func TestWallet_Expired(t *testing.T) {
+	w := &wallets{balance: coin.Coin}
+	c := cache.NewWallet(w, cache.Config{TTL: 5 * time.Millisecond, MaxStale: 10 * time.Millisecond})
+
+	_, err := c.GetUserBalance(context.Background(), "luffy")
+	assert.Nil(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	w.set(0, coin.ErrWalletUnavailable)
+	_, err = c.GetUserBalance(context.Background(), "luffy")
+	assert.Equal(t, coin.ErrWalletUnavailable, err)
+}
+
+// TestWallet_Invalidate tests dropping the cached values when the platform moves coins.
+func TestWallet_Invalidate(t *testing.T) {
+	w := &wallets{balance: coin.Coin.Mul(3)}
+	c := cache.NewWallet(w, cache.Config{TTL: time.Minute})
+
+	_, err := c.GetUserBalance(context.Background(), "luffy")
+	assert.Nil(t, err)
+	_, err = c.MakePayment(context.Background(), "luffy", coin.Coin)
+	assert.Nil(t, err)
+
+	b, err := c.GetUserBalance(context.Background(), "luffy")
+	assert.Nil(t, err)
+	assert.Equal(t, coin.Coin.Mul(2), b)
+	assert.Equal(t, 2, w.count())
+
+	// failed transfers drop the values of both users too.
+	_, err = c.GetUserBalance(context.Background(), "zoro")
+	assert.Nil(t, err)
+	_, err = c.Transfer(context.Background(), "luffy", "zoro", coin.Coin)
+	assert.Equal(t, coin.ErrWalletUnavailable, err)
+	date, _ := c.BalanceDate("luffy")
+	assert.True(t, date.IsZero())
+	date, _ = c.BalanceDate("zoro")
+	assert.True(t, date.IsZero())
+}
+
+// TestWallet_Settled tests dropping the cached values of both users when the tracker settles their transfer.
+func TestWallet_Settled(t *testing.T) {
+	w := &wallets{balance: coin.Coin.Mul(3)}
+	c := cache.NewWallet(w, cache.Config{TTL: time.Minute})
+
+	for _, u := range []string{"luffy", "zoro", "nami"} {
+		_, err := c.GetUserBalance(context.Background(), u)
+		assert.Nil(t, err)
+	}
+
+	tr := tracker.NewTracker(transfers{}, games{}, nil)
+	tr.Handle(c.Settled)
+	assert.Nil(t, tr.Track(coin.Transfer{ID: "tx", Event: coin.EventTransfer, Wallet: "luffy", Recipient: "zoro", Amount: coin.Coin}))
+	assert.Nil(t, tr.Update(coin.Transfer{ID: "tx", Status: coin.TransferComplete}))
+
+	date, _ := c.BalanceDate("luffy")
+	assert.True(t, date.IsZero())
+	date, _ = c.BalanceDate("zoro")
+	assert.True(t, date.IsZero())
+	date, _ = c.BalanceDate("nami")
+	assert.False(t, date.IsZero())
+}
+
+// transfers is an in-memory transfer store.
+type transfers map[string]coin.Transfer
+
+func (s transfers) Add(t coin.Transfer) error  { s[t.ID] = t; return nil }
+func (s transfers) Save(t coin.Transfer) error { s[t.ID] = t; return nil }
+func (s transfers) Pending() []coin.Transfer   { return nil }
+func (s transfers) Find(id string) (coin.Transfer, error) {
+	t, ok := s[id]
+	if !ok {
+		return t, coin.Error("not found")
+	}
+	return t, nil
+}
+
+// games is an empty game store.
+type games struct{}
+
+func (games) Find(id string) (coin.Game, error)                 { return coin.Game{}, coin.Error("not found") }
+func (games) Update(id string, fn func(*coin.Game) error) error { return coin.Error("not found") }
+func (games) List() map[string]coin.Game                        { return nil }
+
+// TestWallet_EachUserTransaction tests iterating the cached ledger.
+func TestWallet_EachUserTransaction(t *testing.T) {
+	w := &wallets{}
+	c := cache.NewWallet(w, cache.Config{TTL: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		var ids []string
+		err := c.EachUserTransaction(context.Background(), "luffy", func(tx coin.Transaction) error {
+			ids = append(ids, tx.ID)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, ids)
+	}
+	assert.Equal(t, 1, w.count())
+
+	// the errors of fn stop the iteration.
+	stop := coin.Error("stop")
+	var n int
+	err := c.EachUserTransaction(context.Background(), "luffy", func(tx coin.Transaction) error {
+		n++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, n)
+}
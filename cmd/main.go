@@ -1,53 +1,356 @@
 package main
 
 import (
-	"github.com/namsral/flag"
+	"context"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/breaker"
+	"github.com/pmdcosta/treasure-coin/cache"
+	"github.com/pmdcosta/treasure-coin/config"
 	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/pmdcosta/treasure-coin/events"
+	"github.com/pmdcosta/treasure-coin/evm"
 	"github.com/pmdcosta/treasure-coin/http"
 	"github.com/pmdcosta/treasure-coin/http/handlers"
 	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/logging"
+	"github.com/pmdcosta/treasure-coin/metrics"
+	"github.com/pmdcosta/treasure-coin/openid"
 	"github.com/pmdcosta/treasure-coin/ost"
+	"github.com/pmdcosta/treasure-coin/queue"
+	"github.com/pmdcosta/treasure-coin/tracker"
+	"github.com/pmdcosta/treasure-coin/wallet"
+	"github.com/pmdcosta/treasure-coin/web"
+	"github.com/pmdcosta/treasure-coin/webhooks"
+	log "github.com/sirupsen/logrus"
 )
 
 func main() {
-	var (
-		dbPath       = flag.String("db-path", "app.db", "Choose database path.")
-		serverHost   = flag.String("server-host", "https://treasurecoin.powertrip.pt", "Choose server host.")
-		serverPort   = flag.String("server-port", "8080", "Choose server port to bind to.")
-		serverCert   = flag.String("server-cert", "ssl/certificate.pem", "Choose server certificate for ssl.")
-		serverSecret = flag.String("server-secret", "ssl/secret.pem", "Choose server secret for ssl.")
-		serverSSL    = flag.Bool("server-ssl", false, "Choose wheather the server should use ssl.")
-		ostUrl       = flag.String("ost-url", "", "Choose the OST API base url.")
-		ostKey       = flag.String("ost-key", "", "Choose the OST API key.")
-		ostSecret    = flag.String("ost-secret", "", "Choose the OST API secret.")
-		ostCompany   = flag.String("ost-company", "", "Choose the OST API company ID.")
-	)
-	flag.Parse()
+	// print the effective configuration.
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "check" {
+		os.Exit(checkConfig(os.Args[3:]))
+	}
 
-	// get ost config.
-	config := ost.Config{}
-	config.LoadCred(".env", *ostUrl, *ostKey, *ostSecret, *ostCompany)
+	// load the configuration.
+	cfg, err := config.Load(os.Args[0], os.Args[1:], os.Stderr)
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// log in the configured format, masking the secrets.
+	if err := logging.Configure(log.StandardLogger(), os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// stop on interrupt or termination.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	if err := run(ctx, cfg); err != nil {
+		log.WithError(err).Error("server failed")
+		os.Exit(1)
+	}
+	log.Info("server stopped")
+}
+
+// run serves the application until the context is done, then stops accepting requests, waits for those in flight,
+// stops the background workers and closes the database.
+func run(ctx context.Context, cfg config.Config) error {
 	// instantiate the database client and services.
-	db := database.NewClient(*dbPath)
+	db := database.NewClient(cfg.Database.Path)
 	if err := db.Open(); err != nil {
-		panic(err)
+		return err
 	}
+	defer db.Close()
 
-	// instantiate the ost client service.
-	st := ost.NewClient(config)
+	// grant the admin role to the configured user.
+	if cfg.Auth.AdminEmail != "" {
+		if u, err := db.UserService().Find(cfg.Auth.AdminEmail); err == nil && u.Role != coin.RoleAdmin {
+			u.Role = coin.RoleAdmin
+			if err := db.UserService().Save(u); err != nil {
+				return err
+			}
+		}
+	}
+
+	// collect the metrics of the application.
+	mt := metrics.NewMetrics(db, db.SessionService())
+
+	// instantiate the wallet provider.
+	st, wh, err := newWalletProvider(ctx, cfg.Wallet, mt)
+	if err != nil {
+		return err
+	}
+
+	// follow the transfers until they settle, polling those whose webhook never arrives.
+	tr := tracker.NewTracker(db.TransferService(), db.GameService(), st)
+
+	// stop calling the wallet provider while it is unavailable.
+	br := breaker.NewWallet(st, breaker.Config{
+		Threshold: cfg.Wallet.BreakerThreshold,
+		Cooldown:  time.Duration(cfg.Wallet.BreakerCooldown),
+	})
+
+	// cache the balances and ledgers, serving them stale while the wallet provider is unavailable.
+	var ws handlers.WalletService = br
+	if cfg.Wallet.CacheTTL > 0 {
+		cw := cache.NewWallet(br, cache.Config{
+			TTL:      time.Duration(cfg.Wallet.CacheTTL),
+			MaxStale: time.Duration(cfg.Wallet.CacheMaxStale),
+		})
+		tr.Handle(cw.Settled)
+		ws = cw
+	}
+
+	// replay the sign-ups and rewards deferred while the wallet provider was unavailable.
+	qu := queue.NewQueue(db.OperationService(), db.UserService(), db.GameService(), ws, tr)
+
+	// publish the game events to the pages and the webhooks following them.
+	bus := events.NewBus()
+
+	// deliver the game events to the webhooks, queued before the requests publishing them are answered.
+	dp := webhooks.NewDispatcher(db.WebhookService(), db.DeliveryService(), webhooks.Config{
+		Timeout:      time.Duration(cfg.Webhooks.Timeout),
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		Backoff:      time.Duration(cfg.Webhooks.Backoff),
+		AllowPrivate: cfg.Webhooks.AllowPrivate,
+	})
+	bus.Handle(dp.Enqueue)
 
 	// instantiate the middleware.
-	am := middlewares.NewAuthMiddleware(db.UserService(), db.SessionService())
+	am := middlewares.NewAuthMiddleware(db.UserService(), db.SessionService(), db.TokenService())
+	gm := middlewares.NewGameMiddleware(db.GameService())
+	wm := middlewares.NewWalletMiddleware(br)
+	mm := middlewares.NewMetricsMiddleware(mt)
 
 	// instantiate the handlers.
-	dh := handlers.NewDefaultHandler(am, db.GameService(), db.UserService(), st)
-	ah := handlers.NewAuthHandler(am, db.UserService(), db.GameService(), st)
-	gh := handlers.NewGameHandler(am, db.GameService(), st, *serverHost)
+	dh := handlers.NewDefaultHandler(am, db.GameService(), db.UserService(), ws)
+	ah := handlers.NewAuthHandler(am, db.UserService(), db.GameService(), ws, qu, mt, cfg.Game.SignupAirdrop)
+	gh := handlers.NewGameHandler(am, gm, db.GameService(), ws, tr, qu, mt, bus, cfg.Server.Host, cfg.Server.Codes)
+	tr.Handle(gh.Settled)
+	ach := handlers.NewAccountHandler(am, db.UserService(), db.GameService(), ws, qu, db.WebhookService())
+	adh := handlers.NewAdminHandler(am, db.UserService(), db.GameService(), ws)
+	wah := handlers.NewWalletHandler(am, gm, db.UserService(), ws, tr, db.TransferService(), handlers.TransferLimits{
+		Max:   cfg.Game.MaxTransfer,
+		Daily: cfg.Game.DailyTransfer,
+	})
+	eh := handlers.NewEventHandler(am, gm, db.GameService(), bus)
+	hkh := handlers.NewHookHandler(am, db.WebhookService(), db.DeliveryService(), db.GameService(), dp)
+	hh := handlers.NewHealthHandler(db, st, mt.Handler(), cfg.Server.MetricsToken)
+
+	// the webhooks are only received from the providers sending them.
+	hs := []http.Handler{hh, dh, ah, gh, eh, hkh, ach, adh, wah}
+	if wh != nil {
+		hs = append(hs, handlers.NewWebhookHandler(wh, tr))
+	}
+
+	// instantiate the openid connect sign in, if configured.
+	if cfg.Auth.OIDC.Issuer != "" {
+		oc, err := openid.NewClient(ctx, openid.Config{
+			Name:         cfg.Auth.OIDC.Name,
+			Issuer:       cfg.Auth.OIDC.Issuer,
+			ClientID:     cfg.Auth.OIDC.ClientID,
+			ClientSecret: cfg.Auth.OIDC.ClientSecret,
+			RedirectURL:  cfg.Server.Host + "/auth/oidc/callback",
+		})
+		if err != nil {
+			return err
+		}
+
+		// registered first so the sign in pages can link to the provider.
+		hs = append([]http.Handler{handlers.NewOIDCHandler(am, oc, db.UserService(), ws, qu, mt, cfg.Game.SignupAirdrop)}, hs...)
+	}
+
+	// the QR codes of the treasures are generated at runtime, so they are kept on the disk.
+	if err := os.MkdirAll(cfg.Server.Codes, 0755); err != nil {
+		return err
+	}
 
 	// start the server.
-	router := http.NewServer(":"+*serverPort, *serverCert, *serverSecret, *serverSSL, dh, ah, gh)
-	if err := router.Open(); err != nil {
-		panic(err)
+	var redirect string
+	if cfg.Server.RedirectPort != 0 {
+		redirect = ":" + strconv.Itoa(cfg.Server.RedirectPort)
+	}
+	router := http.NewServer(http.Config{
+		Port: ":" + strconv.Itoa(cfg.Server.Port),
+		Timeouts: http.Timeouts{
+			Read:  time.Duration(cfg.Server.ReadTimeout),
+			Write: time.Duration(cfg.Server.WriteTimeout),
+			Idle:  time.Duration(cfg.Server.IdleTimeout),
+		},
+		TLS: http.TLS{
+			Enabled:      cfg.Server.SSL,
+			Cert:         cfg.Server.Cert,
+			Key:          cfg.Server.Key,
+			RedirectPort: redirect,
+			Host:         cfg.Server.Host,
+			HSTS:         time.Duration(cfg.Server.HSTSMaxAge),
+		},
+		ContentSecurityPolicy: cfg.Server.ContentSecurityPolicy,
+		Files: http.Files{
+			Templates: web.Templates(cfg.Server.Assets),
+			Assets:    web.Assets(cfg.Server.Assets),
+			Codes:     cfg.Server.Codes,
+			Reload:    cfg.Server.Assets != "",
+		},
+	}, hs...)
+	router.Use(mm.ObserveRequests(), wm.SetWalletStatus(), am.SetUserStatus())
+
+	// the background workers run until the server has stopped, and always before the database is closed.
+	workers, stopWorkers := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		stopWorkers()
+		wg.Wait()
+	}()
+	for _, worker := range []func(ctx context.Context){
+		func(ctx context.Context) { tr.Run(ctx, time.Duration(cfg.Wallet.PollInterval)) },
+		func(ctx context.Context) { qu.Run(ctx, time.Duration(cfg.Wallet.BreakerCooldown)) },
+		func(ctx context.Context) { dp.Run(ctx, webhooks.DefaultInterval) },
+	} {
+		wg.Add(1)
+		go func(worker func(ctx context.Context)) {
+			defer wg.Done()
+			worker(workers)
+		}(worker)
+	}
+
+	// reload the certificate on hangup.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-workers.Done():
+				return
+			case <-hup:
+				if err := router.ReloadCertificate(); err != nil {
+					log.WithError(err).Error("failed to reload the certificate, keeping the current one")
+				}
+			}
+		}
+	}()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- router.Open()
+	}()
+
+	// wait for a signal, or for the server to fail.
+	select {
+	case err = <-served:
+	case <-ctx.Done():
+		log.Info("shutting down")
+		// the event streams never end on their own.
+		bus.Close()
+		shutdown, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+		defer cancel()
+		if err = router.Close(shutdown); err != nil {
+			log.WithError(err).Warn("requests still in flight were dropped")
+		}
+		err = <-served
+	}
+	return err
+}
+
+// walletProvider defines the interface of the wallet providers, able to report the status of their transactions.
+type walletProvider interface {
+	breaker.WalletService
+	tracker.StatusChecker
+	handlers.WalletPinger
+}
+
+// newWalletProvider instantiates the configured wallet provider, and its webhook parser if it sends webhooks.
+func newWalletProvider(ctx context.Context, cfg config.WalletConfig, mt *metrics.Metrics) (walletProvider, handlers.WebhookParser, error) {
+	switch cfg.Provider {
+	case config.ProviderEVM:
+		funding, _ := new(big.Int).SetString(cfg.EVM.GasFunding, 10)
+		ec := evm.NewClient(evm.Config{
+			URL:        cfg.EVM.URL,
+			Token:      cfg.EVM.Token,
+			Treasury:   cfg.EVM.Treasury,
+			Passphrase: cfg.EVM.Passphrase,
+			GasFunding: funding,
+			StartBlock: uint64(cfg.EVM.StartBlock),
+			LogRange:   uint64(cfg.EVM.LogRange),
+			Timeout:    time.Duration(cfg.Timeout),
+			Observer:   mt,
+		})
+		if err := ec.Setup(ctx); err != nil {
+			return nil, nil, err
+		}
+		return wallet.NewService(ec, wallet.Config{Treasury: cfg.EVM.Treasury, Reward: cfg.EVM.Reward}), nil, nil
+	}
+
+	// get ost config.
+	wc := ost.Config{
+		Key:           cfg.Key,
+		Secret:        cfg.Secret,
+		Url:           cfg.URL,
+		Company:       cfg.Company,
+		Timeout:       time.Duration(cfg.Timeout),
+		MaxRetries:    cfg.MaxRetries,
+		CreateActions: cfg.CreateActions,
+		WebhookSecret: cfg.WebhookSecret,
+		Observer:      mt,
+		Actions: ost.Actions{
+			Reward:   cfg.Actions.Reward,
+			Payment:  cfg.Actions.Payment,
+			Decrease: cfg.Actions.Decrease,
+			Transfer: cfg.Actions.Transfer,
+			Tip:      cfg.Actions.Tip,
+		},
+	}
+	if wc.MaxRetries == 0 {
+		// the ost client treats zero as the default.
+		wc.MaxRetries = -1
+	}
+
+	// instantiate the ost client service.
+	st := ost.NewClient(wc)
+	if err := st.SetupActions(ctx); err != nil {
+		return nil, nil, err
+	}
+	return st, st, nil
+}
+
+// checkConfig prints the effective configuration and reports whether it is valid.
+func checkConfig(args []string) int {
+	cfg, err := config.Load("config check", args, os.Stderr)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	fmt.Println(cfg)
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+	fmt.Fprintln(os.Stderr, "configuration is valid")
+	return 0
 }
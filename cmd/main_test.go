@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin/config"
+	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/pmdcosta/treasure-coin/evm/evmtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Node is a proxy to a simulated chain, able to hold the readiness pings until they are released.
+type Node struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	held    chan struct{}
+	release chan struct{}
+}
+
+// NewNode returns a new instance of Node in front of the chain.
+func NewNode(chain *evmtest.Chain) *Node {
+	n := &Node{}
+	n.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if bytes.Contains(body, []byte(`"eth_blockNumber"`)) {
+			n.mu.Lock()
+			held, release := n.held, n.release
+			n.held = nil
+			n.mu.Unlock()
+			if held != nil {
+				close(held)
+				<-release
+			}
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		chain.Server.Config.Handler.ServeHTTP(w, r)
+	}))
+	return n
+}
+
+// Hold holds the next readiness ping, returning a channel closed once it arrives and a function releasing it.
+// The pings before it are answered right away.
+func (n *Node) Hold() (<-chan struct{}, func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.held, n.release = make(chan struct{}), make(chan struct{})
+	return n.held, func() { close(n.release) }
+}
+
+// freePort returns a port nothing listens on.
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// TestRun_Shutdown tests the requests in flight are answered before the workers stop and the database is closed.
+func TestRun_Shutdown(t *testing.T) {
+	chain := evmtest.NewChain()
+	defer chain.Close()
+	node := NewNode(chain)
+	defer node.Close()
+
+	cfg := config.Default()
+	cfg.Server.Port = freePort(t)
+	cfg.Server.Codes = t.TempDir()
+	cfg.Database.Path = filepath.Join(t.TempDir(), "app.db")
+	cfg.Wallet.Provider = config.ProviderEVM
+	cfg.Wallet.EVM.URL = node.URL
+	cfg.Wallet.EVM.Token = chain.Token
+	cfg.Wallet.EVM.Treasury = chain.Treasury
+	cfg.Wallet.EVM.Passphrase = evmtest.Passphrase
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, cfg)
+	}()
+
+	// wait for the server to listen.
+	url := "http://127.0.0.1:" + strconv.Itoa(cfg.Server.Port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url + "/healthz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a readiness check is in flight when the server is stopped.
+	held, release := node.Hold()
+	answered := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/readyz")
+		if err != nil {
+			answered <- 0
+			return
+		}
+		resp.Body.Close()
+		answered <- resp.StatusCode
+	}()
+	<-held
+	cancel()
+
+	// no more connections are accepted, while the request in flight is still waited for.
+	assert.Eventually(t, func() bool {
+		_, err := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(cfg.Server.Port))
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("run returned with a request in flight: %v", err)
+	default:
+	}
+
+	release()
+	assert.Equal(t, http.StatusOK, <-answered)
+	assert.Nil(t, <-done)
+
+	// the database was closed.
+	db := database.NewClient(cfg.Database.Path)
+	assert.Nil(t, db.Open())
+	db.Close()
+}
+
+// TestRun_SetupFailure tests a failing setup closes the database without leaving anything running.
+func TestRun_SetupFailure(t *testing.T) {
+	chain := evmtest.NewChain()
+	defer chain.Close()
+
+	cfg := config.Default()
+	cfg.Server.Port = freePort(t)
+	cfg.Server.Codes = filepath.Join(t.TempDir(), "file")
+	assert.Nil(t, ioutil.WriteFile(cfg.Server.Codes, nil, 0644))
+	cfg.Server.Codes = filepath.Join(cfg.Server.Codes, "codes")
+	cfg.Database.Path = filepath.Join(t.TempDir(), "app.db")
+	cfg.Wallet.Provider = config.ProviderEVM
+	cfg.Wallet.EVM.URL = chain.URL
+	cfg.Wallet.EVM.Token = chain.Token
+	cfg.Wallet.EVM.Treasury = chain.Treasury
+	cfg.Wallet.EVM.Passphrase = evmtest.Passphrase
+
+	assert.NotNil(t, run(context.Background(), cfg))
+
+	db := database.NewClient(cfg.Database.Path)
+	assert.Nil(t, db.Open())
+	db.Close()
+}
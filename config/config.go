@@ -0,0 +1,553 @@
+// Package config loads the application configuration.
+//
+// Settings are resolved in order of increasing precedence:
+//
+//  1. built-in defaults
+//  2. the JSON configuration file (-config, "config.json" by default)
+//  3. environment variables, named TREASURE_COIN_ followed by the flag name, e.g. TREASURE_COIN_OST_SECRET
+//  4. command line flags, e.g. -ost-secret
+//
+// The legacy ".env" file holding the OST credentials is still read, with the precedence of the configuration file.
+package config
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvPrefix prefixes the environment variables holding settings.
+const EnvPrefix = "TREASURE_COIN_"
+
+// DefaultFile is the configuration file read when none is supplied.
+const DefaultFile = "config.json"
+
+// LegacyWalletFile is the file the OST credentials were originally read from.
+const LegacyWalletFile = ".env"
+
+// DefaultContentSecurityPolicy only lets the pages load the assets of the server, and images from any HTTPS site.
+const DefaultContentSecurityPolicy = "default-src 'self'; img-src 'self' data: https:; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+// redacted replaces the secrets when printing the configuration.
+const redacted = "********"
+
+// Config represents the application configuration.
+type Config struct {
+	Server   ServerConfig   `json:"server"`
+	Database DatabaseConfig `json:"database"`
+	Wallet   WalletConfig   `json:"wallet"`
+	Auth     AuthConfig     `json:"auth"`
+	Game     GameConfig     `json:"game"`
+	Webhooks WebhooksConfig `json:"webhooks"`
+	Log      LogConfig      `json:"log"`
+}
+
+// ServerConfig are the http server settings.
+type ServerConfig struct {
+	// public address of the server, used in QR codes and redirects.
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	SSL  bool   `json:"ssl"`
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	// port of the plain HTTP listener redirecting to the host when ssl is on, 0 for none.
+	RedirectPort int `json:"redirect_port"`
+	// how long browsers only reach the server over HTTPS when ssl is on, 0 for no HSTS.
+	HSTSMaxAge Duration `json:"hsts_max_age"`
+	// restricts what the pages can load, empty for no policy.
+	ContentSecurityPolicy string `json:"content_security_policy"`
+	// connection limits, zero for none, and how long the requests in flight are awaited on shutdown.
+	ReadTimeout     Duration `json:"read_timeout"`
+	WriteTimeout    Duration `json:"write_timeout"`
+	IdleTimeout     Duration `json:"idle_timeout"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	// directory overriding the templates and assets bundled into the binary, read again on every request.
+	Assets string `json:"assets"`
+	// directory the QR codes of the treasures are written to.
+	Codes string `json:"codes"`
+	// bearer token the metrics are scraped with, empty to not serve them.
+	MetricsToken string `json:"metrics_token"`
+}
+
+// DatabaseConfig are the persistence settings.
+type DatabaseConfig struct {
+	Path string `json:"path"`
+}
+
+// wallet providers.
+const (
+	ProviderOST = "ost"
+	ProviderEVM = "evm"
+)
+
+// WalletConfig are the wallet provider settings, the OST API by default.
+type WalletConfig struct {
+	// Provider selects the wallet provider, ProviderOST or ProviderEVM.
+	Provider      string   `json:"provider"`
+	URL           string   `json:"url"`
+	Key           string   `json:"key"`
+	Secret        string   `json:"secret"`
+	Company       string   `json:"company"`
+	Timeout       Duration `json:"timeout"`
+	MaxRetries    int      `json:"max_retries"`
+	CreateActions bool     `json:"create_actions"`
+	WebhookSecret string   `json:"webhook_secret"`
+	PollInterval  Duration `json:"poll_interval"`
+	// how long balances and ledgers are cached, zero to disable, and how long they are served stale.
+	CacheTTL      Duration `json:"cache_ttl"`
+	CacheMaxStale Duration `json:"cache_max_stale"`
+	// how many consecutive failures open the circuit, and how long before the api is probed again.
+	BreakerThreshold int      `json:"breaker_threshold"`
+	BreakerCooldown  Duration `json:"breaker_cooldown"`
+	Actions          struct {
+		Reward   int `json:"reward"`
+		Payment  int `json:"payment"`
+		Decrease int `json:"decrease"`
+		Transfer int `json:"transfer"`
+		Tip      int `json:"tip"`
+	} `json:"actions"`
+	EVM EVMConfig `json:"evm"`
+}
+
+// EVMConfig are the settings of the ERC-20 token wallet provider.
+type EVMConfig struct {
+	// JSON-RPC endpoint of the node.
+	URL        string `json:"url"`
+	Token      string `json:"token"`
+	Treasury   string `json:"treasury"`
+	Passphrase string `json:"passphrase"`
+	// wei sent to every new wallet to pay for its transactions.
+	GasFunding string `json:"gas_funding"`
+	// block the token was deployed at.
+	StartBlock int `json:"start_block"`
+	// blocks spanned by every log query, as the nodes limit it.
+	LogRange int `json:"log_range"`
+	// coins paid for finding a treasure.
+	Reward coin.Amount `json:"reward"`
+}
+
+// AuthConfig are the user authentication settings.
+type AuthConfig struct {
+	// email of the user granted the admin role on startup.
+	AdminEmail string     `json:"admin_email"`
+	OIDC       OIDCConfig `json:"oidc"`
+}
+
+// OIDCConfig are the OpenID Connect sign in settings, disabled without an issuer.
+type OIDCConfig struct {
+	Name         string `json:"name"`
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// GameConfig are the game economy settings.
+type GameConfig struct {
+	// coins airdropped to every new user.
+	SignupAirdrop coin.Amount `json:"signup_airdrop"`
+
+	// coins a player can send to other players at a time and over a day, zero for no limit.
+	MaxTransfer   coin.Amount `json:"max_transfer"`
+	DailyTransfer coin.Amount `json:"daily_transfer"`
+}
+
+// WebhooksConfig are the settings of the webhooks notified of the game events.
+type WebhooksConfig struct {
+	// limit of every delivery attempt, how many are made, and how long the first retry waits, doubling after.
+	Timeout     Duration `json:"timeout"`
+	MaxAttempts int      `json:"max_attempts"`
+	Backoff     Duration `json:"backoff"`
+	// whether the webhooks can reach the loopback and private networks of the server.
+	AllowPrivate bool `json:"allow_private"`
+}
+
+// LogConfig are the application log settings.
+type LogConfig struct {
+	// Format is either logging.FormatText or logging.FormatJSON.
+	Format string `json:"format"`
+	// Level is the least severe level logged, like "info" or "debug".
+	Level string `json:"level"`
+}
+
+// Default returns the built-in configuration.
+func Default() Config {
+	c := Config{}
+	c.Server.Host = "https://treasurecoin.powertrip.pt"
+	c.Server.Port = 8080
+	c.Server.Cert = "ssl/certificate.pem"
+	c.Server.Key = "ssl/secret.pem"
+	c.Server.HSTSMaxAge = Duration(365 * 24 * time.Hour)
+	c.Server.ContentSecurityPolicy = DefaultContentSecurityPolicy
+	c.Server.ReadTimeout = Duration(15 * time.Second)
+	c.Server.WriteTimeout = Duration(time.Minute)
+	c.Server.IdleTimeout = Duration(2 * time.Minute)
+	c.Server.ShutdownTimeout = Duration(30 * time.Second)
+	c.Server.Codes = "public/codes"
+	c.Database.Path = "app.db"
+	c.Wallet.Provider = ProviderOST
+	c.Wallet.Timeout = Duration(10 * time.Second)
+	c.Wallet.MaxRetries = 3
+	c.Wallet.PollInterval = Duration(time.Minute)
+	c.Wallet.CacheTTL = Duration(30 * time.Second)
+	c.Wallet.CacheMaxStale = Duration(10 * time.Minute)
+	c.Wallet.BreakerThreshold = 5
+	c.Wallet.BreakerCooldown = Duration(30 * time.Second)
+	c.Wallet.EVM.GasFunding = "1000000000000000"
+	c.Wallet.EVM.LogRange = 5000
+	c.Wallet.EVM.Reward = coin.Coin
+	c.Game.SignupAirdrop = coin.Coin
+	c.Game.MaxTransfer = coin.Coin.Mul(10)
+	c.Game.DailyTransfer = coin.Coin.Mul(20)
+	c.Webhooks.Timeout = Duration(10 * time.Second)
+	c.Webhooks.MaxAttempts = 10
+	c.Webhooks.Backoff = Duration(30 * time.Second)
+	c.Log.Format = logging.FormatText
+	c.Log.Level = log.InfoLevel.String()
+	return c
+}
+
+// setting represents a configuration value that can be set from the environment and the command line.
+type setting struct {
+	name   string
+	usage  string
+	value  interface{}
+	secret bool
+}
+
+// settings lists the configuration values that can be set from the environment and the command line.
+func (c *Config) settings() []setting {
+	return []setting{
+		{name: "server-host", usage: "Choose server host.", value: &c.Server.Host},
+		{name: "server-port", usage: "Choose server port to bind to.", value: &c.Server.Port},
+		{name: "server-ssl", usage: "Choose wheather the server should use ssl.", value: &c.Server.SSL},
+		{name: "server-cert", usage: "Choose server certificate for ssl.", value: &c.Server.Cert},
+		{name: "server-secret", usage: "Choose server secret for ssl.", value: &c.Server.Key},
+		{name: "server-redirect-port", usage: "Choose the port redirecting plain HTTP to the host when using ssl, 0 for none.", value: &c.Server.RedirectPort},
+		{name: "server-hsts-max-age", usage: "Choose how long browsers only reach the server over HTTPS when using ssl, 0 for no HSTS.", value: &c.Server.HSTSMaxAge},
+		{name: "server-content-security-policy", usage: "Choose the content security policy of the pages, empty for none.", value: &c.Server.ContentSecurityPolicy},
+		{name: "server-read-timeout", usage: "Choose how long the server waits to read a request, 0 for no limit.", value: &c.Server.ReadTimeout},
+		{name: "server-write-timeout", usage: "Choose how long the server takes to write a response, 0 for no limit.", value: &c.Server.WriteTimeout},
+		{name: "server-idle-timeout", usage: "Choose how long the server keeps idle connections open, 0 for no limit.", value: &c.Server.IdleTimeout},
+		{name: "server-shutdown-timeout", usage: "Choose how long the server waits for the requests in flight when stopping.", value: &c.Server.ShutdownTimeout},
+		{name: "server-assets", usage: "Choose a directory overriding the bundled templates and assets, read again on every request.", value: &c.Server.Assets},
+		{name: "server-codes", usage: "Choose the directory the QR codes of the treasures are written to.", value: &c.Server.Codes},
+		{name: "server-metrics-token", usage: "Choose the bearer token the metrics are scraped with, empty to not serve them.", value: &c.Server.MetricsToken, secret: true},
+		{name: "db-path", usage: "Choose database path.", value: &c.Database.Path},
+		{name: "wallet-provider", usage: "Choose the wallet provider, ost or evm.", value: &c.Wallet.Provider},
+		{name: "ost-url", usage: "Choose the OST API base url.", value: &c.Wallet.URL},
+		{name: "ost-key", usage: "Choose the OST API key.", value: &c.Wallet.Key},
+		{name: "ost-secret", usage: "Choose the OST API secret.", value: &c.Wallet.Secret, secret: true},
+		{name: "ost-company", usage: "Choose the OST API company ID.", value: &c.Wallet.Company},
+		{name: "ost-timeout", usage: "Choose the timeout of the OST API requests.", value: &c.Wallet.Timeout},
+		{name: "ost-max-retries", usage: "Choose how many times failed OST API reads are retried.", value: &c.Wallet.MaxRetries},
+		{name: "ost-create-actions", usage: "Choose whether the missing OST actions are created on startup.", value: &c.Wallet.CreateActions},
+		{name: "ost-webhook-secret", usage: "Choose the secret of the OST webhooks, the API secret by default.", value: &c.Wallet.WebhookSecret, secret: true},
+		{name: "ost-poll-interval", usage: "Choose how often the pending OST transactions are polled.", value: &c.Wallet.PollInterval},
+		{name: "ost-cache-ttl", usage: "Choose how long the OST balances and ledgers are cached, 0 to disable.", value: &c.Wallet.CacheTTL},
+		{name: "ost-cache-max-stale", usage: "Choose how long cached OST balances and ledgers are served while they cannot be refreshed.", value: &c.Wallet.CacheMaxStale},
+		{name: "ost-breaker-threshold", usage: "Choose how many consecutive OST API failures stop the calls to it.", value: &c.Wallet.BreakerThreshold},
+		{name: "ost-breaker-cooldown", usage: "Choose how long the OST API calls are stopped before it is probed again.", value: &c.Wallet.BreakerCooldown},
+		{name: "evm-url", usage: "Choose the JSON-RPC url of the EVM node.", value: &c.Wallet.EVM.URL},
+		{name: "evm-token", usage: "Choose the address of the ERC-20 token contract.", value: &c.Wallet.EVM.Token},
+		{name: "evm-treasury", usage: "Choose the node account minting the coins and paying the rewards.", value: &c.Wallet.EVM.Treasury},
+		{name: "evm-passphrase", usage: "Choose the passphrase unlocking the node accounts.", value: &c.Wallet.EVM.Passphrase, secret: true},
+		{name: "evm-gas-funding", usage: "Choose how much wei is sent to every new wallet to pay for its transactions.", value: &c.Wallet.EVM.GasFunding},
+		{name: "evm-start-block", usage: "Choose the block the token was deployed at.", value: &c.Wallet.EVM.StartBlock},
+		{name: "evm-log-range", usage: "Choose how many blocks every query of the transaction history spans.", value: &c.Wallet.EVM.LogRange},
+		{name: "evm-reward", usage: "Choose how many coins are paid for finding a treasure.", value: &c.Wallet.EVM.Reward},
+		{name: "admin-email", usage: "Choose the email of the user granted the admin role on startup.", value: &c.Auth.AdminEmail},
+		{name: "oidc-name", usage: "Choose the OpenID Connect provider name shown to the users.", value: &c.Auth.OIDC.Name},
+		{name: "oidc-issuer", usage: "Choose the OpenID Connect issuer url, leave empty to disable.", value: &c.Auth.OIDC.Issuer},
+		{name: "oidc-client-id", usage: "Choose the OpenID Connect client ID.", value: &c.Auth.OIDC.ClientID},
+		{name: "oidc-client-secret", usage: "Choose the OpenID Connect client secret.", value: &c.Auth.OIDC.ClientSecret, secret: true},
+		{name: "game-signup-airdrop", usage: "Choose how many coins are airdropped to new users.", value: &c.Game.SignupAirdrop},
+		{name: "game-max-transfer", usage: "Choose how many coins a player can send at a time, 0 for no limit.", value: &c.Game.MaxTransfer},
+		{name: "game-daily-transfer", usage: "Choose how many coins a player can send a day, 0 for no limit.", value: &c.Game.DailyTransfer},
+		{name: "webhooks-timeout", usage: "Choose the timeout of every webhook delivery attempt.", value: &c.Webhooks.Timeout},
+		{name: "webhooks-max-attempts", usage: "Choose how many times a webhook delivery is attempted before it is given up.", value: &c.Webhooks.MaxAttempts},
+		{name: "webhooks-backoff", usage: "Choose how long a failed webhook delivery waits to be retried, doubling on every failure.", value: &c.Webhooks.Backoff},
+		{name: "webhooks-allow-private", usage: "Choose whether the webhooks can reach the loopback and private networks.", value: &c.Webhooks.AllowPrivate},
+		{name: "log-format", usage: "Choose the log format, text or json.", value: &c.Log.Format},
+		{name: "log-level", usage: "Choose the least severe level logged, like info or debug.", value: &c.Log.Level},
+	}
+}
+
+// Validate checks the configuration, returning a *ValidationError listing every problem found.
+func (c Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// server.
+	if u, err := url.Parse(c.Server.Host); err != nil || u.Scheme == "" || u.Host == "" {
+		add("server host %q must be an absolute url", c.Server.Host)
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("server port %d is out of range", c.Server.Port)
+	}
+	if c.Server.SSL {
+		for _, f := range []string{c.Server.Cert, c.Server.Key} {
+			if _, err := os.Stat(f); err != nil {
+				add("server ssl file %q cannot be read", f)
+			}
+		}
+		if u, err := url.Parse(c.Server.Host); err == nil && u.Scheme != "https" && c.Server.RedirectPort != 0 {
+			add("server host %q must be an https url to redirect to", c.Server.Host)
+		}
+	} else if c.Server.RedirectPort != 0 {
+		add("server redirect port requires ssl")
+	}
+	if c.Server.RedirectPort < 0 || c.Server.RedirectPort > 65535 {
+		add("server redirect port %d is out of range", c.Server.RedirectPort)
+	} else if c.Server.RedirectPort != 0 && c.Server.RedirectPort == c.Server.Port {
+		add("server redirect port must differ from the server port")
+	}
+	if c.Server.HSTSMaxAge < 0 {
+		add("server hsts max age cannot be negative")
+	}
+
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		add("server timeouts cannot be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("server shutdown timeout must be positive")
+	}
+	if c.Server.Assets != "" {
+		for _, d := range []string{"templates", "public"} {
+			if info, err := os.Stat(filepath.Join(c.Server.Assets, d)); err != nil || !info.IsDir() {
+				add("server assets directory %q has no %s directory", c.Server.Assets, d)
+			}
+		}
+	}
+	if c.Server.Codes == "" {
+		add("server codes directory is required")
+	}
+
+	// database.
+	if c.Database.Path == "" {
+		add("database path is required")
+	}
+
+	// wallet.
+	switch c.Wallet.Provider {
+	case ProviderOST:
+		if u, err := url.Parse(c.Wallet.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			add("ost url %q must be an absolute url", c.Wallet.URL)
+		}
+		if c.Wallet.Key == "" {
+			add("ost key is required")
+		}
+		if c.Wallet.Secret == "" {
+			add("ost secret is required")
+		}
+		if c.Wallet.Company == "" {
+			add("ost company is required")
+		}
+	case ProviderEVM:
+		evm := c.Wallet.EVM
+		if u, err := url.Parse(evm.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			add("evm url %q must be an absolute url", evm.URL)
+		}
+		if !validAddress(evm.Token) {
+			add("evm token %q must be a hex address", evm.Token)
+		}
+		if !validAddress(evm.Treasury) {
+			add("evm treasury %q must be a hex address", evm.Treasury)
+		}
+		if n, ok := new(big.Int).SetString(evm.GasFunding, 10); !ok || n.Sign() < 0 {
+			add("evm gas funding %q must be a non-negative amount of wei", evm.GasFunding)
+		}
+		if evm.StartBlock < 0 {
+			add("evm start block cannot be negative")
+		}
+		if evm.LogRange <= 0 {
+			add("evm log range must be positive")
+		}
+		if evm.Reward.Sign() <= 0 {
+			add("evm reward must be positive")
+		}
+	default:
+		add("wallet provider %q must be %q or %q", c.Wallet.Provider, ProviderOST, ProviderEVM)
+	}
+	if c.Wallet.Timeout <= 0 {
+		add("ost timeout must be positive")
+	}
+	if c.Wallet.PollInterval <= 0 {
+		add("ost poll interval must be positive")
+	}
+	if c.Wallet.CacheTTL < 0 {
+		add("ost cache ttl must not be negative")
+	}
+	if c.Wallet.CacheTTL > 0 && c.Wallet.CacheMaxStale < c.Wallet.CacheTTL {
+		add("ost cache max stale must not be shorter than the cache ttl")
+	}
+	if c.Wallet.BreakerThreshold <= 0 {
+		add("ost breaker threshold must be positive")
+	}
+	if c.Wallet.BreakerCooldown <= 0 {
+		add("ost breaker cooldown must be positive")
+	}
+
+	// auth.
+	if c.Auth.OIDC.Issuer != "" {
+		if c.Auth.OIDC.ClientID == "" {
+			add("oidc client id is required when the oidc issuer is set")
+		}
+		if c.Auth.OIDC.ClientSecret == "" {
+			add("oidc client secret is required when the oidc issuer is set")
+		}
+	}
+
+	// game.
+	if c.Game.SignupAirdrop.Sign() < 0 {
+		add("game signup airdrop cannot be negative")
+	}
+	if c.Game.MaxTransfer.Sign() < 0 || c.Game.DailyTransfer.Sign() < 0 {
+		add("game transfer limits cannot be negative")
+	}
+
+	// webhooks.
+	if c.Webhooks.Timeout <= 0 || c.Webhooks.Backoff <= 0 {
+		add("webhooks timeout and backoff must be positive")
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		add("webhooks max attempts must be at least 1")
+	}
+
+	// log.
+	if c.Log.Format != logging.FormatText && c.Log.Format != logging.FormatJSON {
+		add("log format %q must be %q or %q", c.Log.Format, logging.FormatText, logging.FormatJSON)
+	}
+	if _, err := log.ParseLevel(c.Log.Level); err != nil {
+		add("log level %q is unknown", c.Log.Level)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validAddress returns whether the text is a hex account address.
+func validAddress(address string) bool {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(address[2:])
+	return err == nil
+}
+
+// Redacted returns a copy of the configuration with the secrets hidden.
+func (c Config) Redacted() Config {
+	r := c
+	for _, s := range r.settings() {
+		if p, ok := s.value.(*string); ok && s.secret && *p != "" {
+			*p = redacted
+		}
+	}
+	return r
+}
+
+// String returns the configuration as indented JSON, with the secrets hidden.
+func (c Config) String() string {
+	b, _ := json.MarshalIndent(c.Redacted(), "", "  ")
+	return string(b)
+}
+
+// ValidationError reports every problem found in the configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error returns the report of every problem found.
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Duration is a time.Duration read and written as a string, like "10s".
+type Duration time.Duration
+
+// MarshalText encodes the duration as a string.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// String returns the duration as a string.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// UnmarshalText decodes the duration from a string.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Set parses the string value into the setting.
+func (s setting) Set(v string) error {
+	switch p := s.value.(type) {
+	case *string:
+		*p = v
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*p = b
+	case *Duration:
+		return p.UnmarshalText([]byte(v))
+	case *coin.Amount:
+		return p.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("unsupported setting type %T", p)
+	}
+	return nil
+}
+
+// String returns the current value of the setting.
+func (s setting) String() string {
+	switch p := s.value.(type) {
+	case *string:
+		return *p
+	case *int:
+		return strconv.Itoa(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case fmt.Stringer:
+		return p.String()
+	}
+	return ""
+}
+
+// IsBoolFlag returns whether the setting is a flag that needs no value.
+func (s setting) IsBoolFlag() bool {
+	_, ok := s.value.(*bool)
+	return ok
+}
+
+// env returns the name of the environment variable holding the setting.
+func (s setting) env() string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(s.name, "-", "_", -1))
+}
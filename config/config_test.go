@@ -0,0 +1,193 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// valid is a complete configuration file.
+const valid = `{
+  "wallet": {
+    "url": "https://sandboxapi.ost.com/v1.1",
+    "key": "file-key",
+    "secret": "file-secret",
+    "company": "file-company",
+    "timeout": "5s"
+  },
+  "game": {"signup_airdrop": "2.5"}
+}`
+
+// chdir runs the test in a new temporary directory holding the files.
+func chdir(t *testing.T, files map[string]string) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		os.RemoveAll(dir)
+	})
+}
+
+// TestLoad_Defaults tests loading the built-in configuration without any file.
+func TestLoad_Defaults(t *testing.T) {
+	chdir(t, nil)
+
+	c, err := config.Load("test", nil, ioutil.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, config.Default(), c)
+}
+
+// TestLoad_Precedence tests the flags overriding the environment, overriding the file.
+func TestLoad_Precedence(t *testing.T) {
+	chdir(t, map[string]string{config.DefaultFile: valid})
+	t.Setenv("TREASURE_COIN_OST_KEY", "env-key")
+	t.Setenv("TREASURE_COIN_OST_SECRET", "env-secret")
+
+	c, err := config.Load("test", []string{"-ost-secret", "flag-secret", "-server-ssl"}, ioutil.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, "file-company", c.Wallet.Company)
+	assert.Equal(t, "env-key", c.Wallet.Key)
+	assert.Equal(t, "flag-secret", c.Wallet.Secret)
+	assert.Equal(t, config.Duration(5*time.Second), c.Wallet.Timeout)
+	assert.Equal(t, coin.Coin.Mul(5)/2, c.Game.SignupAirdrop)
+	assert.True(t, c.Server.SSL)
+}
+
+// TestLoad_Legacy tests reading the OST credentials from the legacy file.
+func TestLoad_Legacy(t *testing.T) {
+	chdir(t, map[string]string{
+		config.LegacyWalletFile: `{"Key": "legacy-key", "Secret": "legacy-secret", "Url": "https://sandboxapi.ost.com/v1.1", "Company": "legacy-company", "Actions": {"Reward": 1}}`,
+		config.DefaultFile:      `{"wallet": {"key": "file-key"}}`,
+	})
+
+	c, err := config.Load("test", nil, ioutil.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, "file-key", c.Wallet.Key)
+	assert.Equal(t, "legacy-secret", c.Wallet.Secret)
+	assert.Equal(t, 1, c.Wallet.Actions.Reward)
+	assert.Nil(t, c.Validate())
+}
+
+// TestLoad_Errors tests aggregating every value that cannot be read.
+func TestLoad_Errors(t *testing.T) {
+	chdir(t, map[string]string{config.DefaultFile: `{"wallet": {"secert": "typo"}}`})
+	t.Setenv("TREASURE_COIN_SERVER_PORT", "http")
+
+	_, err := config.Load("test", nil, ioutil.Discard)
+	problems := err.(*config.ValidationError).Problems
+	assert.Len(t, problems, 2)
+	assert.Contains(t, problems[0], "secert")
+	assert.Contains(t, problems[1], "TREASURE_COIN_SERVER_PORT")
+
+	// an explicit file must exist.
+	_, err = config.Load("test", []string{"-config", "missing.json"}, ioutil.Discard)
+	assert.Contains(t, err.Error(), "missing.json")
+}
+
+// TestConfig_Validate tests reporting every problem of the configuration.
+func TestConfig_Validate(t *testing.T) {
+	c := config.Default()
+	c.Server.Port = 0
+	c.Auth.OIDC.Issuer = "https://accounts.example.com"
+	c.Webhooks.MaxAttempts = 0
+	c.Log.Format = "xml"
+
+	err := c.Validate()
+	problems := err.(*config.ValidationError).Problems
+	assert.Equal(t, []string{
+		"server port 0 is out of range",
+		`ost url "" must be an absolute url`,
+		"ost key is required",
+		"ost secret is required",
+		"ost company is required",
+		"oidc client id is required when the oidc issuer is set",
+		"oidc client secret is required when the oidc issuer is set",
+		"webhooks max attempts must be at least 1",
+		`log format "xml" must be "text" or "json"`,
+	}, problems)
+	assert.True(t, strings.HasPrefix(err.Error(), "invalid configuration:"))
+}
+
+// TestConfig_ValidateEVM tests only the settings of the selected wallet provider are required.
+func TestConfig_ValidateEVM(t *testing.T) {
+	c := config.Default()
+	c.Wallet.Provider = config.ProviderEVM
+	c.Wallet.EVM.URL = "http://localhost:8545"
+	c.Wallet.EVM.Token = "0x5fbdb2315678afecb367f032d93f642f64180aa3"
+	c.Wallet.EVM.Treasury = "treasury"
+	c.Wallet.EVM.GasFunding = "-1"
+	c.Wallet.EVM.LogRange = 0
+
+	problems := c.Validate().(*config.ValidationError).Problems
+	assert.Equal(t, []string{
+		`evm treasury "treasury" must be a hex address`,
+		`evm gas funding "-1" must be a non-negative amount of wei`,
+		`evm log range must be positive`,
+	}, problems)
+
+	c.Wallet.Provider = "bank"
+	problems = c.Validate().(*config.ValidationError).Problems
+	assert.Equal(t, []string{`wallet provider "bank" must be "ost" or "evm"`}, problems)
+}
+
+// TestConfig_ValidateSSL tests the plain HTTP redirects require ssl and an https host.
+func TestConfig_ValidateSSL(t *testing.T) {
+	chdir(t, map[string]string{"cert.pem": "cert", "key.pem": "key"})
+	c := config.Default()
+	c.Wallet.Provider = config.ProviderEVM
+	c.Wallet.EVM.URL = "http://localhost:8545"
+	c.Wallet.EVM.Token = "0x5fbdb2315678afecb367f032d93f642f64180aa3"
+	c.Wallet.EVM.Treasury = "0x5fbdb2315678afecb367f032d93f642f64180aa3"
+	c.Server.RedirectPort = 8080
+
+	problems := c.Validate().(*config.ValidationError).Problems
+	assert.Equal(t, []string{
+		"server redirect port requires ssl",
+		"server redirect port must differ from the server port",
+	}, problems)
+
+	c.Server.SSL = true
+	c.Server.Cert = "cert.pem"
+	c.Server.Key = "key.pem"
+	c.Server.Host = "http://treasurecoin.powertrip.pt"
+	c.Server.Port = 8443
+	problems = c.Validate().(*config.ValidationError).Problems
+	assert.Equal(t, []string{`server host "http://treasurecoin.powertrip.pt" must be an https url to redirect to`}, problems)
+
+	c.Server.Host = "https://treasurecoin.powertrip.pt"
+	assert.Nil(t, c.Validate())
+}
+
+// TestConfig_String tests hiding the secrets when printing the configuration.
+func TestConfig_String(t *testing.T) {
+	c := config.Default()
+	c.Wallet.Key = "key"
+	c.Wallet.Secret = "secret"
+	c.Auth.OIDC.ClientSecret = "client-secret"
+
+	s := c.String()
+	assert.Contains(t, s, `"key": "key"`)
+	assert.NotContains(t, s, `: "secret"`)
+	assert.NotContains(t, s, "client-secret")
+	assert.Contains(t, s, `"timeout": "10s"`)
+	assert.Equal(t, "secret", c.Wallet.Secret)
+}
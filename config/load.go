@@ -0,0 +1,133 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Load resolves the configuration from the defaults, the configuration file, the environment and the arguments.
+// It returns a *ValidationError listing every value that could not be read; use Validate to check the result.
+func Load(name string, args []string, output io.Writer) (Config, error) {
+	c := Default()
+	var problems []string
+
+	// parse the command line first to find the configuration file, applying it last.
+	scratch := Default()
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	file := fs.String("config", "", "Choose the configuration file, "+DefaultFile+" by default.")
+	for _, s := range scratch.settings() {
+		fs.Var(s, s.name, s.usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return c, err
+	}
+	if len(fs.Args()) > 0 {
+		return c, &ValidationError{Problems: []string{fmt.Sprintf("unexpected arguments %q", fs.Args())}}
+	}
+
+	// legacy OST credentials.
+	if err := c.readLegacy(LegacyWalletFile); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	// configuration file.
+	path, required := *file, true
+	if path == "" {
+		path, required = os.Getenv(EnvPrefix+"CONFIG"), true
+	}
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	if err := c.readFile(path, required); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	// environment.
+	for _, s := range c.settings() {
+		if v, ok := os.LookupEnv(s.env()); ok {
+			if err := s.Set(v); err != nil {
+				problems = append(problems, fmt.Sprintf("environment variable %s: %s", s.env(), err))
+			}
+		}
+	}
+
+	// command line.
+	settings := make(map[string]setting)
+	for _, s := range c.settings() {
+		settings[s.name] = s
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if s, ok := settings[f.Name]; ok {
+			s.Set(f.Value.String())
+		}
+	})
+
+	if len(problems) > 0 {
+		return c, &ValidationError{Problems: problems}
+	}
+	return c, nil
+}
+
+// readFile reads the JSON configuration file, ignoring a missing file unless required.
+func (c *Config) readFile(path string, required bool) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("configuration file %s cannot be read: %s", path, err)
+	}
+	defer f.Close()
+
+	d := json.NewDecoder(f)
+	d.DisallowUnknownFields()
+	if err := d.Decode(c); err != nil {
+		return fmt.Errorf("configuration file %s is malformed: %s", path, err)
+	}
+	return nil
+}
+
+// readLegacy reads the OST credentials from the legacy file, if it exists.
+func (c *Config) readLegacy(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("legacy file %s cannot be read: %s", path, err)
+	}
+	defer f.Close()
+
+	var legacy struct {
+		Key           string
+		Secret        string
+		Url           string
+		Company       string
+		CreateActions bool
+		Actions       struct {
+			Reward   int
+			Payment  int
+			Decrease int
+			Transfer int
+			Tip      int
+		}
+	}
+	if err := json.NewDecoder(f).Decode(&legacy); err != nil {
+		return fmt.Errorf("legacy file %s is malformed: %s", path, err)
+	}
+	c.Wallet.Key = legacy.Key
+	c.Wallet.Secret = legacy.Secret
+	c.Wallet.URL = legacy.Url
+	c.Wallet.Company = legacy.Company
+	c.Wallet.CreateActions = legacy.CreateActions
+	c.Wallet.Actions.Reward = legacy.Actions.Reward
+	c.Wallet.Actions.Payment = legacy.Actions.Payment
+	c.Wallet.Actions.Decrease = legacy.Actions.Decrease
+	c.Wallet.Actions.Transfer = legacy.Actions.Transfer
+	c.Wallet.Actions.Tip = legacy.Actions.Tip
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/boltdb/bolt"
+	"github.com/pmdcosta/treasure-coin/logging"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -18,9 +19,29 @@ type Client struct {
 	db   *bolt.DB
 
 	// object services.
-	userService    UserService
-	gameService    GameService
-	sessionService SessionService
+	userService      UserService
+	gameService      GameService
+	sessionService   SessionService
+	tokenService     TokenService
+	transferService  TransferService
+	operationService OperationService
+	webhookService   WebhookService
+	deliveryService  DeliveryService
+}
+
+// Stats represents the storage statistics of the database.
+type Stats struct {
+	// Size of the data, in bytes.
+	Size int64
+
+	// pages free and pending to be freed, and read transactions in progress.
+	FreePages    int
+	PendingPages int
+	OpenReads    int
+
+	// writes to disk since the database was opened, and how long they took.
+	Writes    int
+	WriteTime time.Duration
 }
 
 // NewClient returns a new configuration client.
@@ -32,6 +53,11 @@ func NewClient(path string) *Client {
 	c.userService.client = c
 	c.gameService.client = c
 	c.sessionService.client = c
+	c.tokenService.client = c
+	c.transferService.client = c
+	c.operationService.client = c
+	c.webhookService.client = c
+	c.deliveryService.client = c
 	return c
 }
 
@@ -61,6 +87,46 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// Ping checks the database can be read.
+func (c *Client) Ping() error {
+	if c.db == nil {
+		return ErrNotOpen
+	}
+	return c.db.View(func(tx *bolt.Tx) error { return nil })
+}
+
+// Stats returns the storage statistics of the database.
+func (c *Client) Stats() Stats {
+	if c.db == nil {
+		return Stats{}
+	}
+	bs := c.db.Stats()
+	s := Stats{
+		FreePages:    bs.FreePageN,
+		PendingPages: bs.PendingPageN,
+		OpenReads:    bs.OpenTxN,
+		Writes:       bs.TxStats.Write,
+		WriteTime:    bs.TxStats.WriteTime,
+	}
+	c.db.View(func(tx *bolt.Tx) error {
+		s.Size = tx.Size()
+		return nil
+	})
+	return s
+}
+
+// Count returns how many records a collection holds.
+func (c *Client) Count(collection string) int {
+	n := 0
+	c.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(collection)); b != nil {
+			n = b.Stats().KeyN
+		}
+		return nil
+	})
+	return n
+}
+
 // Create persists the supplied data as a new record.
 func (c *Client) Create(collection string, key string, value []byte) error {
 	// start read-write transaction.
@@ -81,18 +147,18 @@ func (c *Client) Create(collection string, key string, value []byte) error {
 	// check if the key exists.
 	v := b.Get([]byte(key))
 	if v != nil {
-		c.logger.WithFields(log.Fields{"collection": collection, "record": key}).Debug(ErrRecordExists)
+		c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, key)}).Debug(ErrRecordExists)
 		return ErrRecordExists
 	}
 
 	// insert record.
 	err = b.Put([]byte(key), value)
 	if err != nil {
-		c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": key}).Error(ErrCreateRecord)
+		c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": recordKey(collection, key)}).Error(ErrCreateRecord)
 		return err
 	}
 
-	c.logger.WithFields(log.Fields{"collection": collection, "key": key, "record": string(value)}).Debug("record created")
+	c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, key), "size": len(value)}).Debug("record created")
 	return tx.Commit()
 }
 
@@ -124,14 +190,22 @@ func (c *Client) CreateIndexed(collection string, value []byte) (string, error)
 	// insert record.
 	err = b.Put([]byte(key), value)
 	if err != nil {
-		c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": key}).Error(ErrCreateRecord)
+		c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": recordKey(collection, key)}).Error(ErrCreateRecord)
 		return "", err
 	}
 
-	c.logger.WithFields(log.Fields{"collection": collection, "key": key, "record": string(value)}).Debug("record created")
+	c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, key), "size": len(value)}).Debug("record created")
 	return key, tx.Commit()
 }
 
+// indexedLess returns whether the id generated by CreateIndexed was generated before the other,
+// as the database sorts them as text.
+func indexedLess(a, b string) bool {
+	x, _ := strconv.ParseUint(a, 10, 64)
+	y, _ := strconv.ParseUint(b, 10, 64)
+	return x < y
+}
+
 // Load retrieves the stored data.
 func (c *Client) Load(collection string, key string) ([]byte, error) {
 	// start read transaction.
@@ -152,11 +226,11 @@ func (c *Client) Load(collection string, key string) ([]byte, error) {
 	// find record.
 	v := b.Get([]byte(key))
 	if v == nil {
-		c.logger.WithFields(log.Fields{"collection": collection, "record": key}).Debug(ErrRecordNotFound)
+		c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, key)}).Debug(ErrRecordNotFound)
 		return nil, ErrRecordNotFound
 	}
 
-	c.logger.WithFields(log.Fields{"collection": collection, "key": key, "record": string(v)}).Debug("record loaded")
+	c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, key), "size": len(v)}).Debug("record loaded")
 	return v, nil
 }
 
@@ -180,11 +254,99 @@ func (c *Client) Save(collection string, key string, value []byte) error {
 	// insert record.
 	err = b.Put([]byte(key), value)
 	if err != nil {
-		c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": key}).Error(ErrCreateRecord)
+		c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": recordKey(collection, key)}).Error(ErrCreateRecord)
 		return err
 	}
 
-	c.logger.WithFields(log.Fields{"collection": collection, "key": key, "record": string(value)}).Debug("record created")
+	c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, key), "size": len(value)}).Debug("record created")
+	return tx.Commit()
+}
+
+// Update replaces an existing record with the data returned by fn, reading and writing it in a single transaction.
+// Nothing is written if fn fails.
+func (c *Client) Update(collection string, key string, fn func(value []byte) ([]byte, error)) error {
+	// start read-write transaction.
+	tx, err := c.db.Begin(true)
+	if err != nil {
+		c.logger.WithFields(log.Fields{"error": err}).Error(ErrTransaction)
+		return err
+	}
+	defer tx.Rollback()
+
+	// create collection if it does not exist.
+	b, err := tx.CreateBucketIfNotExists([]byte(collection))
+	if err != nil {
+		c.logger.WithFields(log.Fields{"error": err, "collection": collection}).Error(ErrCreateCollection)
+		return err
+	}
+
+	// find record.
+	v := b.Get([]byte(key))
+	if v == nil {
+		c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, key)}).Debug(ErrRecordNotFound)
+		return ErrRecordNotFound
+	}
+
+	// the value is only valid during the transaction.
+	value, err := fn(append([]byte(nil), v...))
+	if err != nil {
+		return err
+	}
+
+	// replace record.
+	err = b.Put([]byte(key), value)
+	if err != nil {
+		c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": recordKey(collection, key)}).Error(ErrCreateRecord)
+		return err
+	}
+
+	c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, key), "size": len(value)}).Debug("record updated")
+	return tx.Commit()
+}
+
+// Rename moves a record to a new key, failing if the new key is already taken.
+func (c *Client) Rename(collection string, oldKey string, newKey string, value []byte) error {
+	// start read-write transaction.
+	tx, err := c.db.Begin(true)
+	if err != nil {
+		c.logger.WithFields(log.Fields{"error": err}).Error(ErrTransaction)
+		return err
+	}
+	defer tx.Rollback()
+
+	// create collection if it does not exist.
+	b, err := tx.CreateBucketIfNotExists([]byte(collection))
+	if err != nil {
+		c.logger.WithFields(log.Fields{"error": err, "collection": collection}).Error(ErrCreateCollection)
+		return err
+	}
+
+	// check if the old key exists.
+	if v := b.Get([]byte(oldKey)); v == nil {
+		c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, oldKey)}).Debug(ErrRecordNotFound)
+		return ErrRecordNotFound
+	}
+
+	// check if the new key is free.
+	if oldKey != newKey {
+		if v := b.Get([]byte(newKey)); v != nil {
+			c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, newKey)}).Debug(ErrRecordExists)
+			return ErrRecordExists
+		}
+		if err = b.Delete([]byte(oldKey)); err != nil {
+			c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": recordKey(collection, oldKey)}).Error(ErrDeleteRecord)
+			return err
+		}
+	}
+
+	// insert record.
+	err = b.Put([]byte(newKey), value)
+	if err != nil {
+		c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": recordKey(collection, newKey)}).Error(ErrCreateRecord)
+		return err
+	}
+
+	c.logger.WithFields(log.Fields{"collection": collection, "old": recordKey(collection, oldKey), "record": recordKey(collection, newKey)}).Debug("record renamed")
 	return tx.Commit()
 }
 
@@ -233,13 +395,21 @@ func (c *Client) Delete(collection string, keys ...string) error {
 	// delete records.
 	for _, k := range keys {
 		if err = b.Delete([]byte(k)); err != nil {
-			c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": k}).Debug(ErrDeleteRecord)
+			c.logger.WithFields(log.Fields{"error": err, "collection": collection, "record": recordKey(collection, k)}).Debug(ErrDeleteRecord)
 		}
-		c.logger.WithFields(log.Fields{"collection": collection, "record": k}).Debug("deleted record")
+		c.logger.WithFields(log.Fields{"collection": collection, "record": recordKey(collection, k)}).Debug("deleted record")
 	}
 	return tx.Commit()
 }
 
+// recordKey returns the key of a record to be logged, hiding the keys that are secrets themselves, like the session tokens.
+func recordKey(collection, key string) string {
+	if collection == SessionCollection {
+		return logging.Redacted
+	}
+	return key
+}
+
 // UserService returns the service used to manage user persistence.
 func (c *Client) UserService() *UserService { return &c.userService }
 
@@ -248,3 +418,18 @@ func (c *Client) GameService() *GameService { return &c.gameService }
 
 // SessionService returns the service used to manage game persistence.
 func (c *Client) SessionService() *SessionService { return &c.sessionService }
+
+// TokenService returns the service used to manage API token persistence.
+func (c *Client) TokenService() *TokenService { return &c.tokenService }
+
+// TransferService returns the service used to manage the tracked transfers persistence.
+func (c *Client) TransferService() *TransferService { return &c.transferService }
+
+// OperationService returns the service used to manage the deferred wallet operations persistence.
+func (c *Client) OperationService() *OperationService { return &c.operationService }
+
+// WebhookService returns the service used to manage the webhooks persistence.
+func (c *Client) WebhookService() *WebhookService { return &c.webhookService }
+
+// DeliveryService returns the service used to manage the webhook deliveries persistence.
+func (c *Client) DeliveryService() *DeliveryService { return &c.deliveryService }
@@ -190,3 +190,24 @@ func TestClient_LoadRecord_NoRecord(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestClient_Ping tests checking the database can be read.
+func TestClient_Ping(t *testing.T) {
+	if err := NewClient().Ping(); err != database.ErrNotOpen {
+		t.Fatal(err)
+	}
+
+	c := MustOpenClient()
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Stats(); s.Size == 0 {
+		t.Fatal("expected the database size")
+	}
+
+	// closed.
+	c.Close()
+	if err := c.Ping(); err == nil {
+		t.Fatal("expected the closed database to fail")
+	}
+}
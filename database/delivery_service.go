@@ -0,0 +1,83 @@
+package database
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pmdcosta/treasure-coin"
+)
+
+const DeliveryCollection = "deliveries"
+
+// DeliveryService represents a service for managing the persistence of the deliveries of the events to the webhooks.
+type DeliveryService struct {
+	client *Client
+}
+
+// Add stores the delivery in the database, returning its generated id.
+func (s *DeliveryService) Add(d coin.Delivery) (string, error) {
+	j, _ := json.Marshal(d)
+	return s.client.CreateIndexed(DeliveryCollection, j)
+}
+
+// Find retrieves a delivery from the database by its id.
+func (s *DeliveryService) Find(id string) (coin.Delivery, error) {
+	j, err := s.client.Load(DeliveryCollection, id)
+	if err != nil {
+		return coin.Delivery{}, err
+	}
+
+	var d coin.Delivery
+	json.Unmarshal(j, &d)
+	d.ID = id
+
+	return d, nil
+}
+
+// Save upserts the delivery to the database.
+func (s *DeliveryService) Save(d coin.Delivery) error {
+	j, _ := json.Marshal(d)
+	return s.client.Save(DeliveryCollection, d.ID, j)
+}
+
+// Remove deletes the deliveries from the database.
+func (s *DeliveryService) Remove(deliveries ...coin.Delivery) error {
+	ids := make([]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	return s.client.Delete(DeliveryCollection, ids...)
+}
+
+// List retrieves all the deliveries, in the order they were added.
+func (s *DeliveryService) List() []coin.Delivery {
+	return s.filter(func(coin.Delivery) bool { return true })
+}
+
+// Pending retrieves the deliveries still to be attempted, in the order they were added.
+func (s *DeliveryService) Pending() []coin.Delivery {
+	return s.filter(func(d coin.Delivery) bool { return d.Status == coin.DeliveryPending })
+}
+
+// FindByWebhook retrieves the deliveries to a webhook, in the order they were added.
+func (s *DeliveryService) FindByWebhook(hook string) []coin.Delivery {
+	return s.filter(func(d coin.Delivery) bool { return d.Webhook == hook })
+}
+
+// filter retrieves the deliveries matching fn, in the order they were added.
+func (s *DeliveryService) filter(fn func(coin.Delivery) bool) []coin.Delivery {
+	deliveries := make([]coin.Delivery, 0)
+	s.client.Iterate(DeliveryCollection, func(k, v []byte) error {
+		var d coin.Delivery
+		json.Unmarshal(v, &d)
+
+		d.ID = string(k)
+		if fn(d) {
+			deliveries = append(deliveries, d)
+		}
+		return nil
+	})
+
+	sort.Slice(deliveries, func(i, j int) bool { return indexedLess(deliveries[i].ID, deliveries[j].ID) })
+	return deliveries
+}
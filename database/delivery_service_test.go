@@ -0,0 +1,67 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/stretchr/testify/assert"
+)
+
+// default test delivery.
+var testDelivery = coin.Delivery{
+	Webhook:     "1",
+	Event:       "treasure_found",
+	Game:        "1",
+	Payload:     `{"kind":"treasure_found"}`,
+	Status:      coin.DeliveryPending,
+	CreatedDate: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+	UpdatedDate: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// TestDeliveryService_Pending tests listing the deliveries still to be attempted, in the order they were added.
+func TestDeliveryService_Pending(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	for i := 0; i < 12; i++ {
+		d := testDelivery
+		d.Attempts = i
+		if i%3 == 0 {
+			d.Status = coin.DeliveryDelivered
+		}
+		_, err := c.DeliveryService().Add(d)
+		assert.Nil(t, err)
+	}
+
+	pending := c.DeliveryService().Pending()
+	assert.Len(t, pending, 8)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, 11, pending[7].Attempts)
+	assert.Len(t, c.DeliveryService().List(), 12)
+}
+
+// TestDeliveryService_SaveRemove tests updating and removing the deliveries of a webhook.
+func TestDeliveryService_SaveRemove(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	id, err := c.DeliveryService().Add(testDelivery)
+	assert.Nil(t, err)
+	other := testDelivery
+	other.Webhook = "2"
+	_, err = c.DeliveryService().Add(other)
+	assert.Nil(t, err)
+
+	d, err := c.DeliveryService().Find(id)
+	assert.Nil(t, err)
+	d.Attempts = 1
+	d.LastCode = 500
+	d.LastError = "unexpected status 500"
+	assert.Nil(t, c.DeliveryService().Save(d))
+	assert.Equal(t, []coin.Delivery{d}, c.DeliveryService().FindByWebhook("1"))
+
+	assert.Nil(t, c.DeliveryService().Remove(c.DeliveryService().FindByWebhook("1")...))
+	assert.Empty(t, c.DeliveryService().FindByWebhook("1"))
+	assert.Len(t, c.DeliveryService().FindByWebhook("2"), 1)
+}
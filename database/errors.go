@@ -9,9 +9,10 @@ const (
 	ErrTransaction       = coin.Error("failed to start transaction")
 	ErrCreateCollection  = coin.Error("failed to create collection")
 	ErrRecordNotFound    = coin.Error("record does not exist")
-	ErrRecordExists      = coin.Error("record already exists")
+	ErrRecordExists      = coin.ErrRecordExists
 	ErrCreateRecord      = coin.Error("failed to insert record")
 	ErrDeleteRecord      = coin.Error("failed to delete record")
 	ErrIterateCollection = coin.Error("failed to iterate over collection")
 	ErrCreateKey         = coin.Error("failed to generate a key for the  collection")
+	ErrNotOpen           = coin.Error("database is not open")
 )
@@ -38,6 +38,21 @@ func (s *GameService) Save(game coin.Game) error {
 	return s.client.Save(GameCollection, game.ID, j)
 }
 
+// Update changes the stored game with fn, so concurrent updates of different fields are not lost.
+// The game is not saved if fn fails.
+func (s *GameService) Update(id string, fn func(game *coin.Game) error) error {
+	return s.client.Update(GameCollection, id, func(v []byte) ([]byte, error) {
+		var g coin.Game
+		json.Unmarshal(v, &g)
+		g.ID = id
+
+		if err := fn(&g); err != nil {
+			return nil, err
+		}
+		return json.Marshal(g)
+	})
+}
+
 // Remove removes the game from the database.
 func (s *GameService) Remove(game coin.Game) error {
 	return s.client.Delete(GameCollection, game.ID)
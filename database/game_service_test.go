@@ -95,3 +95,35 @@ func TestGameService_ListRecords(t *testing.T) {
 	games := c.GameService().List()
 	assert.Equal(t, map[string]coin.Game{"1": testGame, "2": newGame}, games)
 }
+
+// TestGameService_Update tests changing a stored game.
+func TestGameService_Update(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	key, err := c.GameService().Add(testGame)
+	assert.Nil(t, err)
+
+	// failed updates are not saved.
+	err = c.GameService().Update(key, func(g *coin.Game) error {
+		g.Title = "Lost"
+		return coin.Error("failed")
+	})
+	assert.Equal(t, coin.Error("failed"), err)
+
+	err = c.GameService().Update(key, func(g *coin.Game) error {
+		assert.Equal(t, key, g.ID)
+		g.Hidden = true
+		return nil
+	})
+	assert.Nil(t, err)
+
+	game, err := c.GameService().Find(key)
+	assert.Nil(t, err)
+	assert.Equal(t, testGame.Title, game.Title)
+	assert.True(t, game.Hidden)
+
+	// missing game.
+	err = c.GameService().Update("2", func(g *coin.Game) error { return nil })
+	assert.Equal(t, database.ErrRecordNotFound, err)
+}
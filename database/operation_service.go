@@ -0,0 +1,54 @@
+package database
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/pmdcosta/treasure-coin"
+)
+
+const OperationCollection = "operations"
+
+// OperationService represents a service for managing the persistence of the deferred wallet operations.
+type OperationService struct {
+	client *Client
+}
+
+// Add stores the operation in the database, returning its generated id.
+func (s *OperationService) Add(op coin.Operation) (string, error) {
+	j, _ := json.Marshal(op)
+	return s.client.CreateIndexed(OperationCollection, j)
+}
+
+// Save upserts the operation to the database.
+func (s *OperationService) Save(op coin.Operation) error {
+	j, _ := json.Marshal(op)
+	return s.client.Save(OperationCollection, op.ID, j)
+}
+
+// Remove deletes the operation from the database.
+func (s *OperationService) Remove(op coin.Operation) error {
+	return s.client.Delete(OperationCollection, op.ID)
+}
+
+// List retrieves all the operations, in the order they were added.
+func (s *OperationService) List() []coin.Operation {
+	ops := make([]coin.Operation, 0)
+	s.client.Iterate(OperationCollection, func(k, v []byte) error {
+		var op coin.Operation
+		json.Unmarshal(v, &op)
+
+		op.ID = string(k)
+		ops = append(ops, op)
+		return nil
+	})
+
+	// the generated ids are sequential, but sorted as text by the database.
+	sort.Slice(ops, func(i, j int) bool {
+		a, _ := strconv.ParseUint(ops[i].ID, 10, 64)
+		b, _ := strconv.ParseUint(ops[j].ID, 10, 64)
+		return a < b
+	})
+	return ops
+}
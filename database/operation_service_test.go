@@ -0,0 +1,56 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/stretchr/testify/assert"
+)
+
+// default test operation.
+var testOperation = coin.Operation{
+	Kind:        coin.OperationSignUp,
+	User:        "luffy@onepiece.com",
+	Amount:      coin.Coin,
+	CreatedDate: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// TestOperationService_List tests listing the operations in the order they were added.
+func TestOperationService_List(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	// past nine ids the database sorts them as text.
+	for i := 0; i < 12; i++ {
+		op := testOperation
+		op.Attempts = i
+		_, err := c.OperationService().Add(op)
+		assert.Nil(t, err)
+	}
+
+	ops := c.OperationService().List()
+	assert.Len(t, ops, 12)
+	for i, op := range ops {
+		assert.Equal(t, i, op.Attempts)
+	}
+}
+
+// TestOperationService_SaveRemove tests updating and removing an operation.
+func TestOperationService_SaveRemove(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	id, err := c.OperationService().Add(testOperation)
+	assert.Nil(t, err)
+
+	op := testOperation
+	op.ID = id
+	op.Attempts = 1
+	op.LastError = "wallet provider is unavailable"
+	assert.Nil(t, c.OperationService().Save(op))
+	assert.Equal(t, []coin.Operation{op}, c.OperationService().List())
+
+	assert.Nil(t, c.OperationService().Remove(op))
+	assert.Empty(t, c.OperationService().List())
+}
@@ -25,3 +25,30 @@ func (s *SessionService) Find(token string) (string, error) {
 func (s *SessionService) Remove(token string) error {
 	return s.client.Delete(SessionCollection, token)
 }
+
+// Count returns how many sessions are open.
+func (s *SessionService) Count() int {
+	return s.client.Count(SessionCollection)
+}
+
+// FindByUser retrieves all the session tokens of a user.
+func (s *SessionService) FindByUser(session string) []string {
+	tokens := make([]string, 0)
+	s.client.Iterate(SessionCollection, func(k, v []byte) error {
+		if string(v) == session {
+			tokens = append(tokens, string(k))
+		}
+		return nil
+	})
+
+	return tokens
+}
+
+// RemoveByUser deletes all the sessions of a user from the database.
+func (s *SessionService) RemoveByUser(session string) error {
+	tokens := s.FindByUser(session)
+	if len(tokens) == 0 {
+		return nil
+	}
+	return s.client.Delete(SessionCollection, tokens...)
+}
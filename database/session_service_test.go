@@ -52,3 +52,35 @@ func TestSessionService_DeleteRecords(t *testing.T) {
 	assert.Equal(t, "", session)
 
 }
+
+// TestSessionService_RemoveByUser tests removing all the sessions of a user.
+func TestSessionService_RemoveByUser(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	assert.Nil(t, c.SessionService().Add(testToken+"_1", testSession))
+	assert.Nil(t, c.SessionService().Add(testToken+"_2", testSession))
+	assert.Nil(t, c.SessionService().Add(testToken+"_3", "other"))
+
+	tokens := c.SessionService().FindByUser(testSession)
+	assert.ElementsMatch(t, []string{testToken + "_1", testToken + "_2"}, tokens)
+
+	err := c.SessionService().RemoveByUser(testSession)
+	assert.Nil(t, err)
+
+	assert.Empty(t, c.SessionService().FindByUser(testSession))
+	session, err := c.SessionService().Find(testToken + "_3")
+	assert.Nil(t, err)
+	assert.Equal(t, "other", session)
+}
+
+// TestSessionService_Count tests counting the open sessions.
+func TestSessionService_Count(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	assert.Equal(t, 0, c.SessionService().Count())
+	assert.Nil(t, c.SessionService().Add(testToken, testSession))
+	assert.Nil(t, c.SessionService().Add("other", testSession))
+	assert.Equal(t, 2, c.SessionService().Count())
+}
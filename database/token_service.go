@@ -0,0 +1,73 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/pmdcosta/treasure-coin"
+)
+
+const TokenCollection = "tokens"
+
+// TokenService represents a service for managing API token persistence.
+// Tokens are stored by the hash of their secret value.
+type TokenService struct {
+	client *Client
+}
+
+// Add adds the record to the database if it does not exist.
+func (s *TokenService) Add(token coin.APIToken) error {
+	j, _ := json.Marshal(token)
+	return s.client.Create(TokenCollection, token.Hash, j)
+}
+
+// Find retrieves a token from the database by its hash.
+func (s *TokenService) Find(hash string) (coin.APIToken, error) {
+	j, err := s.client.Load(TokenCollection, hash)
+	if err != nil {
+		return coin.APIToken{}, err
+	}
+
+	var t coin.APIToken
+	json.Unmarshal(j, &t)
+
+	return t, nil
+}
+
+// Save upserts the token to the database.
+func (s *TokenService) Save(token coin.APIToken) error {
+	j, _ := json.Marshal(token)
+	return s.client.Save(TokenCollection, token.Hash, j)
+}
+
+// Remove deletes the token from the database.
+func (s *TokenService) Remove(token coin.APIToken) error {
+	return s.client.Delete(TokenCollection, token.Hash)
+}
+
+// FindByUser retrieves all the tokens of a user.
+func (s *TokenService) FindByUser(user string) []coin.APIToken {
+	tokens := make([]coin.APIToken, 0)
+	s.client.Iterate(TokenCollection, func(k, v []byte) error {
+		var t coin.APIToken
+		json.Unmarshal(v, &t)
+
+		if t.User == user {
+			tokens = append(tokens, t)
+		}
+		return nil
+	})
+
+	return tokens
+}
+
+// RemoveByUser deletes all the tokens of a user from the database.
+func (s *TokenService) RemoveByUser(user string) error {
+	hashes := make([]string, 0)
+	for _, t := range s.FindByUser(user) {
+		hashes = append(hashes, t.Hash)
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	return s.client.Delete(TokenCollection, hashes...)
+}
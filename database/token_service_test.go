@@ -0,0 +1,56 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// default test token.
+var testAPIToken = coin.APIToken{
+	ID:          "1",
+	Name:        "mobile",
+	User:        "test@user.com",
+	Hash:        "hash",
+	Scopes:      []coin.Scope{coin.ScopeGamesRead},
+	CreatedDate: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+	ExpiryDate:  time.Date(2018, 7, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// TestTokenService_LoadRecord tests retrieving a database record.
+func TestTokenService_LoadRecord(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	err := c.TokenService().Add(testAPIToken)
+	assert.Nil(t, err)
+
+	token, err := c.TokenService().Find(testAPIToken.Hash)
+	assert.Nil(t, err)
+	assert.Equal(t, testAPIToken, token)
+}
+
+// TestTokenService_RemoveByUser tests removing all the tokens of a user.
+func TestTokenService_RemoveByUser(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	other := testAPIToken
+	other.Hash = "other"
+	other.User = "other@user.com"
+
+	assert.Nil(t, c.TokenService().Add(testAPIToken))
+	assert.Nil(t, c.TokenService().Add(other))
+	assert.Equal(t, []coin.APIToken{testAPIToken}, c.TokenService().FindByUser(testAPIToken.User))
+
+	err := c.TokenService().RemoveByUser(testAPIToken.User)
+	assert.Nil(t, err)
+
+	_, err = c.TokenService().Find(testAPIToken.Hash)
+	assert.Equal(t, database.ErrRecordNotFound, err)
+	_, err = c.TokenService().Find(other.Hash)
+	assert.Nil(t, err)
+}
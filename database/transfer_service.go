@@ -0,0 +1,72 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/pmdcosta/treasure-coin"
+)
+
+const TransferCollection = "transfers"
+
+// TransferService represents a service for managing the persistence of the tracked transfers.
+// Transfers are stored by the transaction id of the wallet provider.
+type TransferService struct {
+	client *Client
+}
+
+// Add adds the record to the database if it does not exist.
+func (s *TransferService) Add(transfer coin.Transfer) error {
+	j, _ := json.Marshal(transfer)
+	return s.client.Create(TransferCollection, transfer.ID, j)
+}
+
+// Find retrieves a transfer from the database by its transaction id.
+func (s *TransferService) Find(id string) (coin.Transfer, error) {
+	j, err := s.client.Load(TransferCollection, id)
+	if err != nil {
+		return coin.Transfer{}, err
+	}
+
+	var t coin.Transfer
+	json.Unmarshal(j, &t)
+
+	return t, nil
+}
+
+// Save upserts the transfer to the database.
+func (s *TransferService) Save(transfer coin.Transfer) error {
+	j, _ := json.Marshal(transfer)
+	return s.client.Save(TransferCollection, transfer.ID, j)
+}
+
+// FindByWallet retrieves all the transfers sent from a wallet.
+func (s *TransferService) FindByWallet(wallet string) []coin.Transfer {
+	transfers := make([]coin.Transfer, 0)
+	s.client.Iterate(TransferCollection, func(k, v []byte) error {
+		var t coin.Transfer
+		json.Unmarshal(v, &t)
+
+		if t.Wallet == wallet {
+			transfers = append(transfers, t)
+		}
+		return nil
+	})
+
+	return transfers
+}
+
+// Pending retrieves all the transfers that have not settled yet.
+func (s *TransferService) Pending() []coin.Transfer {
+	transfers := make([]coin.Transfer, 0)
+	s.client.Iterate(TransferCollection, func(k, v []byte) error {
+		var t coin.Transfer
+		json.Unmarshal(v, &t)
+
+		if !t.Status.Settled() {
+			transfers = append(transfers, t)
+		}
+		return nil
+	})
+
+	return transfers
+}
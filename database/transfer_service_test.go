@@ -0,0 +1,68 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// default test transfer.
+var testTransfer = coin.Transfer{
+	ID:          "4f1a4c0f-6b4d-4bb5-9d8f-0c1d5b0f5b61",
+	Event:       coin.EventGameCreated,
+	Wallet:      "5190fed7-dbfb-4687-b2c8-b5cd57002198",
+	Amount:      coin.TreasurePrice.Mul(2),
+	Status:      coin.TransferPending,
+	Game:        "1",
+	CreatedDate: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+	UpdatedDate: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// TestTransferService_LoadRecord tests retrieving a database record.
+func TestTransferService_LoadRecord(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	assert.Nil(t, c.TransferService().Add(testTransfer))
+	assert.Equal(t, database.ErrRecordExists, c.TransferService().Add(testTransfer))
+
+	transfer, err := c.TransferService().Find(testTransfer.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, testTransfer, transfer)
+}
+
+// TestTransferService_Pending tests listing the transfers that have not settled.
+func TestTransferService_Pending(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	settled := testTransfer
+	settled.ID = "settled"
+	settled.Status = coin.TransferFailed
+
+	assert.Nil(t, c.TransferService().Add(testTransfer))
+	assert.Nil(t, c.TransferService().Add(settled))
+	assert.Equal(t, []coin.Transfer{testTransfer}, c.TransferService().Pending())
+
+	complete := testTransfer
+	complete.Status = coin.TransferComplete
+	assert.Nil(t, c.TransferService().Save(complete))
+	assert.Empty(t, c.TransferService().Pending())
+}
+
+// TestTransferService_FindByWallet tests listing the transfers sent from a wallet.
+func TestTransferService_FindByWallet(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	other := testTransfer
+	other.ID = "other"
+	other.Wallet = "other"
+
+	assert.Nil(t, c.TransferService().Add(testTransfer))
+	assert.Nil(t, c.TransferService().Add(other))
+	assert.Equal(t, []coin.Transfer{testTransfer}, c.TransferService().FindByWallet(testTransfer.Wallet))
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/pmdcosta/treasure-coin"
 )
@@ -38,6 +39,12 @@ func (s *UserService) Save(user coin.User) error {
 	return s.client.Save(UserCollection, user.Email, j)
 }
 
+// Rename moves the user stored under email to the user's current email.
+func (s *UserService) Rename(email string, user coin.User) error {
+	j, _ := json.Marshal(user)
+	return s.client.Rename(UserCollection, email, user.Email, j)
+}
+
 // Remove deletes the user from the database.
 func (s *UserService) Remove(user coin.User) error {
 	return s.client.Delete(UserCollection, user.Email)
@@ -58,3 +65,34 @@ func (s *UserService) FindByWallet(wallet string) coin.User {
 
 	return user
 }
+
+// FindByUsername retrieves the users with the username, ignoring case.
+// Usernames are not unique, so several users can be returned.
+func (s *UserService) FindByUsername(username string) []coin.User {
+	users := make([]coin.User, 0)
+	s.client.Iterate(UserCollection, func(k, v []byte) error {
+		var u coin.User
+		json.Unmarshal(v, &u)
+
+		if strings.EqualFold(u.Username, username) {
+			users = append(users, u)
+		}
+		return nil
+	})
+
+	return users
+}
+
+// List returns all the users from the database.
+func (s *UserService) List() map[string]coin.User {
+	users := make(map[string]coin.User)
+	s.client.Iterate(UserCollection, func(k, v []byte) error {
+		var u coin.User
+		json.Unmarshal(v, &u)
+
+		users[string(k)] = u
+		return nil
+	})
+
+	return users
+}
@@ -90,3 +90,77 @@ func TestUserService_DeleteRecords(t *testing.T) {
 	assert.Equal(t, coin.User{}, user)
 
 }
+
+// TestUserService_RenameRecord tests moving a user record to a new email.
+func TestUserService_RenameRecord(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	err := c.UserService().Add(testUser)
+	assert.Nil(t, err)
+
+	user := testUser
+	user.Email = "new@user.com"
+	err = c.UserService().Rename(testUser.Email, user)
+	assert.Nil(t, err)
+
+	_, err = c.UserService().Find(testUser.Email)
+	assert.Equal(t, database.ErrRecordNotFound, err)
+
+	nUser, err := c.UserService().Find(user.Email)
+	assert.Nil(t, err)
+	assert.Equal(t, user, nUser)
+}
+
+// TestUserService_RenameRecordExists tests moving a user record to an email already in use.
+func TestUserService_RenameRecordExists(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	other := testUser
+	other.Email = "other@user.com"
+
+	err := c.UserService().Add(testUser)
+	assert.Nil(t, err)
+	err = c.UserService().Add(other)
+	assert.Nil(t, err)
+
+	err = c.UserService().Rename(testUser.Email, other)
+	assert.Equal(t, database.ErrRecordExists, err)
+
+	user, err := c.UserService().Find(testUser.Email)
+	assert.Nil(t, err)
+	assert.Equal(t, testUser, user)
+}
+
+// TestUserService_ListRecords tests listing all the users in the database.
+func TestUserService_ListRecords(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	other := testUser
+	other.Email = "other@user.com"
+	other.Role = coin.RoleAdmin
+
+	assert.Nil(t, c.UserService().Add(testUser))
+	assert.Nil(t, c.UserService().Add(other))
+
+	users := c.UserService().List()
+	assert.Equal(t, map[string]coin.User{testUser.Email: testUser, other.Email: other}, users)
+}
+
+// TestUserService_FindByUsername tests retrieving the users with a username.
+func TestUserService_FindByUsername(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	other := testUser
+	other.Email = "other@user.com"
+	other.Username = "other"
+
+	assert.Nil(t, c.UserService().Add(testUser))
+	assert.Nil(t, c.UserService().Add(other))
+
+	assert.Equal(t, []coin.User{testUser}, c.UserService().FindByUsername("TEST"))
+	assert.Empty(t, c.UserService().FindByUsername("unknown"))
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pmdcosta/treasure-coin"
+)
+
+const WebhookCollection = "webhooks"
+
+// WebhookService represents a service for managing the persistence of the webhooks notified of the game events.
+type WebhookService struct {
+	client *Client
+}
+
+// Add stores the webhook in the database, returning its generated id.
+func (s *WebhookService) Add(hook coin.Webhook) (string, error) {
+	j, _ := json.Marshal(hook)
+	return s.client.CreateIndexed(WebhookCollection, j)
+}
+
+// Find retrieves a webhook from the database by its id.
+func (s *WebhookService) Find(id string) (coin.Webhook, error) {
+	j, err := s.client.Load(WebhookCollection, id)
+	if err != nil {
+		return coin.Webhook{}, err
+	}
+
+	var hook coin.Webhook
+	json.Unmarshal(j, &hook)
+	hook.ID = id
+
+	return hook, nil
+}
+
+// Save upserts the webhook to the database.
+func (s *WebhookService) Save(hook coin.Webhook) error {
+	j, _ := json.Marshal(hook)
+	return s.client.Save(WebhookCollection, hook.ID, j)
+}
+
+// Remove deletes the webhook from the database.
+func (s *WebhookService) Remove(hook coin.Webhook) error {
+	return s.client.Delete(WebhookCollection, hook.ID)
+}
+
+// List retrieves all the webhooks, in the order they were added.
+func (s *WebhookService) List() []coin.Webhook {
+	hooks := make([]coin.Webhook, 0)
+	s.client.Iterate(WebhookCollection, func(k, v []byte) error {
+		var hook coin.Webhook
+		json.Unmarshal(v, &hook)
+
+		hook.ID = string(k)
+		hooks = append(hooks, hook)
+		return nil
+	})
+
+	sort.Slice(hooks, func(i, j int) bool { return indexedLess(hooks[i].ID, hooks[j].ID) })
+	return hooks
+}
+
+// FindByOwner retrieves all the webhooks registered by a user, in the order they were added.
+func (s *WebhookService) FindByOwner(email string) []coin.Webhook {
+	hooks := make([]coin.Webhook, 0)
+	for _, hook := range s.List() {
+		if hook.Owner == email {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
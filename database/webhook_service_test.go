@@ -0,0 +1,59 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// default test webhook.
+var testWebhook = coin.Webhook{
+	URL:         "https://bot.onepiece.com/hooks",
+	Secret:      "6f2c1ad0e8b54b1d",
+	Game:        "1",
+	Owner:       "luffy@onepiece.com",
+	CreatedDate: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// TestWebhookService_AddFind tests storing and retrieving a webhook.
+func TestWebhookService_AddFind(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	id, err := c.WebhookService().Add(testWebhook)
+	assert.Nil(t, err)
+
+	hook, err := c.WebhookService().Find(id)
+	assert.Nil(t, err)
+	expected := testWebhook
+	expected.ID = id
+	assert.Equal(t, expected, hook)
+
+	assert.Nil(t, c.WebhookService().Remove(hook))
+	_, err = c.WebhookService().Find(id)
+	assert.Equal(t, database.ErrRecordNotFound, err)
+}
+
+// TestWebhookService_FindByOwner tests listing the webhooks of a user in the order they were added.
+func TestWebhookService_FindByOwner(t *testing.T) {
+	c := MustOpenClient()
+	defer c.Close()
+
+	for i := 0; i < 12; i++ {
+		hook := testWebhook
+		if i%2 == 1 {
+			hook.Owner = "zoro@onepiece.com"
+		}
+		_, err := c.WebhookService().Add(hook)
+		assert.Nil(t, err)
+	}
+
+	assert.Len(t, c.WebhookService().List(), 12)
+	hooks := c.WebhookService().FindByOwner("zoro@onepiece.com")
+	assert.Len(t, hooks, 6)
+	assert.Equal(t, "2", hooks[0].ID)
+	assert.Equal(t, "12", hooks[5].ID)
+}
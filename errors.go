@@ -1,7 +1,30 @@
 package coin
 
+import "fmt"
+
 // Error represents an application error.
 type Error string
 
 // Error returns the error message.
 func (e Error) Error() string { return string(e) }
+
+// wallet errors, shared by every wallet provider.
+const (
+	ErrInsufficientBalance = Error("insufficient token balance")
+	ErrInvalidWallet       = Error("wallet does not exist")
+	ErrRateLimited         = Error("wallet provider rate limit exceeded")
+	ErrWalletAuth          = Error("wallet provider rejected the credentials")
+	ErrWalletUnavailable   = Error("wallet provider is unavailable")
+	ErrInvalidWebhook      = Error("wallet provider webhook is not authentic")
+)
+
+// ErrWalletNotSent is returned instead of calling the wallet provider while it is known to be unavailable.
+// It is an ErrWalletUnavailable, but unlike the calls that failed once sent, like the timeouts, the call had no
+// effect and can safely be made again.
+var ErrWalletNotSent = fmt.Errorf("%w, the call was not sent", ErrWalletUnavailable)
+
+// ErrInvalidAmount is returned when parsing a malformed amount of coins.
+const ErrInvalidAmount = Error("invalid amount of coins")
+
+// ErrRecordExists is returned when storing a record under a key already in use, like renaming a user to a taken email.
+const ErrRecordExists = Error("record already exists")
@@ -0,0 +1,197 @@
+// Package events publishes the game events to the parts of the application following them, like the live feeds of the pages.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// event kinds.
+const (
+	KindGameCreated   = "game_created"
+	KindTreasureFound = "treasure_found"
+	KindGameFinished  = "game_finished"
+)
+
+// HistorySize is how many of the latest events are kept to be replayed to the subscribers reconnecting.
+const HistorySize = 64
+
+// subscriptionBuffer is how many events a subscriber can fall behind before missing them.
+const subscriptionBuffer = 16
+
+// Event represents something that happened in a game.
+type Event struct {
+	// ID increases with every event published.
+	ID   uint64    `json:"id"`
+	Kind string    `json:"kind"`
+	Date time.Time `json:"date"`
+
+	Game      string `json:"game"`
+	GameTitle string `json:"game_title"`
+
+	// treasure found and the username of the player who found it, if any.
+	Treasure     string `json:"treasure,omitempty"`
+	TreasureName string `json:"treasure_name,omitempty"`
+	Player       string `json:"player,omitempty"`
+}
+
+// GameCreated returns the event of a game being created.
+func GameCreated(game coin.Game) Event {
+	return Event{Kind: KindGameCreated, Game: game.ID, GameTitle: game.Title}
+}
+
+// TreasureFound returns the event of a treasure of the game being found by the player, named by username
+// as the events are streamed to every player.
+func TreasureFound(game coin.Game, treasure coin.Treasure, player string) Event {
+	return Event{
+		Kind:         KindTreasureFound,
+		Game:         game.ID,
+		GameTitle:    game.Title,
+		Treasure:     treasure.ID,
+		TreasureName: treasure.Name,
+		Player:       player,
+	}
+}
+
+// GameFinished returns the event of the last treasure of a game being found.
+func GameFinished(game coin.Game) Event {
+	return Event{Kind: KindGameFinished, Game: game.ID, GameTitle: game.Title}
+}
+
+// Bus represents an in-process event bus, sending the events published to the handlers and the subscribers.
+type Bus struct {
+	logger *log.Entry
+
+	mu       sync.Mutex
+	closed   bool
+	last     uint64
+	history  []Event
+	handlers []func(Event)
+	subs     map[*Subscription]struct{}
+}
+
+// NewBus returns a new event bus.
+func NewBus() *Bus {
+	return &Bus{
+		logger: log.WithFields(log.Fields{"package": "events"}),
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Handle registers a function called with every event, in the goroutine publishing it.
+// Handlers must be quick, and are meant for the consumers that cannot miss events.
+func (b *Bus) Handle(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, fn)
+}
+
+// Publish numbers and dates the event, and sends it to the handlers and the subscribers.
+// Subscribers falling behind miss the event rather than slowing the publisher.
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.last++
+	e.ID = b.last
+	if e.Date.IsZero() {
+		e.Date = time.Now()
+	}
+	b.history = append(b.history, e)
+	if len(b.history) > HistorySize {
+		b.history = b.history[len(b.history)-HistorySize:]
+	}
+
+	for s := range b.subs {
+		if !s.matches(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			b.logger.WithFields(log.Fields{"event": e.ID, "game": s.game}).Warn("subscriber is falling behind, event dropped")
+		}
+	}
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(e)
+	}
+}
+
+// Subscribe returns a subscription to the events of the game, or of every game when empty.
+// The events published after the last one seen are replayed first, if still kept.
+func (b *Bus) Subscribe(game string, after uint64) *Subscription {
+	s := &Subscription{bus: b, game: game}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// room for the events replayed on top of the buffer.
+	var replay []Event
+	if after > 0 {
+		for _, e := range b.history {
+			if e.ID > after && s.matches(e) {
+				replay = append(replay, e)
+			}
+		}
+	}
+	s.events = make(chan Event, subscriptionBuffer+len(replay))
+	for _, e := range replay {
+		s.events <- e
+	}
+
+	if b.closed {
+		close(s.events)
+		return s
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Close ends every subscription, and ignores the events published afterwards.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for s := range b.subs {
+		delete(b.subs, s)
+		close(s.events)
+	}
+}
+
+// Subscription represents a subscriber of the events of a game, or of every game.
+type Subscription struct {
+	bus    *Bus
+	game   string
+	events chan Event
+}
+
+// Events returns the events received, closed when the subscription or the bus is.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops receiving events.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.events)
+	}
+}
+
+// matches returns whether the event is sent to the subscriber.
+func (s *Subscription) matches(e Event) bool {
+	return s.game == "" || s.game == e.Game
+}
@@ -0,0 +1,90 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBus_Subscribe tests the subscribers receive the events of their game.
+func TestBus_Subscribe(t *testing.T) {
+	b := events.NewBus()
+	all := b.Subscribe("", 0)
+	one := b.Subscribe("game1", 0)
+	defer all.Close()
+	defer one.Close()
+
+	b.Publish(events.GameCreated(coin.Game{ID: "game1", Title: "Game 1"}))
+	b.Publish(events.GameCreated(coin.Game{ID: "game2", Title: "Game 2"}))
+
+	e := <-all.Events()
+	assert.Equal(t, uint64(1), e.ID)
+	assert.Equal(t, events.KindGameCreated, e.Kind)
+	assert.False(t, e.Date.IsZero())
+	assert.Equal(t, "game2", (<-all.Events()).Game)
+
+	assert.Equal(t, "game1", (<-one.Events()).Game)
+	assert.Len(t, one.Events(), 0)
+}
+
+// TestBus_Replay tests the events missed are replayed to the subscribers reconnecting.
+func TestBus_Replay(t *testing.T) {
+	b := events.NewBus()
+	game := coin.Game{ID: "game1"}
+	for i := 0; i < events.HistorySize+2; i++ {
+		b.Publish(events.TreasureFound(game, coin.Treasure{ID: "t1"}, "luffy"))
+	}
+
+	s := b.Subscribe("game1", events.HistorySize)
+	defer s.Close()
+	assert.Len(t, s.Events(), 2)
+	assert.Equal(t, uint64(events.HistorySize+1), (<-s.Events()).ID)
+
+	// the events no longer kept are missed.
+	s = b.Subscribe("game1", 1)
+	defer s.Close()
+	assert.Len(t, s.Events(), events.HistorySize)
+	assert.Equal(t, uint64(3), (<-s.Events()).ID)
+}
+
+// TestBus_Handle tests the handlers are called with every event.
+func TestBus_Handle(t *testing.T) {
+	b := events.NewBus()
+	var handled []events.Event
+	b.Handle(func(e events.Event) {
+		handled = append(handled, e)
+	})
+
+	b.Publish(events.GameFinished(coin.Game{ID: "game1"}))
+	assert.Len(t, handled, 1)
+	assert.Equal(t, events.KindGameFinished, handled[0].Kind)
+}
+
+// TestBus_Close tests closing the bus ends the subscriptions.
+func TestBus_Close(t *testing.T) {
+	b := events.NewBus()
+	s := b.Subscribe("", 0)
+	b.Close()
+
+	_, ok := <-s.Events()
+	assert.False(t, ok)
+	s.Close()
+
+	_, ok = <-b.Subscribe("", 0).Events()
+	assert.False(t, ok)
+	b.Publish(events.GameCreated(coin.Game{ID: "game1"}))
+}
+
+// TestBus_SlowSubscriber tests the events are dropped for the subscribers falling behind.
+func TestBus_SlowSubscriber(t *testing.T) {
+	b := events.NewBus()
+	s := b.Subscribe("", 0)
+	defer s.Close()
+
+	for i := 0; i < 100; i++ {
+		b.Publish(events.GameCreated(coin.Game{ID: "game1"}))
+	}
+	assert.True(t, len(s.Events()) < 100)
+}
@@ -0,0 +1,85 @@
+package evm
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ERC-20 functions and events used by the client.
+// The token must expose mint(address,uint256) to the treasury and burn(uint256) to the holders.
+var (
+	selectorBalanceOf = selector("balanceOf(address)")
+	selectorDecimals  = selector("decimals()")
+	selectorTransfer  = selector("transfer(address,uint256)")
+	selectorMint      = selector("mint(address,uint256)")
+	selectorBurn      = selector("burn(uint256)")
+
+	// TransferTopic is the topic of the ERC-20 Transfer(address,address,uint256) event.
+	TransferTopic = "0x" + hex.EncodeToString(keccak("Transfer(address,address,uint256)"))
+)
+
+// zeroAddress is the counterparty of the minted and burnt coins.
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// keccak returns the Keccak-256 hash of the text.
+func keccak(text string) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(text))
+	return h.Sum(nil)
+}
+
+// selector returns the hex selector of a function signature.
+func selector(signature string) string {
+	return hex.EncodeToString(keccak(signature)[:4])
+}
+
+// encodeCall returns the call data of a function with the supplied encoded arguments.
+func encodeCall(selector string, args ...string) string {
+	return "0x" + selector + strings.Join(args, "")
+}
+
+// encodeAddress encodes an address as an ABI word.
+func encodeAddress(address string) string {
+	return strings.Repeat("0", 24) + strings.TrimPrefix(strings.ToLower(address), "0x")
+}
+
+// encodeUint encodes an unsigned integer as an ABI word.
+func encodeUint(n *big.Int) string {
+	s := n.Text(16)
+	return strings.Repeat("0", 64-len(s)) + s
+}
+
+// decodeUint decodes an unsigned integer from an ABI word or a quantity, like "0x1a".
+func decodeUint(s string) (*big.Int, bool) {
+	s = strings.TrimPrefix(s, "0x")
+	if s == "" {
+		return new(big.Int), true
+	}
+	return new(big.Int).SetString(s, 16)
+}
+
+// decodeAddress decodes an address from an ABI word, like an indexed event topic.
+func decodeAddress(word string) string {
+	word = strings.TrimPrefix(word, "0x")
+	if len(word) < 40 {
+		return ""
+	}
+	return "0x" + word[len(word)-40:]
+}
+
+// validAddress returns whether the text is a hex address.
+func validAddress(address string) bool {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(address[2:])
+	return err == nil
+}
+
+// quantity encodes an integer as a JSON-RPC quantity.
+func quantity(n *big.Int) string {
+	return "0x" + n.Text(16)
+}
@@ -0,0 +1,362 @@
+// Package evm implements a wallet provider on an ERC-20 token of an EVM-compatible chain, over JSON-RPC.
+//
+// The wallets are accounts kept by the node, created with personal_newAccount and unlocked with a single passphrase
+// to send their transactions. The treasury account mints the coins, pays the rewards and collects the payments.
+package evm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultTimeout is the timeout of the JSON-RPC requests by default.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultLogRange is how many blocks every eth_getLogs request spans by default.
+const DefaultLogRange = 5000
+
+// Config are the EVM client settings.
+type Config struct {
+	// JSON-RPC endpoint of the node.
+	URL string
+	// Token is the address of the ERC-20 token contract.
+	Token string
+	// Treasury is the node account minting the coins, paying the rewards and collecting the payments.
+	Treasury string
+	// Passphrase unlocks the node accounts of the treasury and of the wallets.
+	Passphrase string
+	// GasFunding is how much wei is sent from the treasury to every new wallet to pay for its transactions.
+	GasFunding *big.Int
+	// StartBlock is the block the token was deployed at, where the transaction history starts.
+	StartBlock uint64
+	// LogRange is how many blocks every eth_getLogs request spans, as the nodes limit it.
+	LogRange uint64
+
+	Timeout    time.Duration
+	HTTPClient *http.Client
+	// Observer is told about every request made to the node, if set.
+	Observer Observer
+}
+
+// Client represents a client to interact with an ERC-20 token through a node.
+type Client struct {
+	logger *log.Entry
+
+	url        string
+	token      string
+	treasury   string
+	passphrase string
+	gasFunding *big.Int
+	startBlock uint64
+	logRange   uint64
+
+	// decimals of the token, resolved by Setup.
+	decimals int
+	ready    bool
+
+	observer Observer
+	http     *http.Client
+	id       int64
+}
+
+// NewClient returns a new EVM client.
+func NewClient(config Config) *Client {
+	c := &Client{
+		logger:     log.WithFields(log.Fields{"package": "evm"}),
+		url:        config.URL,
+		token:      config.Token,
+		treasury:   config.Treasury,
+		passphrase: config.Passphrase,
+		gasFunding: config.GasFunding,
+		startBlock: config.StartBlock,
+		logRange:   config.LogRange,
+		observer:   config.Observer,
+		http:       config.HTTPClient,
+	}
+
+	if c.logRange == 0 {
+		c.logRange = DefaultLogRange
+	}
+	if c.gasFunding == nil {
+		c.gasFunding = new(big.Int)
+	}
+	if c.http == nil {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		c.http = &http.Client{Timeout: timeout}
+	}
+	return c
+}
+
+// Setup checks the token and treasury addresses and reads the decimals of the token.
+// It must be called before any other method.
+func (c *Client) Setup(ctx context.Context) error {
+	if !validAddress(c.token) {
+		return coin.Error("evm: the token address " + c.token + " is invalid")
+	}
+	if !validAddress(c.treasury) {
+		return coin.Error("evm: the treasury address " + c.treasury + " is invalid")
+	}
+
+	var result string
+	if err := c.call(ctx, "eth_call", &result, c.callArgs(encodeCall(selectorDecimals)), "latest"); err != nil {
+		return err
+	}
+	decimals, ok := decodeUint(result)
+	if !ok || !decimals.IsInt64() || decimals.Int64() > 77 {
+		return coin.Error("evm: the token returned invalid decimals " + result)
+	}
+	c.decimals = int(decimals.Int64())
+	c.ready = true
+
+	c.logger.WithContext(ctx).WithFields(log.Fields{"contract": c.token, "decimals": c.decimals}).Info("token set up")
+	return nil
+}
+
+// Ping checks the node can be reached.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.call(ctx, "eth_blockNumber", nil)
+}
+
+// CreateWallet creates a node account for the wallet, funded with the gas allowance.
+func (c *Client) CreateWallet(ctx context.Context, name string) (string, error) {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"name": name}).Info("creating wallet account in the node")
+
+	var address string
+	if err := c.call(ctx, "personal_newAccount", &address, c.passphrase); err != nil {
+		return "", err
+	}
+
+	// fund the wallet to pay for its transactions.
+	if c.gasFunding.Sign() > 0 {
+		tx := map[string]string{"from": c.treasury, "to": address, "value": quantity(c.gasFunding)}
+		if err := c.call(ctx, "personal_sendTransaction", nil, tx, c.passphrase); err != nil {
+			return "", err
+		}
+	}
+
+	c.logger.WithContext(ctx).WithFields(log.Fields{"name": name, "address": address}).Info("wallet account created in the node")
+	return address, nil
+}
+
+// Balance retrieves the token balance of the wallet.
+func (c *Client) Balance(ctx context.Context, wallet string) (coin.Amount, error) {
+	if !c.ready {
+		return 0, ErrNotSetUp
+	}
+	if !validAddress(wallet) {
+		return 0, ErrInvalidWallet
+	}
+
+	var result string
+	if err := c.call(ctx, "eth_call", &result, c.callArgs(encodeCall(selectorBalanceOf, encodeAddress(wallet))), "latest"); err != nil {
+		return 0, err
+	}
+	units, ok := decodeUint(result)
+	if !ok {
+		return 0, newError(http.StatusOK, 0, "invalid balance "+result)
+	}
+	return c.fromUnits(units), nil
+}
+
+// Transfer sends coins between wallets, returning the transaction hash.
+func (c *Client) Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	if !validAddress(to) {
+		return "", ErrInvalidWallet
+	}
+	return c.send(ctx, from, selectorTransfer, amount, encodeAddress(to))
+}
+
+// Mint creates coins in the wallet from the treasury, returning the transaction hash.
+func (c *Client) Mint(ctx context.Context, to string, amount coin.Amount) (string, error) {
+	if !validAddress(to) {
+		return "", ErrInvalidWallet
+	}
+	return c.send(ctx, c.treasury, selectorMint, amount, encodeAddress(to))
+}
+
+// Burn destroys coins of the wallet, returning the transaction hash.
+func (c *Client) Burn(ctx context.Context, from string, amount coin.Amount) (string, error) {
+	return c.send(ctx, from, selectorBurn, amount)
+}
+
+// send sends a token transaction from the wallet, with the amount as the last argument.
+func (c *Client) send(ctx context.Context, from, selector string, amount coin.Amount, args ...string) (string, error) {
+	if !c.ready {
+		return "", ErrNotSetUp
+	}
+	if !validAddress(from) {
+		return "", ErrInvalidWallet
+	}
+	c.logger.WithContext(ctx).WithFields(log.Fields{"from": from, "selector": selector, "amount": amount}).Info("sending token transaction to the node")
+
+	units, err := c.toUnits(amount)
+	if err != nil {
+		return "", err
+	}
+	tx := c.callArgs(encodeCall(selector, append(args, encodeUint(units))...))
+	tx["from"] = from
+
+	var hash string
+	if err := c.call(ctx, "personal_sendTransaction", &hash, tx, c.passphrase); err != nil {
+		return "", err
+	}
+
+	c.logger.WithContext(ctx).WithFields(log.Fields{"from": from, "transaction": hash}).Info("token transaction sent to the node")
+	return hash, nil
+}
+
+// TransactionStatus retrieves the status of a transaction from its receipt.
+func (c *Client) TransactionStatus(ctx context.Context, id string) (coin.TransferStatus, error) {
+	var receipt *struct {
+		Status string `json:"status"`
+	}
+	if err := c.call(ctx, "eth_getTransactionReceipt", &receipt, id); err != nil {
+		return "", err
+	}
+	if receipt != nil {
+		if receipt.Status == "0x1" {
+			return coin.TransferComplete, nil
+		}
+		return coin.TransferFailed, nil
+	}
+
+	// not mined yet, unless the node has no record of the transaction.
+	var tx *json.RawMessage
+	if err := c.call(ctx, "eth_getTransactionByHash", &tx, id); err != nil {
+		return "", err
+	}
+	if tx == nil {
+		return "", ErrInvalidWallet
+	}
+	return coin.TransferPending, nil
+}
+
+// callArgs returns the arguments of a call to the token contract.
+func (c *Client) callArgs(data string) map[string]string {
+	return map[string]string{"to": c.token, "data": data}
+}
+
+// toUnits converts an amount of coins to token units, failing if the token decimals cannot hold it exactly.
+func (c *Client) toUnits(a coin.Amount) (*big.Int, error) {
+	n := big.NewInt(int64(a))
+	if c.decimals >= coin.AmountDecimals {
+		return n.Mul(n, pow10(c.decimals-coin.AmountDecimals)), nil
+	}
+	units, rem := new(big.Int).QuoRem(n, pow10(coin.AmountDecimals-c.decimals), new(big.Int))
+	if rem.Sign() != 0 {
+		return nil, ErrInexactAmount
+	}
+	return units, nil
+}
+
+// fromUnits converts token units to an amount of coins, truncating the decimal places an Amount cannot hold.
+func (c *Client) fromUnits(units *big.Int) coin.Amount {
+	n := new(big.Int).Set(units)
+	if c.decimals >= coin.AmountDecimals {
+		n.Quo(n, pow10(c.decimals-coin.AmountDecimals))
+	} else {
+		n.Mul(n, pow10(coin.AmountDecimals-c.decimals))
+	}
+	if !n.IsInt64() {
+		return coin.Amount(1<<63 - 1)
+	}
+	return coin.Amount(n.Int64())
+}
+
+// pow10 returns 10 to the power of n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// rpcRequest represents a JSON-RPC request.
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// rpcResponse represents a JSON-RPC response.
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// call executes a JSON-RPC method and decodes its result into out, reporting it to the observer.
+func (c *Client) call(ctx context.Context, method string, out interface{}, params ...interface{}) error {
+	start := time.Now()
+	err := c.roundTrip(ctx, method, out, params...)
+	if c.observer != nil {
+		c.observer.ObserveWallet(method, time.Since(start), err)
+	}
+	return err
+}
+
+// roundTrip executes a JSON-RPC method and decodes its result into out.
+func (c *Client) roundTrip(ctx context.Context, method string, out interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, _ := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: atomic.AddInt64(&c.id, 1), Method: method, Params: params})
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(ctx)
+
+	// make the request.
+	response, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return newError(0, 0, err.Error())
+	}
+
+	// parse the response.
+	defer response.Body.Close()
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return newError(response.StatusCode, 0, err.Error())
+	}
+	if response.StatusCode != http.StatusOK {
+		return newError(response.StatusCode, 0, string(contents))
+	}
+
+	var r rpcResponse
+	if err := json.Unmarshal(contents, &r); err != nil {
+		return newError(response.StatusCode, 0, "invalid response: "+string(contents))
+	}
+	if r.Error != nil {
+		return newError(response.StatusCode, r.Error.Code, r.Error.Message)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(r.Result, out); err != nil {
+			return newError(response.StatusCode, 0, "invalid response result: "+err.Error())
+		}
+	}
+	return nil
+}
+
+// Observer defines the interface to report the requests made to the node.
+type Observer interface {
+	ObserveWallet(endpoint string, duration time.Duration, err error)
+}
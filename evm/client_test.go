@@ -0,0 +1,296 @@
+package evm_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/evm"
+	"github.com/pmdcosta/treasure-coin/evm/evmtest"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Client is a test wrapper.
+type Client struct {
+	*evm.Client
+	Chain *evmtest.Chain
+}
+
+// NewClient returns a new instance of Client, set up on a simulated chain.
+func NewClient(t *testing.T) *Client {
+	log.SetLevel(log.DebugLevel)
+
+	chain := evmtest.NewChain()
+	c := &Client{
+		Client: chain.Client(),
+		Chain:  chain,
+	}
+	require.Nil(t, c.Setup(context.Background()))
+	return c
+}
+
+// Close stops the simulated chain.
+func (c *Client) Close() {
+	c.Chain.Close()
+}
+
+// MustCreateWallet creates a wallet holding the amount of coins.
+func (c *Client) MustCreateWallet(t *testing.T, amount coin.Amount) string {
+	w, err := c.CreateWallet(context.Background(), "Luffy")
+	require.Nil(t, err)
+	if amount > 0 {
+		_, err = c.Mint(context.Background(), w, amount)
+		require.Nil(t, err)
+	}
+	return w
+}
+
+// TestClient_Setup tests checking the settings and reading the token decimals.
+func TestClient_Setup(t *testing.T) {
+	chain := evmtest.NewChain()
+	defer chain.Close()
+
+	// calls fail before the setup.
+	_, err := chain.Client().Balance(context.Background(), chain.Treasury)
+	assert.Equal(t, evm.ErrNotSetUp, err)
+
+	config := chain.Config()
+	config.Token = "token"
+	assert.NotNil(t, evm.NewClient(config).Setup(context.Background()))
+
+	assert.Nil(t, chain.Client().Setup(context.Background()))
+}
+
+// TestClient_Ping tests checking the node can be reached, reporting the request to the observer.
+func TestClient_Ping(t *testing.T) {
+	chain := evmtest.NewChain()
+	defer chain.Close()
+
+	var methods []string
+	config := chain.Config()
+	config.Observer = ObserverFunc(func(endpoint string, duration time.Duration, err error) {
+		methods = append(methods, endpoint)
+	})
+	assert.Nil(t, evm.NewClient(config).Ping(context.Background()))
+	assert.Equal(t, []string{"eth_blockNumber"}, methods)
+}
+
+// ObserverFunc is a test observer calling the function for every request.
+type ObserverFunc func(endpoint string, duration time.Duration, err error)
+
+// ObserveWallet calls the function.
+func (f ObserverFunc) ObserveWallet(endpoint string, duration time.Duration, err error) {
+	f(endpoint, duration, err)
+}
+
+// TestClient_CreateWallet tests creating a wallet funded to pay for its transactions.
+func TestClient_CreateWallet(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	w, err := c.CreateWallet(context.Background(), "Luffy")
+	assert.Nil(t, err)
+	assert.Len(t, w, 42)
+	assert.Equal(t, big.NewInt(1e15), c.Chain.EtherOf(w))
+}
+
+// TestClient_Mint tests minting coins, converted to the token decimals.
+func TestClient_Mint(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	w := c.MustCreateWallet(t, 2*coin.Coin+coin.Coin/2)
+	units, _ := new(big.Int).SetString("2500000000000000000", 10)
+	assert.Equal(t, units, c.Chain.BalanceOf(w))
+
+	b, err := c.Balance(context.Background(), w)
+	assert.Nil(t, err)
+	assert.Equal(t, 2*coin.Coin+coin.Coin/2, b)
+}
+
+// TestClient_MintDecimals tests refusing the amounts a token with fewer decimals cannot hold.
+func TestClient_MintDecimals(t *testing.T) {
+	chain := evmtest.NewChain()
+	defer chain.Close()
+	chain.SetDecimals(6)
+	c := &Client{Client: chain.Client(), Chain: chain}
+	require.Nil(t, c.Setup(context.Background()))
+
+	w := c.MustCreateWallet(t, 2*coin.Coin+coin.Coin/2)
+	assert.Equal(t, big.NewInt(2500000), chain.BalanceOf(w))
+
+	_, err := c.Mint(context.Background(), w, coin.Coin/1e6+1)
+	assert.Equal(t, evm.ErrInexactAmount, err)
+	assert.Equal(t, big.NewInt(2500000), chain.BalanceOf(w))
+}
+
+// TestClient_Transfer tests sending coins between wallets.
+func TestClient_Transfer(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	from := c.MustCreateWallet(t, 10*coin.Coin)
+	to := c.MustCreateWallet(t, 0)
+
+	id, err := c.Transfer(context.Background(), from, to, 4*coin.Coin)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, id)
+
+	b, _ := c.Balance(context.Background(), from)
+	assert.Equal(t, 6*coin.Coin, b)
+	b, _ = c.Balance(context.Background(), to)
+	assert.Equal(t, 4*coin.Coin, b)
+}
+
+// TestClient_Errors tests the node errors are matched to the wallet errors.
+func TestClient_Errors(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	from := c.MustCreateWallet(t, coin.Coin)
+	to := c.MustCreateWallet(t, 0)
+
+	_, err := c.Transfer(context.Background(), from, to, 2*coin.Coin)
+	assert.True(t, errors.Is(err, evm.ErrInsufficientBalance))
+
+	_, err = c.Burn(context.Background(), from, 2*coin.Coin)
+	assert.True(t, errors.Is(err, evm.ErrInsufficientBalance))
+
+	_, err = c.Transfer(context.Background(), from, "wallet", coin.Coin)
+	assert.Equal(t, evm.ErrInvalidWallet, err)
+
+	_, err = c.Transfer(context.Background(), "0x000000000000000000000000000000000000dead", to, coin.Coin)
+	assert.True(t, errors.Is(err, evm.ErrInvalidWallet))
+
+	config := c.Chain.Config()
+	config.Passphrase = "other"
+	other := evm.NewClient(config)
+	require.Nil(t, other.Setup(context.Background()))
+	_, err = other.Transfer(context.Background(), from, to, coin.Coin)
+	assert.True(t, errors.Is(err, evm.ErrUnauthorized))
+
+	c.Chain.Down(true)
+	_, err = c.Balance(context.Background(), from)
+	assert.True(t, errors.Is(err, evm.ErrUnavailable))
+	assert.True(t, errors.Is(c.Ping(context.Background()), evm.ErrUnavailable))
+}
+
+// TestClient_History tests listing the transfers of a wallet, newest first.
+func TestClient_History(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	w := c.MustCreateWallet(t, 10*coin.Coin)
+	other := c.MustCreateWallet(t, 5*coin.Coin)
+	_, err := c.Transfer(context.Background(), w, other, 3*coin.Coin)
+	require.Nil(t, err)
+	_, err = c.Transfer(context.Background(), other, w, coin.Coin)
+	require.Nil(t, err)
+	_, err = c.Burn(context.Background(), w, 2*coin.Coin)
+	require.Nil(t, err)
+
+	var transactions []coin.Transaction
+	err = c.History(context.Background(), w, func(t coin.Transaction) error {
+		transactions = append(transactions, t)
+		return nil
+	})
+	assert.Nil(t, err)
+	require.Len(t, transactions, 4)
+
+	// burnt.
+	assert.Equal(t, w, transactions[0].FromWallet)
+	assert.Equal(t, "", transactions[0].ToWallet)
+	assert.Equal(t, -2*coin.Coin, transactions[0].Amount)
+
+	// received.
+	assert.Equal(t, other, transactions[1].FromWallet)
+	assert.Equal(t, coin.Coin, transactions[1].Amount)
+
+	// sent.
+	assert.Equal(t, other, transactions[2].ToWallet)
+	assert.Equal(t, -3*coin.Coin, transactions[2].Amount)
+
+	// minted.
+	assert.Equal(t, "", transactions[3].FromWallet)
+	assert.Equal(t, 10*coin.Coin, transactions[3].Amount)
+
+	assert.True(t, transactions[0].Date.After(transactions[3].Date))
+	assert.False(t, transactions[3].Date.Before(evmtest.GenesisTime))
+
+	// stops at the first error.
+	calls := 0
+	err = c.History(context.Background(), w, func(t coin.Transaction) error {
+		calls++
+		return coin.ErrInvalidAmount
+	})
+	assert.Equal(t, coin.ErrInvalidAmount, err)
+	assert.Equal(t, 1, calls)
+}
+
+// TestClient_HistoryRange tests reading the transfers in ranges of blocks the node accepts.
+func TestClient_HistoryRange(t *testing.T) {
+	chain := evmtest.NewChain()
+	defer chain.Close()
+	chain.LimitLogRange(2)
+	config := chain.Config()
+	config.LogRange = 2
+	c := &Client{Client: evm.NewClient(config), Chain: chain}
+	require.Nil(t, c.Setup(context.Background()))
+
+	w := c.MustCreateWallet(t, 10*coin.Coin)
+	for i := 0; i < 4; i++ {
+		_, err := c.Burn(context.Background(), w, coin.Coin)
+		require.Nil(t, err)
+	}
+
+	var transactions []coin.Transaction
+	err := c.History(context.Background(), w, func(t coin.Transaction) error {
+		transactions = append(transactions, t)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 5)
+
+	// the node refuses a range wider than its limit.
+	config.LogRange = 100
+	wide := evm.NewClient(config)
+	require.Nil(t, wide.Setup(context.Background()))
+	err = wide.History(context.Background(), w, func(coin.Transaction) error { return nil })
+	assert.Contains(t, err.Error(), "block range is too large")
+}
+
+// TestClient_TransactionStatus tests tracking a transaction until it is mined.
+func TestClient_TransactionStatus(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	from := c.MustCreateWallet(t, 5*coin.Coin)
+	to := c.MustCreateWallet(t, 0)
+
+	c.Chain.Hold()
+	first, err := c.Transfer(context.Background(), from, to, 4*coin.Coin)
+	require.Nil(t, err)
+	// valid when sent, but the first transfer spends the balance before it is mined.
+	second, err := c.Transfer(context.Background(), from, to, 4*coin.Coin)
+	require.Nil(t, err)
+
+	s, err := c.TransactionStatus(context.Background(), first)
+	assert.Nil(t, err)
+	assert.Equal(t, coin.TransferPending, s)
+
+	c.Chain.Mine()
+	s, err = c.TransactionStatus(context.Background(), first)
+	assert.Nil(t, err)
+	assert.Equal(t, coin.TransferComplete, s)
+	s, err = c.TransactionStatus(context.Background(), second)
+	assert.Nil(t, err)
+	assert.Equal(t, coin.TransferFailed, s)
+
+	_, err = c.TransactionStatus(context.Background(), "0x1234")
+	assert.Equal(t, evm.ErrInvalidWallet, err)
+}
@@ -0,0 +1,73 @@
+package evm
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pmdcosta/treasure-coin"
+)
+
+// EVM errors, matching the wallet errors shared by every provider.
+const (
+	ErrInsufficientBalance = coin.ErrInsufficientBalance
+	ErrInvalidWallet       = coin.ErrInvalidWallet
+	ErrRateLimited         = coin.ErrRateLimited
+	ErrUnauthorized        = coin.ErrWalletAuth
+	ErrUnavailable         = coin.ErrWalletUnavailable
+
+	ErrNotSetUp      = coin.Error("the token has not been set up")
+	ErrInexactAmount = coin.Error("the amount has more decimals than the token")
+)
+
+// Error represents a failed JSON-RPC request.
+type Error struct {
+	// http status code of the response, zero if no response was received.
+	StatusCode int
+
+	// error details from the JSON-RPC response.
+	Code    int
+	Message string
+
+	// Kind classifies the failure, nil if it matches none of the EVM errors.
+	Kind error
+}
+
+// newError returns a new classified Error.
+func newError(status, code int, message string) *Error {
+	e := &Error{StatusCode: status, Code: code, Message: message}
+	e.Kind = e.classify()
+	return e
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("evm: request failed with status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("evm: request failed with status %d: %s (%d)", e.StatusCode, e.Message, e.Code)
+}
+
+// Unwrap returns the kind of the error, so it can be matched with errors.Is.
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// classify maps the response status and JSON-RPC error details to one of the EVM errors.
+func (e *Error) classify() error {
+	msg := strings.ToLower(e.Message)
+
+	switch {
+	case e.StatusCode == 0 || e.StatusCode >= http.StatusInternalServerError:
+		return ErrUnavailable
+	case e.StatusCode == http.StatusTooManyRequests || strings.Contains(msg, "rate limit"):
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden || strings.Contains(msg, "could not decrypt key") || strings.Contains(msg, "authentication needed"):
+		return ErrUnauthorized
+	case strings.Contains(msg, "exceeds balance") || strings.Contains(msg, "insufficient funds") || strings.Contains(msg, "insufficient balance"):
+		return ErrInsufficientBalance
+	case strings.Contains(msg, "unknown account") || strings.Contains(msg, "no key for given address"):
+		return ErrInvalidWallet
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+// Package evmtest provides an in-process simulated chain holding an ERC-20 token, to test the EVM wallet provider.
+package evmtest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin/evm"
+	"golang.org/x/crypto/sha3"
+)
+
+// Passphrase unlocks the accounts of the simulated chain.
+const Passphrase = "treasure"
+
+// Decimals are the decimals of the simulated token.
+const Decimals = 18
+
+// GenesisTime is when the first block of the simulated chain was mined, each block is mined a second later.
+var GenesisTime = time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// token functions and events.
+var (
+	selectorBalanceOf = selector("balanceOf(address)")
+	selectorDecimals  = selector("decimals()")
+	selectorTransfer  = selector("transfer(address,uint256)")
+	selectorMint      = selector("mint(address,uint256)")
+	selectorBurn      = selector("burn(uint256)")
+	transferTopic     = "0x" + hex.EncodeToString(keccak("Transfer(address,address,uint256)"))
+)
+
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// Chain represents a simulated chain answering the JSON-RPC methods of a node.
+// Transactions are mined as soon as they are sent, unless mining is held.
+type Chain struct {
+	*httptest.Server
+
+	// Token is the address of the token contract and Treasury the account allowed to mint it.
+	Token    string
+	Treasury string
+
+	mu       sync.Mutex
+	accounts map[string]bool
+	balances map[string]*big.Int
+	ether    map[string]*big.Int
+	logs     []eventLog
+	receipts map[string]string
+	pending  []transaction
+	blocks   int
+	nonce    int
+	hold     bool
+	down     bool
+
+	// decimals of the token and most blocks a log query can span, unlimited if zero.
+	decimals int
+	logRange int
+}
+
+// eventLog represents a Transfer event of the token.
+type eventLog struct {
+	hash   string
+	block  int
+	index  int
+	from   string
+	to     string
+	amount *big.Int
+}
+
+// transaction represents a transaction waiting to be mined.
+type transaction struct {
+	hash  string
+	from  string
+	to    string
+	data  string
+	value *big.Int
+}
+
+// NewChain starts a new simulated chain with the token deployed and the treasury account created.
+func NewChain() *Chain {
+	c := &Chain{
+		accounts: make(map[string]bool),
+		balances: make(map[string]*big.Int),
+		ether:    make(map[string]*big.Int),
+		receipts: make(map[string]string),
+		decimals: Decimals,
+	}
+	c.Token = c.newAddress()
+	c.Treasury = c.newAddress()
+	c.accounts[c.Treasury] = true
+	c.ether[c.Treasury] = new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)
+
+	c.Server = httptest.NewServer(http.HandlerFunc(c.serve))
+	return c
+}
+
+// Config returns the settings of a client connected to the chain.
+func (c *Chain) Config() evm.Config {
+	return evm.Config{
+		URL:        c.URL,
+		Token:      c.Token,
+		Treasury:   c.Treasury,
+		Passphrase: Passphrase,
+		GasFunding: big.NewInt(1e15),
+		Timeout:    2 * time.Second,
+	}
+}
+
+// Client returns a new client connected to the chain, not set up.
+func (c *Chain) Client() *evm.Client {
+	return evm.NewClient(c.Config())
+}
+
+// Down makes the node unreachable, answering every request with an error status.
+func (c *Chain) Down(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.down = down
+}
+
+// SetDecimals changes the decimals of the token, read by the clients when they are set up.
+func (c *Chain) SetDecimals(decimals int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decimals = decimals
+}
+
+// LimitLogRange rejects the log queries spanning more than n blocks, like the public nodes do.
+func (c *Chain) LimitLogRange(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logRange = n
+}
+
+// Hold stops mining the transactions sent, until Mine is called.
+func (c *Chain) Hold() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hold = true
+}
+
+// Mine mines the held transactions in a new block and resumes mining as they are sent.
+func (c *Chain) Mine() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hold = false
+	c.mine()
+}
+
+// BalanceOf returns the token balance of the account, in token units.
+func (c *Chain) BalanceOf(address string) *big.Int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return new(big.Int).Set(c.balance(address))
+}
+
+// EtherOf returns the ether balance of the account, in wei.
+func (c *Chain) EtherOf(address string) *big.Int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.ether[strings.ToLower(address)]; ok {
+		return new(big.Int).Set(e)
+	}
+	return new(big.Int)
+}
+
+// serve answers a JSON-RPC request.
+func (c *Chain) serve(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.down {
+		http.Error(w, "node unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.handle(req.Method, req.Params)
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if err != nil {
+		resp["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+	} else {
+		resp["result"] = result
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handle executes a JSON-RPC method.
+func (c *Chain) handle(method string, params []json.RawMessage) (interface{}, error) {
+	arg := func(i int, v interface{}) {
+		if i < len(params) {
+			json.Unmarshal(params[i], v)
+		}
+	}
+
+	switch method {
+	case "personal_newAccount":
+		var passphrase string
+		arg(0, &passphrase)
+		if passphrase != Passphrase {
+			return nil, fmt.Errorf("passphrase rejected")
+		}
+		address := c.newAddress()
+		c.accounts[address] = true
+		return address, nil
+
+	case "personal_sendTransaction":
+		var tx map[string]string
+		var passphrase string
+		arg(0, &tx)
+		arg(1, &passphrase)
+		return c.send(tx, passphrase)
+
+	case "eth_call":
+		var call map[string]string
+		arg(0, &call)
+		return c.call(call)
+
+	case "eth_getLogs":
+		var filter struct {
+			FromBlock string        `json:"fromBlock"`
+			ToBlock   string        `json:"toBlock"`
+			Address   string        `json:"address"`
+			Topics    []interface{} `json:"topics"`
+		}
+		arg(0, &filter)
+		from, to := c.blockNumber(filter.FromBlock), c.blockNumber(filter.ToBlock)
+		if c.logRange > 0 && to-from+1 > c.logRange {
+			return nil, fmt.Errorf("block range is too large, the limit is %d blocks", c.logRange)
+		}
+		return c.filterLogs(from, to, filter.Address, filter.Topics), nil
+
+	case "eth_blockNumber":
+		return quantity(int64(c.blocks)), nil
+
+	case "eth_getBlockByNumber":
+		var number string
+		arg(0, &number)
+		n, _ := new(big.Int).SetString(strings.TrimPrefix(number, "0x"), 16)
+		if n == nil || n.Int64() > int64(c.blocks) {
+			return nil, nil
+		}
+		return map[string]string{"number": number, "timestamp": quantity(GenesisTime.Unix() + n.Int64())}, nil
+
+	case "eth_getTransactionReceipt":
+		var hash string
+		arg(0, &hash)
+		status, ok := c.receipts[hash]
+		if !ok {
+			return nil, nil
+		}
+		return map[string]string{"transactionHash": hash, "status": status}, nil
+
+	case "eth_getTransactionByHash":
+		var hash string
+		arg(0, &hash)
+		if _, ok := c.receipts[hash]; ok {
+			return map[string]string{"hash": hash}, nil
+		}
+		for _, tx := range c.pending {
+			if tx.hash == hash {
+				return map[string]string{"hash": hash}, nil
+			}
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("the method %s does not exist/is not available", method)
+}
+
+// send validates a transaction like a node estimating its gas, and queues it to be mined.
+func (c *Chain) send(tx map[string]string, passphrase string) (interface{}, error) {
+	from := strings.ToLower(tx["from"])
+	if !c.accounts[from] {
+		return nil, fmt.Errorf("unknown account")
+	}
+	if passphrase != Passphrase {
+		return nil, fmt.Errorf("could not decrypt key with given password")
+	}
+
+	t := transaction{from: from, to: strings.ToLower(tx["to"]), data: strings.TrimPrefix(tx["data"], "0x"), value: new(big.Int)}
+	if v, ok := new(big.Int).SetString(strings.TrimPrefix(tx["value"], "0x"), 16); ok {
+		t.value = v
+	}
+	if err := c.validate(t); err != nil {
+		return nil, err
+	}
+
+	c.nonce++
+	t.hash = "0x" + hex.EncodeToString(keccak(fmt.Sprintf("tx-%d", c.nonce)))
+	c.pending = append(c.pending, t)
+	if !c.hold {
+		c.mine()
+	}
+	return t.hash, nil
+}
+
+// validate checks the transaction would succeed at the current state.
+func (c *Chain) validate(t transaction) error {
+	if t.to != c.Token {
+		if c.ether[t.from] == nil || c.ether[t.from].Cmp(t.value) < 0 {
+			return fmt.Errorf("insufficient funds for transfer")
+		}
+		return nil
+	}
+
+	_, amount, err := c.decode(t)
+	if err != nil {
+		return err
+	}
+	switch t.data[:8] {
+	case selectorMint:
+		if t.from != c.Treasury {
+			return fmt.Errorf("execution reverted: caller is not the minter")
+		}
+	case selectorTransfer, selectorBurn:
+		if c.balance(t.from).Cmp(amount) < 0 {
+			return fmt.Errorf("execution reverted: ERC20: transfer amount exceeds balance")
+		}
+	}
+	return nil
+}
+
+// mine applies the pending transactions in a new block.
+func (c *Chain) mine() {
+	if len(c.pending) == 0 {
+		return
+	}
+	c.blocks++
+
+	for i, t := range c.pending {
+		if t.to != c.Token {
+			c.ether[t.from].Sub(c.ether[t.from], t.value)
+			if c.ether[t.to] == nil {
+				c.ether[t.to] = new(big.Int)
+			}
+			c.ether[t.to].Add(c.ether[t.to], t.value)
+			c.receipts[t.hash] = "0x1"
+			continue
+		}
+
+		// transactions invalidated by the ones mined before them fail.
+		if c.validate(t) != nil {
+			c.receipts[t.hash] = "0x0"
+			continue
+		}
+		to, amount, _ := c.decode(t)
+		from := t.from
+		switch t.data[:8] {
+		case selectorMint:
+			from = zeroAddress
+		case selectorBurn:
+			to = zeroAddress
+		}
+		if from != zeroAddress {
+			c.balance(from).Sub(c.balance(from), amount)
+		}
+		if to != zeroAddress {
+			c.balance(to).Add(c.balance(to), amount)
+		}
+		c.logs = append(c.logs, eventLog{hash: t.hash, block: c.blocks, index: i, from: from, to: to, amount: amount})
+		c.receipts[t.hash] = "0x1"
+	}
+	c.pending = nil
+}
+
+// decode decodes the recipient and amount of a token transaction.
+func (c *Chain) decode(t transaction) (string, *big.Int, error) {
+	if len(t.data) < 8 {
+		return "", nil, fmt.Errorf("execution reverted")
+	}
+	switch t.data[:8] {
+	case selectorTransfer, selectorMint:
+		if len(t.data) != 8+128 {
+			return "", nil, fmt.Errorf("execution reverted")
+		}
+		amount, _ := new(big.Int).SetString(t.data[72:], 16)
+		return "0x" + t.data[32:72], amount, nil
+	case selectorBurn:
+		if len(t.data) != 8+64 {
+			return "", nil, fmt.Errorf("execution reverted")
+		}
+		amount, _ := new(big.Int).SetString(t.data[8:], 16)
+		return "", amount, nil
+	}
+	return "", nil, fmt.Errorf("execution reverted")
+}
+
+// call executes a read-only call to the token.
+func (c *Chain) call(call map[string]string) (interface{}, error) {
+	data := strings.TrimPrefix(call["data"], "0x")
+	if strings.ToLower(call["to"]) != c.Token || len(data) < 8 {
+		return "0x", nil
+	}
+	switch data[:8] {
+	case selectorDecimals:
+		return "0x" + word(big.NewInt(int64(c.decimals))), nil
+	case selectorBalanceOf:
+		if len(data) != 8+64 {
+			return nil, fmt.Errorf("execution reverted")
+		}
+		return "0x" + word(c.balance("0x"+data[32:])), nil
+	}
+	return nil, fmt.Errorf("execution reverted")
+}
+
+// blockNumber decodes a block parameter, the latest block if it is a tag.
+func (c *Chain) blockNumber(block string) int {
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(block, "0x"), 16)
+	if !ok {
+		return c.blocks
+	}
+	return int(n.Int64())
+}
+
+// filterLogs returns the Transfer logs of the token mined in the block range and matching the topics.
+func (c *Chain) filterLogs(fromBlock, toBlock int, address string, topics []interface{}) []map[string]interface{} {
+	match := func(i int, value string) bool {
+		if i >= len(topics) || topics[i] == nil {
+			return true
+		}
+		t, _ := topics[i].(string)
+		return strings.EqualFold(t, value)
+	}
+
+	logs := make([]map[string]interface{}, 0)
+	for _, l := range c.logs {
+		from, to := "0x"+strings.Repeat("0", 24)+l.from[2:], "0x"+strings.Repeat("0", 24)+l.to[2:]
+		if l.block < fromBlock || l.block > toBlock || !strings.EqualFold(address, c.Token) || !match(0, transferTopic) || !match(1, from) || !match(2, to) {
+			continue
+		}
+		logs = append(logs, map[string]interface{}{
+			"address":         c.Token,
+			"transactionHash": l.hash,
+			"blockNumber":     quantity(int64(l.block)),
+			"logIndex":        quantity(int64(l.index)),
+			"topics":          []string{transferTopic, from, to},
+			"data":            "0x" + word(l.amount),
+		})
+	}
+	return logs
+}
+
+// balance returns the token balance of the account, creating it if needed.
+func (c *Chain) balance(address string) *big.Int {
+	address = strings.ToLower(address)
+	if c.balances[address] == nil {
+		c.balances[address] = new(big.Int)
+	}
+	return c.balances[address]
+}
+
+// newAddress returns a new unique address.
+func (c *Chain) newAddress() string {
+	c.nonce++
+	return "0x" + hex.EncodeToString(keccak(fmt.Sprintf("account-%d", c.nonce))[12:])
+}
+
+// keccak returns the Keccak-256 hash of the text.
+func keccak(text string) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(text))
+	return h.Sum(nil)
+}
+
+// selector returns the hex selector of a function signature.
+func selector(signature string) string {
+	return hex.EncodeToString(keccak(signature)[:4])
+}
+
+// word encodes an unsigned integer as an ABI word.
+func word(n *big.Int) string {
+	s := n.Text(16)
+	return strings.Repeat("0", 64-len(s)) + s
+}
+
+// quantity encodes an integer as a JSON-RPC quantity.
+func quantity(n int64) string {
+	return fmt.Sprintf("0x%x", n)
+}
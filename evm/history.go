@@ -0,0 +1,144 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// transferLog represents an ERC-20 Transfer event log.
+type transferLog struct {
+	TransactionHash string   `json:"transactionHash"`
+	BlockNumber     string   `json:"blockNumber"`
+	LogIndex        string   `json:"logIndex"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+
+	// decoded positions, used for sorting.
+	block uint64
+	index uint64
+}
+
+// History calls fn for every token transfer of the wallet, newest first, and stops at the first error, returned as is.
+// Minted coins are reported without a sender and burnt coins without a recipient.
+func (c *Client) History(ctx context.Context, wallet string, fn func(coin.Transaction) error) error {
+	if !c.ready {
+		return ErrNotSetUp
+	}
+	if !validAddress(wallet) {
+		return ErrInvalidWallet
+	}
+	wallet = strings.ToLower(wallet)
+	c.logger.WithContext(ctx).WithFields(log.Fields{"wallet": wallet}).Info("getting wallet transfers from the node")
+
+	var latest string
+	if err := c.call(ctx, "eth_blockNumber", &latest); err != nil {
+		return err
+	}
+	last, ok := decodeUint(latest)
+	if !ok || !last.IsUint64() {
+		return coin.Error("evm: the node returned an invalid block number " + latest)
+	}
+
+	// the transfers sent and received by the wallet, in ranges of blocks the node accepts.
+	var logs []transferLog
+	seen := make(map[string]bool)
+	for from := c.startBlock; from <= last.Uint64(); from += c.logRange {
+		to := from + c.logRange - 1
+		if to > last.Uint64() {
+			to = last.Uint64()
+		}
+		for _, topics := range [][]interface{}{
+			{TransferTopic, "0x" + encodeAddress(wallet)},
+			{TransferTopic, nil, "0x" + encodeAddress(wallet)},
+		} {
+			var found []transferLog
+			filter := map[string]interface{}{
+				"fromBlock": quantity(new(big.Int).SetUint64(from)),
+				"toBlock":   quantity(new(big.Int).SetUint64(to)),
+				"address":   c.token,
+				"topics":    topics,
+			}
+			if err := c.call(ctx, "eth_getLogs", &found, filter); err != nil {
+				return err
+			}
+			for _, l := range found {
+				if key := l.TransactionHash + l.LogIndex; !seen[key] && len(l.Topics) == 3 {
+					seen[key] = true
+					logs = append(logs, l)
+				}
+			}
+		}
+	}
+
+	// newest first.
+	for i := range logs {
+		if n, ok := decodeUint(logs[i].BlockNumber); ok {
+			logs[i].block = n.Uint64()
+		}
+		if n, ok := decodeUint(logs[i].LogIndex); ok {
+			logs[i].index = n.Uint64()
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].block != logs[j].block {
+			return logs[i].block > logs[j].block
+		}
+		return logs[i].index > logs[j].index
+	})
+
+	times := make(map[string]time.Time)
+	for _, l := range logs {
+		date, ok := times[l.BlockNumber]
+		if !ok {
+			var err error
+			if date, err = c.blockTime(ctx, l.BlockNumber); err != nil {
+				return err
+			}
+			times[l.BlockNumber] = date
+		}
+
+		t := coin.Transaction{
+			ID:         l.TransactionHash,
+			FromWallet: decodeAddress(l.Topics[1]),
+			ToWallet:   decodeAddress(l.Topics[2]),
+			Date:       date,
+		}
+		if units, ok := decodeUint(l.Data); ok {
+			t.Amount = c.fromUnits(units)
+		}
+		if t.FromWallet == zeroAddress {
+			t.FromWallet = ""
+		}
+		if t.ToWallet == zeroAddress {
+			t.ToWallet = ""
+		}
+		if t.FromWallet == wallet && t.ToWallet != wallet {
+			t.Amount = t.Amount.Neg()
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blockTime retrieves the time a block was mined.
+func (c *Client) blockTime(ctx context.Context, number string) (time.Time, error) {
+	var block struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := c.call(ctx, "eth_getBlockByNumber", &block, number, false); err != nil {
+		return time.Time{}, err
+	}
+	ts, _ := decodeUint(block.Timestamp)
+	if ts == nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(ts.Int64(), 0), nil
+}
@@ -0,0 +1,173 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// AssetsPath is the address the static assets are served from.
+const AssetsPath = "/assets/"
+
+// fingerprint is the query parameter holding the hash of an asset, changing its url whenever its content changes.
+const fingerprint = "v"
+
+// cache control of the fingerprinted assets, which never change, and of the rest, which are revalidated.
+const (
+	immutableCache  = "public, max-age=31536000, immutable"
+	revalidateCache = "no-cache"
+)
+
+// Files are the templates and static assets served.
+type Files struct {
+	Templates fs.FS
+	Assets    fs.FS
+
+	// Codes is the directory the QR codes of the treasures are written to, served with the assets.
+	Codes string
+
+	// Reload reads the templates and assets again on every request, for development.
+	Reload bool
+}
+
+// assets serves the static assets and fingerprints their urls.
+type assets struct {
+	files  fs.FS
+	reload bool
+
+	// hashes of the assets, by name.
+	mu     sync.Mutex
+	hashes map[string]string
+}
+
+// newAssets returns a new instance of assets.
+func newAssets(files fs.FS, reload bool) *assets {
+	return &assets{
+		files:  files,
+		reload: reload,
+		hashes: make(map[string]string),
+	}
+}
+
+// URL returns the fingerprinted url of an asset, like "/assets/css/index.css?v=4f2a9c1b7d3e8a60".
+func (a *assets) URL(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	hash, err := a.hash(name)
+	if err != nil {
+		return AssetsPath + name
+	}
+	return AssetsPath + name + "?" + fingerprint + "=" + hash
+}
+
+// hash returns the hash of the content of an asset.
+func (a *assets) hash(name string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if hash, ok := a.hashes[name]; ok && !a.reload {
+		return hash, nil
+	}
+
+	f, err := a.files.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))[:16]
+	a.hashes[name] = hash
+	return hash, nil
+}
+
+// Serve serves the assets, cached for good when requested by their fingerprinted url and revalidated otherwise.
+// Requests for missing assets are left to the handlers.
+func (a *assets) Serve() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			return
+		}
+		if !strings.HasPrefix(c.Request.URL.Path, AssetsPath) {
+			return
+		}
+
+		name := path.Clean(strings.TrimPrefix(c.Request.URL.Path, AssetsPath))
+		if !fs.ValidPath(name) {
+			return
+		}
+		f, err := a.files.Open(name)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+
+		stat, err := f.Stat()
+		content, ok := f.(io.ReadSeeker)
+		if err != nil || stat.IsDir() || !ok {
+			return
+		}
+		hash, err := a.hash(name)
+		if err != nil {
+			return
+		}
+
+		c.Header("ETag", `"`+hash+`"`)
+		if c.Query(fingerprint) == hash {
+			c.Header("Cache-Control", immutableCache)
+		} else {
+			c.Header("Cache-Control", revalidateCache)
+		}
+		http.ServeContent(c.Writer, c.Request, name, stat.ModTime(), content)
+		c.Abort()
+	}
+}
+
+// templates renders the pages, parsing the templates once or, when reloading, on every page.
+type templates struct {
+	files  fs.FS
+	funcs  template.FuncMap
+	reload bool
+
+	parsed *template.Template
+}
+
+// newTemplates returns the page templates, failing if they cannot be parsed.
+func newTemplates(files fs.FS, funcs template.FuncMap, reload bool) (*templates, error) {
+	t := &templates{files: files, funcs: funcs, reload: reload}
+	parsed, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+	t.parsed = parsed
+	return t, nil
+}
+
+// parse parses every template.
+func (t *templates) parse() (*template.Template, error) {
+	return template.New("").Funcs(t.funcs).ParseFS(t.files, "*.html")
+}
+
+// Instance returns the renderer of a page.
+func (t *templates) Instance(name string, data interface{}) render.Render {
+	if !t.reload {
+		return render.HTML{Template: t.parsed, Name: name, Data: data}
+	}
+
+	// shows the developer why the changed templates are broken.
+	parsed, err := t.parse()
+	if err != nil {
+		return render.String{Format: "%s", Data: []interface{}{err.Error()}}
+	}
+	return render.HTML{Template: parsed, Name: name, Data: data}
+}
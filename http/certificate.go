@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNoCertificate is returned on handshakes before the certificate is loaded.
+const ErrNoCertificate = coin.Error("no tls certificate loaded")
+
+// certificate holds the TLS certificate of the server, loaded again from its files when they change.
+type certificate struct {
+	logger *log.Entry
+
+	// certificate and key files.
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// newCertificate returns a new certificate read from the files, not loaded yet.
+func newCertificate(certFile, keyFile string) *certificate {
+	return &certificate{
+		logger:   log.WithFields(log.Fields{"package": "http", "module": "certificate"}),
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+}
+
+// Load reads the certificate files, keeping the current certificate when they cannot be read.
+func (c *certificate) Load() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.mu.Unlock()
+	c.logger.WithFields(log.Fields{"cert": c.certFile}).Info("certificate loaded")
+	return nil
+}
+
+// GetCertificate returns the current certificate to the TLS handshakes.
+func (c *certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.cert == nil {
+		return nil, ErrNoCertificate
+	}
+	return c.cert, nil
+}
+
+// Watch loads the certificate again whenever its files change, until the context is done.
+// The directories holding the files are watched, as certificates are usually replaced rather than written in place.
+func (c *certificate) Watch(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	for _, d := range []string{filepath.Dir(c.certFile), filepath.Dir(c.keyFile)} {
+		if err := w.Add(d); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if !c.changed(e.Name) {
+				continue
+			}
+			// the key may not be written yet, so a failed load is retried on its change.
+			if err := c.Load(); err != nil {
+				c.logger.WithFields(log.Fields{"file": e.Name, "error": err}).Warn("failed to reload the certificate, keeping the current one")
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			c.logger.WithFields(log.Fields{"error": err}).Warn("failed to watch the certificate files")
+		}
+	}
+}
+
+// changed returns whether a change to the file affects the certificate: either one of its files,
+// or the data directory of a Kubernetes secret, swapped on every update.
+func (c *certificate) changed(name string) bool {
+	name = filepath.Clean(name)
+	return name == filepath.Clean(c.certFile) || name == filepath.Clean(c.keyFile) || strings.HasPrefix(filepath.Base(name), "..")
+}
@@ -0,0 +1,588 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// reauthWindow is how recently the users without a password must have signed in to change their credentials
+// or delete their account.
+const reauthWindow = 10 * time.Minute
+
+// AccountHandler handles the account management routes in the server.
+type AccountHandler struct {
+	// custom logger object.
+	logger *log.Entry
+
+	// handler path
+	path string
+
+	// router group.
+	group *gin.RouterGroup
+
+	// middleware for handling user auth.
+	auth *middlewares.AuthMiddleware
+
+	// external services.
+	users   UserManager
+	games   GameManager
+	wallets WalletService
+	queue   WalletQueue
+	hooks   WebhookManager
+}
+
+// NewAccountHandler returns a new instance of AccountHandler.
+func NewAccountHandler(auth *middlewares.AuthMiddleware, users UserManager, games GameManager, wallets WalletService, queue WalletQueue, hooks WebhookManager) *AccountHandler {
+	h := &AccountHandler{
+		logger:  log.WithFields(log.Fields{"package": "http", "module": "account-handler"}),
+		path:    "/account",
+		auth:    auth,
+		users:   users,
+		games:   games,
+		wallets: wallets,
+		queue:   queue,
+		hooks:   hooks,
+	}
+
+	return h
+}
+
+// Bootstrap registers the handler routes in the server.
+func (h *AccountHandler) Bootstrap(router *gin.Engine) {
+	h.logger.Info("Bootstrapping account handler")
+
+	// account routes.
+	h.group = router.Group(h.path, h.auth.RequireAuth(), h.auth.RequireSession())
+	h.group.POST(UpdateProfileRoute, h.performUpdateProfile)
+	h.group.POST(ChangeEmailRoute, h.performChangeEmail)
+	h.group.POST(ChangePasswordRoute, h.performChangePassword)
+	h.group.GET(ExportAccountRoute, h.performExportAccount)
+	h.group.POST(DeleteAccountRoute, h.performDeleteAccount)
+	h.group.POST(CreateTokenRoute, h.performCreateToken)
+	h.group.POST(RevokeTokenRoute, h.performRevokeToken)
+}
+
+// performUpdateProfile changes the public profile data of the user.
+func (h *AccountHandler) performUpdateProfile(c *gin.Context) {
+	u := currentUser(c)
+
+	// validate the username.
+	username := c.PostForm("username")
+	if username == "" {
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "Please provide a valid username.",
+		}.Render())
+		return
+	}
+
+	// store the user data.
+	u.Username = username
+	if err := h.users.Save(u); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email}).Error(err)
+		h.renderProfile(c, currentUser(c), util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	h.renderProfile(c, u, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "Your profile has been updated.",
+	}.Render())
+}
+
+// performChangeEmail changes the email of the user and migrates every record keyed by it.
+func (h *AccountHandler) performChangeEmail(c *gin.Context) {
+	u := currentUser(c)
+
+	// get the POSTed values.
+	email := c.PostForm("email")
+	password := c.PostForm("password")
+
+	// re-authenticate the user.
+	if !h.reauthenticate(c, u, password) {
+		return
+	}
+
+	// validate the email.
+	if !validEmail(email) || email == u.Email {
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "Please provide a new valid email.",
+		}.Render())
+		return
+	}
+
+	// move the user record to the new key.
+	old := u.Email
+	u.Email = email
+	if err := h.users.Rename(old, u); errors.Is(err, coin.ErrRecordExists) {
+		h.renderProfile(c, currentUser(c), util.RequestError{
+			Title:   "Failed!",
+			Message: "An account with that email already exists.",
+		}.Render())
+		return
+	} else if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": old}).Error(err)
+		h.renderProfileStatus(c, http.StatusInternalServerError, currentUser(c), util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	// update the games referencing the old email.
+	h.moveGames(c, old, email)
+
+	// move the deferred wallet operations to the new email, so they are not dropped as those of a missing user.
+	if err := h.queue.MoveUser(old, u.Email); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": old}).Error(err)
+	}
+
+	// move the api tokens to the new email.
+	if err := h.auth.MoveUserTokens(old, u.Email); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": old}).Error(err)
+	}
+
+	// move the webhooks to the new email.
+	for _, hook := range h.hooks.FindByOwner(old) {
+		hook.Owner = u.Email
+		if err := h.hooks.Save(hook); err != nil {
+			util.Logger(c, h.logger).WithFields(log.Fields{"webhook": hook.ID, "email": email}).Error(err)
+		}
+	}
+
+	// revoke the sessions bound to the old email and log the user back in.
+	if err := h.auth.RemoveUserSessions(old); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": old}).Error(err)
+	}
+	h.auth.AddSession(c, u.Email)
+	c.Set(util.UserCookie, u)
+
+	h.renderProfile(c, u, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "Your email has been changed.",
+	}.Render())
+}
+
+// performChangePassword changes the password of the user.
+func (h *AccountHandler) performChangePassword(c *gin.Context) {
+	u := currentUser(c)
+
+	// get the POSTed values.
+	current := c.PostForm("current_password")
+	password := c.PostForm("password")
+	confirm := c.PostForm("confirm_password")
+
+	// re-authenticate the user.
+	if !h.reauthenticate(c, u, current) {
+		return
+	}
+
+	// validate the new password.
+	if password == "" || password != confirm {
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "The new passwords do not match.",
+		}.Render())
+		return
+	}
+
+	// hash the supplied password.
+	hash, err := hashPassword(password)
+	if err != nil {
+		util.Logger(c, h.logger).Error(err)
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	// store the user data.
+	u.Password = hash
+	if err := h.users.Save(u); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email}).Error(err)
+		h.renderProfile(c, currentUser(c), util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	// revoke every other session and log the user back in.
+	if err := h.auth.RemoveUserSessions(u.Email); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email}).Error(err)
+	}
+	h.auth.AddSession(c, u.Email)
+	c.Set(util.UserCookie, u)
+
+	h.renderProfile(c, u, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "Your password has been changed.",
+	}.Render())
+}
+
+// performExportAccount sends the user a JSON document with all of their data.
+func (h *AccountHandler) performExportAccount(c *gin.Context) {
+	u := currentUser(c)
+
+	export := accountExport{
+		ExportDate: time.Now(),
+		User: exportedUser{
+			Email:    u.Email,
+			Username: u.Username,
+			Wallet:   u.Wallet,
+		},
+		Games:        make([]exportedGame, 0),
+		Discoveries:  make([]exportedDiscovery, 0),
+		Sessions:     make([]string, 0),
+		Tokens:       make([]exportedToken, 0),
+		Transactions: make([]coin.Transaction, 0),
+	}
+
+	// collect the games created and the treasures found.
+	for id, g := range h.games.List() {
+		g.ID = id
+		if g.Creator == u.Email {
+			export.Games = append(export.Games, newExportedGame(g))
+		}
+		for _, t := range g.Treasures {
+			if t.Found && t.FoundUser == u.Email {
+				export.Discoveries = append(export.Discoveries, exportedDiscovery{
+					GameID:    id,
+					GameTitle: g.Title,
+					Treasure:  t.Name,
+					FoundDate: t.FoundDate,
+				})
+			}
+		}
+	}
+
+	// collect the active sessions, masking the tokens.
+	for _, s := range h.auth.UserSessions(u.Email) {
+		if i := strings.IndexByte(s, '.') + 9; len(s) > i {
+			s = s[:i] + "..."
+		}
+		export.Sessions = append(export.Sessions, s)
+	}
+
+	// collect the api tokens, without their hashes.
+	for _, t := range h.auth.UserTokens(u.Email) {
+		export.Tokens = append(export.Tokens, exportedToken{
+			Name:         t.Name,
+			Scopes:       t.Scopes,
+			CreatedDate:  t.CreatedDate,
+			ExpiryDate:   t.ExpiryDate,
+			LastUsedDate: t.LastUsedDate,
+		})
+	}
+
+	// collect the wallet transactions.
+	t := make([]coin.Transaction, 0)
+	err := h.wallets.EachUserTransaction(c.Request.Context(), u.Wallet, func(tr coin.Transaction) error {
+		t = append(t, tr)
+		return nil
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": u.Wallet}).Error(err)
+	} else {
+		enrichTransactions(h.games.List(), t)
+		export.Transactions = t
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=treasure-coin-%s.json", u.Username))
+	c.JSON(http.StatusOK, export)
+}
+
+// performDeleteAccount removes the user account and returns the remaining balance.
+func (h *AccountHandler) performDeleteAccount(c *gin.Context) {
+	u := currentUser(c)
+
+	// re-authenticate the user.
+	if !h.reauthenticate(c, u, c.PostForm("password")) {
+		return
+	}
+
+	// return the remaining balance to the pool, if the wallet was ever created.
+	if u.Wallet != "" {
+		if err := h.wallets.RemoveTokens(c.Request.Context(), u.Wallet); err != nil {
+			util.Logger(c, h.logger).WithFields(log.Fields{"wallet": u.Wallet, "step": "remove-tokens"}).Error(err)
+			h.renderProfile(c, u, walletError(err, "It seems we messed up somehow, please try again.").Render())
+			return
+		}
+	}
+
+	// remove the user data first, so a failure leaves the account untouched rather than half deleted.
+	if err := h.users.Remove(u); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email}).Error(err)
+		h.renderProfileStatus(c, http.StatusInternalServerError, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	// revoke every session and api token.
+	if err := h.auth.RemoveUserSessions(u.Email); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email}).Error(err)
+	}
+	if err := h.auth.RemoveUserTokens(u.Email); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email}).Error(err)
+	}
+
+	// stop notifying the webhooks of the user, their pending deliveries are dropped by the dispatcher.
+	for _, hook := range h.hooks.FindByOwner(u.Email) {
+		if err := h.hooks.Remove(hook); err != nil {
+			util.Logger(c, h.logger).WithFields(log.Fields{"webhook": hook.ID}).Error(err)
+		}
+	}
+	h.auth.RemoveSession(c)
+
+	// anonymize the games, so no account created later with the same email inherits them.
+	h.moveGames(c, u.Email, coin.DeletedUser)
+	c.Set(util.UserCookie, coin.User{})
+
+	games := visibleGames(c, h.games.List())
+	util.Render(c, gin.H{
+		"games":          games,
+		"MessageTitle":   "Success",
+		"MessageMessage": "Your account has been deleted, goodbye " + u.Username + ".",
+	}, IndexPage)
+}
+
+// performCreateToken creates a new API token for the user.
+func (h *AccountHandler) performCreateToken(c *gin.Context) {
+	u := currentUser(c)
+
+	// validate the name.
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "Please provide a name for the token.",
+		}.Render())
+		return
+	}
+
+	// validate the expiry.
+	days, err := strconv.Atoi(c.PostForm("expiry"))
+	if err != nil || days < 0 {
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "Please provide a valid token expiry.",
+		}.Render())
+		return
+	}
+
+	// validate the scopes.
+	scopes := make([]coin.Scope, 0)
+	for _, s := range c.PostFormArray("scopes") {
+		scopes = append(scopes, coin.Scope(s))
+	}
+
+	secret, _, err := h.auth.CreateToken(u.Email, name, scopes, time.Duration(days)*24*time.Hour)
+	if err == middlewares.ErrInvalidScopes {
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "Please select at least one valid scope.",
+		}.Render())
+		return
+	} else if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email}).Error(err)
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	data := util.RequestSuccess{
+		Title:   "Success!",
+		Message: "Your token has been created, copy it now as it will not be shown again.",
+	}.Render()
+	data["created_token"] = secret
+	h.renderProfile(c, u, data)
+}
+
+// performRevokeToken revokes an API token of the user.
+func (h *AccountHandler) performRevokeToken(c *gin.Context) {
+	u := currentUser(c)
+
+	if err := h.auth.RevokeToken(u.Email, c.Param("token")); err != nil {
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "Token not found.",
+		}.Render())
+		return
+	}
+
+	h.renderProfile(c, u, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The token has been revoked.",
+	}.Render())
+}
+
+// reauthenticate checks the user is the one who signed in, rendering the profile with an error if not.
+// Users are asked for their password, or to have signed in again recently when their account has none.
+func (h *AccountHandler) reauthenticate(c *gin.Context, u coin.User, password string) bool {
+	if u.Password == "" {
+		if h.auth.Reauthenticated(c, reauthWindow) {
+			return true
+		}
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: fmt.Sprintf("Please sign in again to confirm it is you, then retry within %d minutes.", int(reauthWindow.Minutes())),
+		}.Render())
+		return false
+	}
+
+	if !checkPasswordHash(password, u.Password) {
+		h.renderProfile(c, u, util.RequestError{
+			Title:   "Failed!",
+			Message: "Invalid credentials provided.",
+		}.Render())
+		return false
+	}
+	return true
+}
+
+// moveGames replaces an email with another in the games created and the treasures found by the user.
+func (h *AccountHandler) moveGames(c *gin.Context, old, email string) {
+	for id, g := range h.games.List() {
+		changed := false
+		if g.Creator == old {
+			g.Creator = email
+			changed = true
+		}
+		for k, t := range g.Treasures {
+			if t.FoundUser == old {
+				t.FoundUser = email
+				g.Treasures[k] = t
+				changed = true
+			}
+		}
+		if changed {
+			g.ID = id
+			if err := h.games.Save(g); err != nil {
+				util.Logger(c, h.logger).WithFields(log.Fields{"game": id, "email": email}).Error(err)
+			}
+		}
+	}
+}
+
+// renderProfile renders the profile page of the user along with the supplied messages.
+func (h *AccountHandler) renderProfile(c *gin.Context, user coin.User, data gin.H) {
+	h.renderProfileStatus(c, http.StatusOK, user, data)
+}
+
+// renderProfileStatus renders the profile page like renderProfile using the supplied status code.
+func (h *AccountHandler) renderProfileStatus(c *gin.Context, code int, user coin.User, data gin.H) {
+	c.Set(util.UserCookie, user)
+
+	// get user balance.
+	if err := addBalance(c, h.wallets, user, data); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": user.Wallet}).Error(err)
+	}
+
+	// get the requested page of user transactions.
+	if err := addLedger(c, h.wallets, h.games, user, data); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": user.Wallet}).Error(err)
+	}
+
+	data["password_set"] = user.Password != ""
+	data["tokens"] = h.auth.UserTokens(user.Email)
+	data["scopes"] = coin.Scopes
+	util.RenderStatus(c, code, data, ProfilePage)
+}
+
+// accountExport represents the full export of the data of a user.
+type accountExport struct {
+	ExportDate   time.Time           `json:"export_date"`
+	User         exportedUser        `json:"user"`
+	Games        []exportedGame      `json:"games"`
+	Discoveries  []exportedDiscovery `json:"discoveries"`
+	Sessions     []string            `json:"sessions"`
+	Tokens       []exportedToken     `json:"tokens"`
+	Transactions []coin.Transaction  `json:"transactions"`
+}
+
+// exportedUser represents the exported profile of a user.
+type exportedUser struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+	Wallet   string `json:"wallet"`
+}
+
+// exportedGame represents a game created by the user.
+// Treasures only tell whether they were found, as who found them is the data of the other players.
+type exportedGame struct {
+	ID          string             `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	StartDate   time.Time          `json:"start_date"`
+	Hidden      bool               `json:"hidden"`
+	Treasures   []exportedTreasure `json:"treasures"`
+}
+
+// exportedTreasure represents a treasure of a game created by the user.
+type exportedTreasure struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Hint     string `json:"hint"`
+	Location string `json:"location"`
+	Found    bool   `json:"found"`
+}
+
+// newExportedGame returns the exported game, with its treasures sorted by id.
+func newExportedGame(g coin.Game) exportedGame {
+	e := exportedGame{
+		ID:          g.ID,
+		Title:       g.Title,
+		Description: g.Description,
+		StartDate:   g.StartDate,
+		Hidden:      g.Hidden,
+		Treasures:   make([]exportedTreasure, 0, len(g.Treasures)),
+	}
+	for _, t := range g.Treasures {
+		e.Treasures = append(e.Treasures, exportedTreasure{
+			ID:       t.ID,
+			Name:     t.Name,
+			Hint:     t.Hint,
+			Location: t.Location,
+			Found:    t.Found,
+		})
+	}
+	sort.Slice(e.Treasures, func(i, j int) bool { return e.Treasures[i].ID < e.Treasures[j].ID })
+	return e
+}
+
+// exportedDiscovery represents a treasure found by the user.
+type exportedDiscovery struct {
+	GameID    string    `json:"game_id"`
+	GameTitle string    `json:"game_title"`
+	Treasure  string    `json:"treasure"`
+	FoundDate time.Time `json:"found_date"`
+}
+
+// exportedToken represents an API token of the user.
+type exportedToken struct {
+	Name         string       `json:"name"`
+	Scopes       []coin.Scope `json:"scopes"`
+	CreatedDate  time.Time    `json:"created_date"`
+	ExpiryDate   time.Time    `json:"expiry_date"`
+	LastUsedDate time.Time    `json:"last_used_date"`
+}
@@ -0,0 +1,210 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/pmdcosta/treasure-coin/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+// NewAccountHandler returns the account handler of the server.
+func NewAccountHandler(s *Server, wallets *Wallet) *handlers.AccountHandler {
+	return handlers.NewAccountHandler(s.Auth, s.DB.UserService(), s.DB.GameService(), wallets, &Queue{}, s.DB.WebhookService())
+}
+
+// Users is a user store failing to rename or remove the users with the configured errors.
+type Users struct {
+	handlers.UserManager
+
+	RenameErr error
+	RemoveErr error
+}
+
+// Rename renames the user unless set to fail.
+func (u *Users) Rename(email string, user coin.User) error {
+	if u.RenameErr != nil {
+		return u.RenameErr
+	}
+	return u.UserManager.Rename(email, user)
+}
+
+// Remove removes the user unless set to fail.
+func (u *Users) Remove(user coin.User) error {
+	if u.RemoveErr != nil {
+		return u.RemoveErr
+	}
+	return u.UserManager.Remove(user)
+}
+
+// TestAccountHandler_Export tests the export of the games created by the user only tells whether their treasures
+// were found, and not by whom.
+func TestAccountHandler_Export(t *testing.T) {
+	s := NewServer(t)
+	session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy"}, "meat")
+	s.Bootstrap(NewAccountHandler(s, NewWallet()))
+
+	id, _ := s.DB.GameService().Add(coin.Game{
+		Title:   "Grand Line",
+		Creator: "luffy@treasure.coin",
+		Treasures: map[string]coin.Treasure{
+			"one-piece": {ID: "one-piece", Name: "One Piece", Token: "secret", Found: true, FoundUser: "nami@treasure.coin"},
+			"meat":      {ID: "meat", Name: "Meat", Token: "secret"},
+		},
+	})
+
+	w := s.Do(NewRequest(http.MethodGet, "/account/export", nil), session)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "nami@treasure.coin")
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	var export struct {
+		Games []struct {
+			ID        string
+			Title     string
+			Treasures []struct {
+				ID    string
+				Found bool
+			}
+		}
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Len(t, export.Games, 1)
+	assert.Equal(t, id, export.Games[0].ID)
+	assert.Equal(t, "Grand Line", export.Games[0].Title)
+	assert.Len(t, export.Games[0].Treasures, 2)
+	assert.Equal(t, "meat", export.Games[0].Treasures[0].ID)
+	assert.False(t, export.Games[0].Treasures[0].Found)
+	assert.Equal(t, "one-piece", export.Games[0].Treasures[1].ID)
+	assert.True(t, export.Games[0].Treasures[1].Found)
+}
+
+// TestAccountHandler_Delete tests deleting an account anonymizes its games and discoveries.
+func TestAccountHandler_Delete(t *testing.T) {
+	s := NewServer(t)
+	session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy"}, "meat")
+	s.Bootstrap(NewAccountHandler(s, NewWallet()))
+
+	created, _ := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "luffy@treasure.coin", Hidden: true})
+	played, _ := s.DB.GameService().Add(coin.Game{
+		Title:     "East Blue",
+		Creator:   "nami@treasure.coin",
+		Treasures: map[string]coin.Treasure{"map": {ID: "map", Found: true, FoundUser: "luffy@treasure.coin"}},
+	})
+
+	w := s.Do(NewRequest(http.MethodPost, "/account/delete", url.Values{"password": {"fish"}}), session)
+	assert.Contains(t, w.Body.String(), "Invalid credentials provided.")
+	_, err := s.DB.UserService().Find("luffy@treasure.coin")
+	assert.Nil(t, err)
+
+	w = s.Do(NewRequest(http.MethodPost, "/account/delete", url.Values{"password": {"meat"}}), session)
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = s.DB.UserService().Find("luffy@treasure.coin")
+	assert.NotNil(t, err)
+
+	g, _ := s.DB.GameService().Find(created)
+	assert.Equal(t, coin.DeletedUser, g.Creator)
+	g, _ = s.DB.GameService().Find(played)
+	assert.Equal(t, "nami@treasure.coin", g.Creator)
+	assert.Equal(t, coin.DeletedUser, g.Treasures["map"].FoundUser)
+}
+
+// TestAccountHandler_DeleteFailure tests an account failing to be removed is left untouched.
+func TestAccountHandler_DeleteFailure(t *testing.T) {
+	s := NewServer(t)
+	session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy"}, "meat")
+	users := &Users{UserManager: s.DB.UserService(), RemoveErr: errors.New("disk full")}
+	s.Bootstrap(handlers.NewAccountHandler(s.Auth, users, s.DB.GameService(), NewWallet(), &Queue{}, s.DB.WebhookService()))
+
+	id, _ := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "luffy@treasure.coin"})
+
+	w := s.Do(NewRequest(http.MethodPost, "/account/delete", url.Values{"password": {"meat"}}), session)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "It seems we messed up somehow, please try again.")
+
+	_, err := s.DB.UserService().Find("luffy@treasure.coin")
+	assert.Nil(t, err)
+	g, _ := s.DB.GameService().Find(id)
+	assert.Equal(t, "luffy@treasure.coin", g.Creator)
+
+	// the session is still valid.
+	w = s.Do(NewRequest(http.MethodGet, "/account/export", nil), session)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestAccountHandler_ChangeEmailFailure tests only a taken email is reported as such.
+func TestAccountHandler_ChangeEmailFailure(t *testing.T) {
+	tests := map[string]struct {
+		err error
+
+		code    int
+		message string
+	}{
+		"taken":  {err: coin.ErrRecordExists, code: http.StatusOK, message: "An account with that email already exists."},
+		"failed": {err: errors.New("disk full"), code: http.StatusInternalServerError, message: "It seems we messed up somehow, please try again."},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewServer(t)
+			session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy"}, "meat")
+			users := &Users{UserManager: s.DB.UserService(), RenameErr: tc.err}
+			s.Bootstrap(handlers.NewAccountHandler(s.Auth, users, s.DB.GameService(), NewWallet(), &Queue{}, s.DB.WebhookService()))
+
+			w := s.Do(NewRequest(http.MethodPost, "/account/email", url.Values{"email": {"nami@treasure.coin"}, "password": {"meat"}}), session)
+			assert.Equal(t, tc.code, w.Code)
+			assert.Contains(t, w.Body.String(), tc.message)
+			_, err := s.DB.UserService().Find("luffy@treasure.coin")
+			assert.Nil(t, err)
+		})
+	}
+}
+
+// TestAccountHandler_ChangeEmailTaken tests renaming the user to the email of another account fails.
+func TestAccountHandler_ChangeEmailTaken(t *testing.T) {
+	s := NewServer(t)
+	session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy"}, "meat")
+	s.AddUser(t, coin.User{Email: "nami@treasure.coin", Username: "nami"}, "tangerine")
+	s.Bootstrap(NewAccountHandler(s, NewWallet()))
+
+	w := s.Do(NewRequest(http.MethodPost, "/account/email", url.Values{"email": {"nami@treasure.coin"}, "password": {"meat"}}), session)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "An account with that email already exists.")
+}
+
+// TestAccountHandler_ChangeEmailPending tests the wallet operations deferred before the email changed are performed.
+func TestAccountHandler_ChangeEmailPending(t *testing.T) {
+	s := NewServer(t)
+	session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy"}, "meat")
+	id, err := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "shanks@treasure.coin", Treasures: map[string]coin.Treasure{
+		"one-piece": {ID: "one-piece", Name: "One Piece", Found: true, FoundUser: "luffy@treasure.coin", Reward: coin.TransferPending},
+	}})
+	assert.Nil(t, err)
+
+	// the user signed up and found a treasure while the wallet provider was unavailable.
+	wallets := NewWallet()
+	q := queue.NewQueue(s.DB.OperationService(), s.DB.UserService(), s.DB.GameService(), wallets, &Transfers{})
+	assert.Nil(t, q.Defer(coin.Operation{Kind: coin.OperationSignUp, User: "luffy@treasure.coin", Amount: coin.Coin}))
+	assert.Nil(t, q.Defer(coin.Operation{Kind: coin.OperationReward, User: "luffy@treasure.coin", Game: id, Treasure: "one-piece"}))
+	s.Bootstrap(handlers.NewAccountHandler(s.Auth, s.DB.UserService(), s.DB.GameService(), wallets, q, s.DB.WebhookService()))
+
+	w := s.Do(NewRequest(http.MethodPost, "/account/email", url.Values{"email": {"monkey@treasure.coin"}, "password": {"meat"}}), session)
+	assert.Equal(t, http.StatusOK, w.Code)
+	for _, op := range s.DB.OperationService().List() {
+		assert.Equal(t, "monkey@treasure.coin", op.User)
+	}
+
+	q.Replay(context.Background())
+	assert.Empty(t, s.DB.OperationService().List())
+	u, err := s.DB.UserService().Find("monkey@treasure.coin")
+	assert.Nil(t, err)
+	assert.Equal(t, "wallet-luffy", u.Wallet)
+	assert.Equal(t, coin.Coin.Add(coin.TreasurePrice), wallets.Balance("wallet-luffy"))
+	g, _ := s.DB.GameService().Find(id)
+	assert.NotEmpty(t, g.Treasures["one-piece"].Transaction)
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// AdminHandler handles the operator console in the server.
+type AdminHandler struct {
+	// custom logger object.
+	logger *log.Entry
+
+	// handler path
+	path string
+
+	// router group.
+	group *gin.RouterGroup
+
+	// middleware for handling user auth.
+	auth *middlewares.AuthMiddleware
+
+	// external services.
+	users   UserManager
+	games   GameManager
+	wallets WalletService
+}
+
+// NewAdminHandler returns a new instance of AdminHandler.
+func NewAdminHandler(auth *middlewares.AuthMiddleware, users UserManager, games GameManager, wallets WalletService) *AdminHandler {
+	h := &AdminHandler{
+		logger:  log.WithFields(log.Fields{"package": "http", "module": "admin-handler"}),
+		path:    "/admin",
+		auth:    auth,
+		users:   users,
+		games:   games,
+		wallets: wallets,
+	}
+
+	return h
+}
+
+// Bootstrap registers the handler routes in the server.
+func (h *AdminHandler) Bootstrap(router *gin.Engine) {
+	h.logger.Info("Bootstrapping admin handler")
+
+	// admin routes.
+	h.group = router.Group(h.path, h.auth.RequireSession(), h.auth.RequireRole(coin.RoleModerator))
+	h.group.GET(AdminRoute, h.showAdminPage)
+	h.group.POST(AdminDisableUserRoute, h.performDisableUser)
+	h.group.POST(AdminEnableUserRoute, h.performEnableUser)
+	h.group.POST(AdminHideGameRoute, h.performHideGame)
+	h.group.POST(AdminShowGameRoute, h.performShowGame)
+	h.group.POST(AdminResetTreasureRoute, h.performResetTreasure)
+	h.group.POST(AdminUserRoleRoute, h.auth.RequireRole(coin.RoleAdmin), h.performChangeRole)
+	h.group.POST(AdminAirdropRoute, h.auth.RequireRole(coin.RoleAdmin), h.performAirdrop)
+}
+
+// showAdminPage renders the admin console.
+func (h *AdminHandler) showAdminPage(c *gin.Context) {
+	h.render(c, gin.H{})
+}
+
+// performDisableUser disables a user account and revokes its sessions.
+func (h *AdminHandler) performDisableUser(c *gin.Context) {
+	target, ok := h.findManagedUser(c)
+	if !ok {
+		return
+	}
+
+	target.Disabled = true
+	if err := h.users.Save(target); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": target.Email}).Error(err)
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	// log the user out everywhere.
+	if err := h.auth.RemoveUserSessions(target.Email); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": target.Email}).Error(err)
+	}
+
+	util.Logger(c, h.logger).WithFields(log.Fields{"email": target.Email, "by": currentUser(c).Email}).Info("user disabled")
+	h.render(c, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The account " + target.Email + " has been disabled.",
+	}.Render())
+}
+
+// performEnableUser enables a disabled user account.
+func (h *AdminHandler) performEnableUser(c *gin.Context) {
+	target, ok := h.findManagedUser(c)
+	if !ok {
+		return
+	}
+
+	target.Disabled = false
+	if err := h.users.Save(target); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": target.Email}).Error(err)
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	util.Logger(c, h.logger).WithFields(log.Fields{"email": target.Email, "by": currentUser(c).Email}).Info("user enabled")
+	h.render(c, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The account " + target.Email + " has been enabled.",
+	}.Render())
+}
+
+// performChangeRole changes the role of a user.
+func (h *AdminHandler) performChangeRole(c *gin.Context) {
+	target, ok := h.findManagedUser(c)
+	if !ok {
+		return
+	}
+
+	// validate the role.
+	role := coin.Role(c.PostForm("role"))
+	if !role.Valid() {
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "Please provide a valid role.",
+		}.Render())
+		return
+	}
+
+	target.Role = role
+	if err := h.users.Save(target); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": target.Email}).Error(err)
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	util.Logger(c, h.logger).WithFields(log.Fields{"email": target.Email, "role": role, "by": currentUser(c).Email}).Info("user role changed")
+	h.render(c, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The account " + target.Email + " is now a " + role.String() + ".",
+	}.Render())
+}
+
+// performAirdrop airdrops tokens into the wallet of a user.
+func (h *AdminHandler) performAirdrop(c *gin.Context) {
+	target, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	// validate the amount.
+	amount, err := coin.ParseAmount(c.PostForm("amount"))
+	if err != nil || amount.Sign() <= 0 {
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "Please provide a valid amount of tokens.",
+		}.Render())
+		return
+	}
+
+	if target.Wallet == "" {
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "The wallet of " + target.Email + " is still being set up.",
+		}.Render())
+		return
+	}
+
+	if err := h.wallets.Airdrop(c.Request.Context(), target.Wallet, amount); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": target.Wallet, "step": "airdrop"}).Error(err)
+		h.render(c, walletError(err, "Failed to airdrop the tokens, please try again.").Render())
+		return
+	}
+
+	util.Logger(c, h.logger).WithFields(log.Fields{"email": target.Email, "amount": amount.String(), "by": currentUser(c).Email}).Info("tokens airdropped")
+	h.render(c, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The tokens have been airdropped to " + target.Email + ".",
+	}.Render())
+}
+
+// performHideGame hides a game from the players.
+func (h *AdminHandler) performHideGame(c *gin.Context) {
+	h.setGameHidden(c, true)
+}
+
+// performShowGame makes a hidden game visible to the players again.
+func (h *AdminHandler) performShowGame(c *gin.Context) {
+	h.setGameHidden(c, false)
+}
+
+// setGameHidden changes the visibility of a game.
+func (h *AdminHandler) setGameHidden(c *gin.Context, hidden bool) {
+	g := c.Param("game")
+
+	game, err := h.games.Find(g)
+	if err != nil {
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "Game not found.",
+		}.Render())
+		return
+	}
+
+	err = h.games.Update(g, func(game *coin.Game) error {
+		game.Hidden = hidden
+		return nil
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"game": g}).Error(err)
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	util.Logger(c, h.logger).WithFields(log.Fields{"game": g, "hidden": hidden, "by": currentUser(c).Email}).Info("game visibility changed")
+	h.render(c, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The visibility of " + game.Title + " has been changed.",
+	}.Render())
+}
+
+// performResetTreasure marks a found treasure as hidden again.
+func (h *AdminHandler) performResetTreasure(c *gin.Context) {
+	g := c.Param("game")
+	t := c.Param("treasure")
+
+	game, err := h.games.Find(g)
+	if err != nil {
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "Game not found.",
+		}.Render())
+		return
+	}
+
+	treasure, ok := game.Treasures[t]
+	if !ok {
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "Treasure not found.",
+		}.Render())
+		return
+	}
+
+	treasure.Found = false
+	treasure.FoundUser = ""
+	treasure.FoundDate = time.Time{}
+	treasure.Transaction = ""
+	treasure.Reward = ""
+
+	err = h.games.Update(g, func(game *coin.Game) error {
+		game.Treasures[t] = treasure
+		return nil
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"game": g, "treasure": t}).Error(err)
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+
+	util.Logger(c, h.logger).WithFields(log.Fields{"game": g, "treasure": t, "by": currentUser(c).Email}).Info("treasure reset")
+	h.render(c, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The treasure " + treasure.Name + " can be found again.",
+	}.Render())
+}
+
+// findUser retrieves the user targeted by the request, rendering an error if it does not exist.
+func (h *AdminHandler) findUser(c *gin.Context) (coin.User, bool) {
+	target, err := h.users.Find(c.PostForm("email"))
+	if err != nil {
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "User not found.",
+		}.Render())
+		return coin.User{}, false
+	}
+	return target, true
+}
+
+// findManagedUser retrieves the user targeted by the request, checking if the current user can manage them.
+func (h *AdminHandler) findManagedUser(c *gin.Context) (coin.User, bool) {
+	target, ok := h.findUser(c)
+	if !ok {
+		return coin.User{}, false
+	}
+
+	// users can only manage accounts less privileged than their own.
+	u := currentUser(c)
+	if target.Email == u.Email || (target.HasRole(u.Role) && !u.HasRole(coin.RoleAdmin)) {
+		h.render(c, util.RequestError{
+			Title:   "Failed!",
+			Message: "You do not have permission to manage that account.",
+		}.Render())
+		return coin.User{}, false
+	}
+	return target, true
+}
+
+// render renders the admin console with the users and games matching the search query.
+func (h *AdminHandler) render(c *gin.Context, data gin.H) {
+	q := strings.ToLower(strings.TrimSpace(c.Query("q")))
+
+	users := make(map[string]coin.User)
+	for k, u := range h.users.List() {
+		if q == "" || strings.Contains(strings.ToLower(u.Email), q) || strings.Contains(strings.ToLower(u.Username), q) {
+			users[k] = u
+		}
+	}
+
+	games := make(map[string]coin.Game)
+	rewards := make([]rewardView, 0)
+	for k, g := range h.games.List() {
+		g.ID = k
+		if q == "" || k == q || strings.Contains(strings.ToLower(g.Title), q) || strings.Contains(strings.ToLower(g.Creator), q) {
+			games[k] = g
+		}
+
+		// the rewards sent without the wallet provider confirming them are always listed, until they are checked.
+		for _, t := range g.Treasures {
+			if t.Reward == coin.TransferUnknown {
+				rewards = append(rewards, rewardView{Treasure: t, Game: k, GameTitle: g.Title})
+			}
+		}
+	}
+	sort.Slice(rewards, func(i, j int) bool { return rewards[i].FoundDate.Before(rewards[j].FoundDate) })
+
+	data["query"] = q
+	data["users"] = users
+	data["games"] = games
+	data["unknown_rewards"] = rewards
+	data["roles"] = coin.Roles
+	util.Render(c, data, AdminPage)
+}
+
+// rewardView represents a treasure reward shown with its game.
+type rewardView struct {
+	coin.Treasure
+	Game      string
+	GameTitle string
+}
+
+// currentUser returns the logged in user of the request.
+func currentUser(c *gin.Context) coin.User {
+	if user, exists := c.Get(util.UserCookie); exists {
+		return user.(coin.User)
+	}
+	return coin.User{}
+}
@@ -0,0 +1,32 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdminHandler_UnknownRewards tests the rewards of unknown outcome are listed for the admins to check, whatever
+// they search for.
+func TestAdminHandler_UnknownRewards(t *testing.T) {
+	s := NewServer(t)
+	session := s.AddUser(t, coin.User{Email: "garp@treasure.coin", Username: "garp", Role: coin.RoleModerator}, "fist")
+	s.Bootstrap(handlers.NewAdminHandler(s.Auth, s.DB.UserService(), s.DB.GameService(), NewWallet()))
+
+	_, err := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "shanks@treasure.coin", Treasures: map[string]coin.Treasure{
+		"one-piece": {ID: "one-piece", Name: "One Piece", Found: true, FoundUser: "luffy@treasure.coin", Reward: coin.TransferUnknown},
+		"poneglyph": {ID: "poneglyph", Name: "Poneglyph", Found: true, FoundUser: "robin@treasure.coin", Reward: coin.TransferPending},
+	}})
+	assert.Nil(t, err)
+
+	for _, target := range []string{"/admin/", "/admin/?q=east+blue"} {
+		w := s.Do(NewRequest(http.MethodGet, target, nil), session)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Unconfirmed Rewards")
+		assert.Contains(t, w.Body.String(), "luffy@treasure.coin")
+		assert.NotContains(t, w.Body.String(), "robin@treasure.coin")
+	}
+}
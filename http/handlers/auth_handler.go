@@ -1,7 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pmdcosta/treasure-coin"
@@ -29,10 +35,15 @@ type AuthHandler struct {
 	users   UserManager
 	games   GameManager
 	wallets WalletService
+	queue   WalletQueue
+	events  GameEvents
+
+	// coins airdropped to every new user.
+	airdrop coin.Amount
 }
 
 // NewAuthHandler returns a new instance of AuthHandler.
-func NewAuthHandler(auth *middlewares.AuthMiddleware, users UserManager, games GameManager, wallets WalletService) *AuthHandler {
+func NewAuthHandler(auth *middlewares.AuthMiddleware, users UserManager, games GameManager, wallets WalletService, queue WalletQueue, events GameEvents, airdrop coin.Amount) *AuthHandler {
 	h := &AuthHandler{
 		logger:  log.WithFields(log.Fields{"package": "http", "module": "authHandler"}),
 		path:    "/auth",
@@ -40,6 +51,9 @@ func NewAuthHandler(auth *middlewares.AuthMiddleware, users UserManager, games G
 		users:   users,
 		games:   games,
 		wallets: wallets,
+		queue:   queue,
+		events:  events,
+		airdrop: airdrop,
 	}
 
 	return h
@@ -49,9 +63,6 @@ func NewAuthHandler(auth *middlewares.AuthMiddleware, users UserManager, games G
 func (h *AuthHandler) Bootstrap(router *gin.Engine) {
 	h.logger.Info("Bootstrapping auth handler")
 
-	// register middleware.
-	router.Use(h.auth.SetUserStatus())
-
 	// auth routes.
 	h.group = router.Group(h.path)
 	h.group.POST(SignInRoute, h.performSignIn)
@@ -64,32 +75,40 @@ func (h *AuthHandler) performSignIn(c *gin.Context) {
 	// get the POSTed values.
 	email := c.PostForm("email")
 	password := c.PostForm("password")
+	next := redirectTarget(c.PostForm("next"))
 
 	// get user from the database.
 	u, err := h.users.Find(email)
 	if err != nil {
-		h.logger.WithFields(log.Fields{"email": email}).Error(err)
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "It seems we messed up somehow, please try again.",
-		}.Render(), SignInPage)
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": email}).Error(err)
+		h.renderError(c, SignInPage, next, "It seems we messed up somehow, please try again.")
 		return
 	}
 
 	// check if the credentials are correct.
 	if !checkPasswordHash(password, u.Password) {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Invalid credentials provided.",
-		}.Render(), SignInPage)
+		h.renderError(c, SignInPage, next, "Invalid credentials provided.")
+		return
+	}
+
+	// check if the account has been disabled.
+	if u.Disabled {
+		h.renderError(c, SignInPage, next, "This account has been disabled.")
 		return
 	}
 
 	// log the user in.
 	h.auth.AddSession(c, u.Email)
+	c.Set(util.UserCookie, u)
+
+	// send the user back to the page that required the sign in.
+	if next != "" {
+		c.Redirect(http.StatusFound, next)
+		return
+	}
 
 	// redirect to home page.
-	games := h.games.List()
+	games := visibleGames(c, h.games.List())
 	util.Render(c, gin.H{
 		"games":          games,
 		"MessageTitle":   "Success",
@@ -97,7 +116,7 @@ func (h *AuthHandler) performSignIn(c *gin.Context) {
 	}, IndexPage)
 }
 
-// performSignOut renders the about page.
+// performSignOut logs the user out.
 func (h *AuthHandler) performSignOut(c *gin.Context) {
 	h.auth.RemoveSession(c)
 
@@ -105,42 +124,33 @@ func (h *AuthHandler) performSignOut(c *gin.Context) {
 	c.Redirect(http.StatusTemporaryRedirect, IndexRoute)
 }
 
-// performSignUp renders the about page.
+// performSignUp creates a new user account.
 func (h *AuthHandler) performSignUp(c *gin.Context) {
 	// get the POSTed values.
 	email := c.PostForm("email")
 	username := c.PostForm("username")
 	password := c.PostForm("password")
+	next := redirectTarget(c.PostForm("next"))
 
-	// hash the supplied password.
-	hash, err := hashPassword(password)
-	if err != nil {
-		h.logger.Error(err)
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "It seems we messed up somehow, please try again.",
-		}.Render(), SignUpPage)
+	// validate the email.
+	if !validEmail(email) {
+		h.renderError(c, SignUpPage, next, "Please provide a valid email.")
 		return
 	}
 
-	// create a user wallet.
-	w, err := h.wallets.CreateUser(username)
-	if err != nil || w == "" {
-		h.logger.WithFields(log.Fields{"username": username, "step": "wallet"}).Error(err)
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "It seems we messed up somehow, please try again.",
-		}.Render(), SignUpPage)
+	// hash the supplied password.
+	hash, err := hashPassword(password)
+	if err != nil {
+		util.Logger(c, h.logger).Error(err)
+		h.renderError(c, SignUpPage, next, "It seems we messed up somehow, please try again.")
 		return
 	}
 
-	// airdrop the users some tokens.
-	if err := h.wallets.Airdrop(w, 1.0); err != nil {
-		h.logger.WithFields(log.Fields{"wallet": w, "step": "airdrop"}).Error(err)
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "It seems we messed up somehow, please try again.",
-		}.Render(), SignUpPage)
+	// create a user wallet and airdrop the users some tokens, later if the wallet provider is unavailable.
+	w, deferred, err := createWallet(c.Request.Context(), h.wallets, username, h.airdrop)
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"username": username, "wallet": w, "step": "wallet"}).Error(err)
+		h.renderWalletError(c, SignUpPage, next, err)
 		return
 	}
 
@@ -150,30 +160,83 @@ func (h *AuthHandler) performSignUp(c *gin.Context) {
 		Username: username,
 		Password: hash,
 		Wallet:   w,
+		Role:     coin.DefaultRole,
 	}
 
 	// store the user data.
 	err = h.users.Add(user)
 	if err != nil {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "An account with that email already exists.",
-		}.Render(), SignUpPage)
+		h.renderError(c, SignUpPage, next, "An account with that email already exists.")
 		return
 	}
+	h.events.SignedUp()
+
+	// create the wallet once the wallet provider recovers.
+	welcome := "Welcome to treasure coin " + user.Username + "."
+	if deferred {
+		if err := deferSignUp(h.queue, user, h.airdrop); err != nil {
+			util.Logger(c, h.logger).WithFields(log.Fields{"email": user.Email, "step": "defer"}).Error(err)
+		}
+		welcome += " Your wallet is being set up and your coins will arrive shortly."
+	}
 
 	// log the user in.
 	h.auth.AddSession(c, user.Email)
+	c.Set(util.UserCookie, user)
+
+	// send the user back to the page that required the sign up.
+	if next != "" {
+		c.Redirect(http.StatusFound, next)
+		return
+	}
 
 	// redirect to home page.
-	games := h.games.List()
+	games := visibleGames(c, h.games.List())
 	util.Render(c, gin.H{
 		"games":          games,
 		"MessageTitle":   "Success",
-		"MessageMessage": "Welcome to treasure coin " + user.Username + ".",
+		"MessageMessage": welcome,
 	}, IndexPage)
 }
 
+// renderError renders an auth page with an error, keeping the page to return to.
+func (h *AuthHandler) renderError(c *gin.Context, page, next, message string) {
+	data := util.RequestError{
+		Title:   "Failed!",
+		Message: message,
+	}.Render()
+	data["next"] = next
+	util.Render(c, data, page)
+}
+
+// renderWalletError renders the page with the error matching a wallet service failure.
+func (h *AuthHandler) renderWalletError(c *gin.Context, page, next string, err error) {
+	e := walletError(err, "It seems we messed up somehow, please try again.")
+	data := e.Render()
+	data["next"] = next
+	util.RenderStatus(c, e.Code, data, page)
+}
+
+// redirectTarget returns the supplied address if it is safe to redirect to, or an empty string.
+// Only local paths are allowed so the sign in cannot be used as an open redirect. Browsers drop control characters
+// and read backslashes as slashes, so addresses with either are refused rather than parsed differently.
+func redirectTarget(next string) string {
+	if strings.IndexFunc(next, unicode.IsControl) >= 0 || strings.Contains(next, "\\") {
+		return ""
+	}
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil || !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(next, "//") {
+		return ""
+	}
+	return next
+}
+
+// validEmail returns whether the supplied string is a plain email address.
+func validEmail(email string) bool {
+	a, err := mail.ParseAddress(email)
+	return err == nil && a.Address == email
+}
+
 // hashPassword generates an hash based on the supplied string.
 func hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
@@ -191,14 +254,36 @@ type UserManager interface {
 	Add(user coin.User) error
 	Find(email string) (coin.User, error)
 	FindByWallet(wallet string) coin.User
+	FindByUsername(username string) []coin.User
+	Save(user coin.User) error
+	Rename(email string, user coin.User) error
+	Remove(user coin.User) error
+	List() map[string]coin.User
 }
 
 // WalletService defines the interface to interact with the blockchain wallet layer.
 type WalletService interface {
-	CreateUser(user string) (string, error)
-	GetUserBalance(user string) (string, error)
-	Airdrop(user string, amount float64) error
-	GetRewarded(user string) error
-	MakePayment(user string, amount int) error
-	GetUserTransactions(user string) ([]coin.Transaction, error)
+	CreateUser(ctx context.Context, user string) (string, error)
+	GetUserBalance(ctx context.Context, user string) (coin.Amount, error)
+	Airdrop(ctx context.Context, user string, amount coin.Amount) error
+	GetRewarded(ctx context.Context, user string) (string, error)
+	MakePayment(ctx context.Context, user string, amount coin.Amount) (string, error)
+	Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error)
+	Tip(ctx context.Context, from, to string, amount coin.Amount) (string, error)
+	GetUserTransactions(ctx context.Context, user string) ([]coin.Transaction, error)
+	EachUserTransaction(ctx context.Context, user string, fn func(coin.Transaction) error) error
+	RemoveTokens(ctx context.Context, user string) error
+}
+
+// WalletCache defines the interface of a WalletService caching the user balances.
+type WalletCache interface {
+	// BalanceDate returns when the cached balance was fetched, and whether it is being refreshed.
+	BalanceDate(wallet string) (time.Time, bool)
+}
+
+// GameEvents defines the interface to record the game events.
+type GameEvents interface {
+	SignedUp()
+	GameCreated()
+	TreasureClaimed()
 }
@@ -0,0 +1,86 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAuthHandler_SignInRedirect tests signing in sends the user back to the local page that required it,
+// and never to another site.
+func TestAuthHandler_SignInRedirect(t *testing.T) {
+	s := NewServer(t)
+	s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy"}, "meat")
+	s.Bootstrap(handlers.NewAuthHandler(s.Auth, s.DB.UserService(), s.DB.GameService(), nil, nil, nil, 0))
+
+	tests := []struct {
+		next     string
+		location string
+	}{
+		{"/games/describe/1", "/games/describe/1"},
+		{"/games/found/1/gold?token=abc", "/games/found/1/gold?token=abc"},
+		{"/", "/"},
+		{"", ""},
+		{"games/list", ""},
+		{"//evil.com", ""},
+		{"///evil.com", ""},
+		{"/\\evil.com", ""},
+		{"/\t/evil.com", ""},
+		{"/\n/evil.com", ""},
+		{"\t//evil.com", ""},
+		{"https://evil.com", ""},
+		{"https:/evil.com", ""},
+		{"javascript:alert(1)", ""},
+		{"/%2F/evil.com", "/%2F/evil.com"},
+	}
+	for _, tt := range tests {
+		w := s.Do(NewRequest(http.MethodPost, "/auth/signin", url.Values{
+			"email":    {"luffy@treasure.coin"},
+			"password": {"meat"},
+			"next":     {tt.next},
+		}), "")
+		if tt.location == "" {
+			assert.Equal(t, http.StatusOK, w.Code, "%q", tt.next)
+			assert.Empty(t, w.Header().Get("Location"), "%q", tt.next)
+			continue
+		}
+		assert.Equal(t, http.StatusFound, w.Code, "%q", tt.next)
+		assert.Equal(t, tt.location, w.Header().Get("Location"), "%q", tt.next)
+	}
+}
+
+// TestAuthHandler_SignUpEmail tests signing up requires a valid email.
+func TestAuthHandler_SignUpEmail(t *testing.T) {
+	s := NewServer(t)
+	s.Bootstrap(handlers.NewAuthHandler(s.Auth, s.DB.UserService(), s.DB.GameService(), nil, nil, nil, 0))
+
+	for _, email := range []string{"", "zoro", coin.DeletedUser, "Zoro <zoro@treasure.coin>"} {
+		w := s.Do(NewJSONRequest(http.MethodPost, "/auth/signup", url.Values{
+			"email":    {email},
+			"username": {"zoro"},
+			"password": {"swords"},
+		}), "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, email)
+		assert.Contains(t, w.Body.String(), "Please provide a valid email.", email)
+	}
+	assert.Empty(t, s.DB.UserService().List())
+}
+
+// TestAuthHandler_SessionCookie tests the session cookie is not sent with the requests of other sites.
+func TestAuthHandler_SessionCookie(t *testing.T) {
+	s := NewServer(t)
+	s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy"}, "meat")
+	s.Bootstrap(handlers.NewAuthHandler(s.Auth, s.DB.UserService(), s.DB.GameService(), nil, nil, nil, 0))
+
+	w := s.Do(NewRequest(http.MethodPost, "/auth/signin", url.Values{"email": {"luffy@treasure.coin"}, "password": {"meat"}}), "")
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, middlewares.TokenCookie, cookies[0].Name)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	assert.True(t, cookies[0].HttpOnly)
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"encoding/csv"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/pmdcosta/treasure-coin"
 	"github.com/pmdcosta/treasure-coin/http/middlewares"
@@ -46,21 +49,19 @@ func NewDefaultHandler(auth *middlewares.AuthMiddleware, games GameManager, user
 func (h *DefaultHandler) Bootstrap(router *gin.Engine) {
 	h.logger.Info("Bootstrapping default handler")
 
-	// register middleware.
-	router.Use(h.auth.SetUserStatus())
-
 	// default routes.
 	h.group = router.Group(h.path)
 	h.group.GET(IndexRoute, h.showIndexPage)
 	h.group.GET(AboutRoute, h.showAboutPage)
-	h.group.GET(ProfileRoute, h.showProfilePage)
+	h.group.GET(ProfileRoute, h.auth.RequireAuth(), h.auth.RequireScope(coin.ScopeWalletRead), h.showProfilePage)
+	h.group.GET(ExportTransactionsRoute, h.auth.RequireAuth(), h.auth.RequireScope(coin.ScopeWalletRead), h.performExportTransactions)
 	h.group.GET(SignInRoute, h.showSignInPage)
 	h.group.GET(SignUpRoute, h.showSignUpPage)
 }
 
 // showIndexPage renders the about page.
 func (h *DefaultHandler) showIndexPage(c *gin.Context) {
-	games := h.games.List()
+	games := visibleGames(c, h.games.List())
 	util.Render(c, gin.H{
 		"games": games,
 	}, IndexPage)
@@ -73,33 +74,92 @@ func (h *DefaultHandler) showAboutPage(c *gin.Context) {
 
 // showProfilePage renders the profile page.
 func (h *DefaultHandler) showProfilePage(c *gin.Context) {
-	user, exists := c.Get(util.UserCookie)
-	if !exists {
-		util.Render(c, util.RequestError{
+	user := currentUser(c)
+
+	data := gin.H{
+		"tokens": h.auth.UserTokens(user.Email),
+		"scopes": coin.Scopes,
+	}
+
+	// get user balance.
+	if err := addBalance(c, h.wallets, user, data); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": user.Wallet}).Error(err)
+	}
+
+	// get the requested page of user transactions.
+	if err := addLedger(c, h.wallets, h.games, user, data); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": user.Wallet}).Error(err)
+	}
+
+	data["payload"] = gin.H{
+		"balance":      data["balance"],
+		"balance_date": data["balance_date"],
+		"transactions": data["transactions"],
+		"page":         data["ledger"].(gin.H)["page"],
+		"has_next":     data["ledger"].(gin.H)["next"] != nil,
+	}
+	util.Render(c, data, ProfilePage)
+}
+
+// performExportTransactions downloads the full filtered transaction history of the user as CSV or JSON.
+func (h *DefaultHandler) performExportTransactions(c *gin.Context) {
+	user := currentUser(c)
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		util.Abort(c, util.RequestError{
+			Code:    http.StatusBadRequest,
 			Title:   "Failed!",
-			Message: "Requires a logged in user.",
-		}.Render(), IndexPage)
+			Message: "Please choose either the csv or json format.",
+		})
 		return
 	}
 
-	// get user balance.
-	b, _ := h.wallets.GetUserBalance(user.(coin.User).Wallet)
+	q, e := parseLedgerQuery(c)
+	if e != nil {
+		util.Abort(c, *e)
+		return
+	}
 
-	// get user transactions.
-	t, _ := h.wallets.GetUserTransactions(user.(coin.User).Wallet)
+	// read the whole ledger.
+	transactions := make([]coin.Transaction, 0)
+	err := h.wallets.EachUserTransaction(c.Request.Context(), user.Wallet, func(t coin.Transaction) error {
+		if q.filter.Match(t) {
+			transactions = append(transactions, t)
+		}
+		return nil
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": user.Wallet, "step": "export"}).Error(err)
+		util.Abort(c, walletError(err, "Failed to export the transactions, please try again."))
+		return
+	}
 
-	util.Render(c, gin.H{
-		"balance":      b,
-		"transactions": t,
-	}, ProfilePage)
+	enrichTransactions(h.games.List(), transactions)
+
+	c.Header("Content-Disposition", "attachment; filename="+exportFilename(user, format))
+	if format == "json" {
+		c.JSON(http.StatusOK, transactions)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := writeTransactionsCSV(csv.NewWriter(c.Writer), transactions); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": user.Wallet, "step": "export"}).Error(err)
+	}
 }
 
-// showSignInPage renders the about page.
+// showSignInPage renders the sign in page.
 func (h *DefaultHandler) showSignInPage(c *gin.Context) {
-	util.Render(c, gin.H{}, SignInPage)
+	util.Render(c, gin.H{
+		"next": redirectTarget(c.Query("next")),
+	}, SignInPage)
 }
 
-// showSignUpPage renders the about page.
+// showSignUpPage renders the sign up page.
 func (h *DefaultHandler) showSignUpPage(c *gin.Context) {
-	util.Render(c, gin.H{}, SignUpPage)
+	util.Render(c, gin.H{
+		"next": redirectTarget(c.Query("next")),
+	}, SignUpPage)
 }
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pmdcosta/treasure-coin"
+)
+
+// wallet errors of the sign-up.
+const (
+	errWalletPending = coin.Error("the wallet of the user has not been created yet")
+	errWalletEmpty   = coin.Error("wallet provider returned an empty wallet")
+)
+
+// createWallet creates the wallet of a user signing up and airdrops the signup coins.
+// While the wallet provider is unavailable it reports the sign-up as deferred, returning the wallet created so far, if any.
+// An airdrop failing once sent is neither deferred nor reported, as it may have gone through.
+func createWallet(ctx context.Context, wallets WalletService, username string, airdrop coin.Amount) (wallet string, deferred bool, err error) {
+	wallet, err = wallets.CreateUser(ctx, username)
+	if errors.Is(err, coin.ErrWalletUnavailable) {
+		return "", true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if wallet == "" {
+		return "", false, errWalletEmpty
+	}
+
+	err = wallets.Airdrop(ctx, wallet, airdrop)
+	if errors.Is(err, coin.ErrWalletNotSent) {
+		return wallet, true, nil
+	}
+	if errors.Is(err, coin.ErrWalletUnavailable) {
+		// the coins may have been sent, so the airdrop is not deferred.
+		return wallet, false, nil
+	}
+	return wallet, false, err
+}
+
+// deferSignUp queues the wallet creation and airdrop of the user until the wallet provider recovers.
+func deferSignUp(queue WalletQueue, user coin.User, airdrop coin.Amount) error {
+	return queue.Defer(coin.Operation{
+		Kind:   coin.OperationSignUp,
+		User:   user.Email,
+		Amount: airdrop,
+	})
+}
+
+// WalletQueue defines the interface to defer wallet operations while the wallet provider is unavailable.
+type WalletQueue interface {
+	Defer(op coin.Operation) error
+	MoveUser(old, email string) error
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+)
+
+// walletError maps a wallet service failure to the error shown to the user.
+// Failures the user cannot act upon are shown with the fallback message.
+func walletError(err error, fallback string) util.RequestError {
+	e := util.RequestError{Title: "Failed!"}
+	switch {
+	case errors.Is(err, coin.ErrInsufficientBalance):
+		e.Code = http.StatusPaymentRequired
+		e.Message = "You do not have enough coins in your wallet."
+	case errors.Is(err, coin.ErrInvalidWallet):
+		e.Code = http.StatusUnprocessableEntity
+		e.Message = "Your wallet could not be found, please contact us."
+	case errors.Is(err, coin.ErrRateLimited):
+		e.Code = http.StatusTooManyRequests
+		e.Message = "The wallet service is busy, please try again in a minute."
+	case errors.Is(err, coin.ErrWalletUnavailable):
+		e.Code = http.StatusServiceUnavailable
+		e.Message = "The wallet service is unavailable, please try again later."
+	case errors.Is(err, errWalletPending):
+		e.Code = http.StatusConflict
+		e.Message = "Your wallet is still being set up, please try again in a few minutes."
+	case errors.Is(err, coin.ErrWalletAuth):
+		e.Code = http.StatusBadGateway
+		e.Message = fallback
+	default:
+		e.Code = http.StatusInternalServerError
+		e.Message = fallback
+	}
+	return e
+}
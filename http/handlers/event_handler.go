@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/events"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// heartbeatInterval is how often an idle stream is written to, so proxies keep it open.
+const heartbeatInterval = 15 * time.Second
+
+// retryInterval is how long the browsers wait before reconnecting a dropped stream.
+const retryInterval = 3 * time.Second
+
+// EventHandler streams the game events to the pages, as server-sent events.
+type EventHandler struct {
+	// custom logger object.
+	logger *log.Entry
+
+	// handler path
+	path string
+
+	// router group.
+	group *gin.RouterGroup
+
+	// middleware for handling user auth.
+	auth *middlewares.AuthMiddleware
+
+	// middleware for loading games.
+	gm *middlewares.GameMiddleware
+
+	// external services.
+	games GameManager
+	bus   EventSubscriber
+}
+
+// NewEventHandler returns a new instance of EventHandler.
+func NewEventHandler(auth *middlewares.AuthMiddleware, gm *middlewares.GameMiddleware, games GameManager, bus EventSubscriber) *EventHandler {
+	h := &EventHandler{
+		logger: log.WithFields(log.Fields{"package": "http", "module": "event-handler"}),
+		path:   "/games",
+		auth:   auth,
+		gm:     gm,
+		games:  games,
+		bus:    bus,
+	}
+
+	return h
+}
+
+// Bootstrap registers the handler routes in the server.
+func (h *EventHandler) Bootstrap(router *gin.Engine) {
+	h.logger.Info("Bootstrapping event handler")
+
+	// event routes.
+	h.group = router.Group(h.path)
+	h.group.GET(EventsRoute, h.auth.RequireAuth(), h.auth.RequireScope(coin.ScopeGamesRead), h.streamAllEvents)
+	h.group.GET(GameEventsRoute, h.auth.RequireAuth(), h.auth.RequireScope(coin.ScopeGamesRead), h.gm.LoadGame(), h.streamGameEvents)
+}
+
+// streamAllEvents streams the events of every game the user can see.
+func (h *EventHandler) streamAllEvents(c *gin.Context) {
+	h.stream(c, "")
+}
+
+// streamGameEvents streams the events of a game.
+func (h *EventHandler) streamGameEvents(c *gin.Context) {
+	game := c.MustGet(util.GameKey).(coin.Game)
+	h.stream(c, game.ID)
+}
+
+// stream writes the events of the game, or of every game when empty, until the client leaves or the server stops.
+// Browsers reconnecting send the last event they received, and are sent those they missed if still kept.
+func (h *EventHandler) stream(c *gin.Context, game string) {
+	user := currentUser(c)
+	after, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	sub := h.bus.Subscribe(game, after)
+	defer sub.Close()
+
+	// the stream outlives the write timeout of the server.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"error": err}).Warn("stream is cut by the server write timeout")
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", retryInterval.Milliseconds())
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if !h.visible(e, user) {
+				continue
+			}
+			err = writeEvent(c.Writer, e)
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		if err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// visible returns whether the game of the event can be seen by the user, as games can be hidden while streamed.
+func (h *EventHandler) visible(e events.Event, user coin.User) bool {
+	game, err := h.games.Find(e.Game)
+	return err == nil && game.VisibleTo(user)
+}
+
+// writeEvent writes the event in the server-sent events format.
+func writeEvent(w gin.ResponseWriter, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Kind, data)
+	return err
+}
+
+// EventSubscriber defines the interface to follow the game events.
+type EventSubscriber interface {
+	Subscribe(game string, after uint64) *events.Subscription
+}
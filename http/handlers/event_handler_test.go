@@ -0,0 +1,25 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin/events"
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEventHandler_Anonymous tests the events are not streamed to the visitors signed out.
+func TestEventHandler_Anonymous(t *testing.T) {
+	s := NewServer(t)
+	bus := events.NewBus()
+	defer bus.Close()
+	s.Bootstrap(handlers.NewEventHandler(s.Auth, s.Games, s.DB.GameService(), bus))
+
+	w := s.Do(NewRequest(http.MethodGet, "/games/events", nil), "")
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/signin?next=%2Fgames%2Fevents", w.Header().Get("Location"))
+
+	w = s.Do(NewJSONRequest(http.MethodGet, "/games/events", nil), "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gosimple/slug"
 	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/events"
 	"github.com/pmdcosta/treasure-coin/http/middlewares"
 	"github.com/pmdcosta/treasure-coin/http/util"
 	"github.com/satori/go.uuid"
@@ -24,26 +28,42 @@ type GameHandler struct {
 	path string
 	host string
 
+	// directory the QR codes of the treasures are written to.
+	codes string
+
 	// router group.
 	group *gin.RouterGroup
 
 	// middleware for handling user auth.
 	auth *middlewares.AuthMiddleware
 
+	// middleware for loading games.
+	gm *middlewares.GameMiddleware
+
 	// external services.
-	games   GameManager
-	wallets WalletService
+	games     GameManager
+	wallets   WalletService
+	transfers TransferTracker
+	queue     WalletQueue
+	events    GameEvents
+	bus       EventPublisher
 }
 
 // NewGameHandler returns a new instance of GameHandler.
-func NewGameHandler(auth *middlewares.AuthMiddleware, games GameManager, wallets WalletService, host string) *GameHandler {
+func NewGameHandler(auth *middlewares.AuthMiddleware, gm *middlewares.GameMiddleware, games GameManager, wallets WalletService, transfers TransferTracker, queue WalletQueue, events GameEvents, bus EventPublisher, host, codes string) *GameHandler {
 	h := &GameHandler{
-		logger:  log.WithFields(log.Fields{"package": "http", "module": "game-handler"}),
-		path:    "/games",
-		auth:    auth,
-		games:   games,
-		wallets: wallets,
-		host:    host,
+		logger:    log.WithFields(log.Fields{"package": "http", "module": "game-handler"}),
+		path:      "/games",
+		auth:      auth,
+		gm:        gm,
+		games:     games,
+		wallets:   wallets,
+		transfers: transfers,
+		queue:     queue,
+		events:    events,
+		bus:       bus,
+		host:      host,
+		codes:     codes,
 	}
 
 	return h
@@ -53,79 +73,42 @@ func NewGameHandler(auth *middlewares.AuthMiddleware, games GameManager, wallets
 func (h *GameHandler) Bootstrap(router *gin.Engine) {
 	h.logger.Info("Bootstrapping game handler")
 
-	// register middleware.
-	router.Use(h.auth.SetUserStatus())
-
 	// default routes.
 	h.group = router.Group(h.path)
-	h.group.GET(CreateGameRoute, h.showCreatePage)
-	h.group.GET(ListGameRoute, h.showListPage)
-	h.group.GET(DescribeGameRoute, h.showDescribePage)
-	h.group.POST(CreateGameRoute, h.performCreateGame)
-	h.group.GET(DescribeTreasureRoute, h.showDescribeTreasurePage)
-	h.group.GET(FoundTreasureRoute, h.performFoundTreasure)
+	h.group.GET(CreateGameRoute, h.auth.RequireRole(coin.RoleCreator), h.showCreatePage)
+	h.group.GET(ListGameRoute, h.auth.RequireScope(coin.ScopeGamesRead), h.showListPage)
+	h.group.GET(DescribeGameRoute, h.auth.RequireAuth(), h.auth.RequireScope(coin.ScopeGamesRead), h.gm.LoadGame(), h.showDescribePage)
+	h.group.POST(CreateGameRoute, h.auth.RequireRole(coin.RoleCreator), h.auth.RequireScope(coin.ScopeGamesWrite), h.performCreateGame)
+	h.group.GET(DescribeTreasureRoute, h.auth.RequireAuth(), h.auth.RequireScope(coin.ScopeGamesRead), h.gm.LoadGame(), h.gm.LoadTreasure(), h.showDescribeTreasurePage)
+	h.group.GET(FoundTreasureRoute, h.auth.RequireAuth(), h.auth.RequireScope(coin.ScopeGamesWrite), h.gm.LoadGame(), h.gm.LoadTreasure(), h.performFoundTreasure)
 }
 
 // showCreatePage renders the create game page.
 func (h *GameHandler) showCreatePage(c *gin.Context) {
-	logedin, exists := c.Get(util.LogInCookie)
-	if !exists || !logedin.(bool) {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "SignIn/SignUp to create a new game.",
-		}.Render(), SignInPage)
-		return
-	}
-
 	util.Render(c, gin.H{}, CreateGamePage)
 }
 
 // showListPage renders the list of available games page.
 func (h *GameHandler) showListPage(c *gin.Context) {
-	games := h.games.List()
+	games := visibleGames(c, h.games.List())
 	util.Render(c, gin.H{
-		"games": games,
+		"games":   games,
+		"payload": publicGames(c, games),
 	}, ListGamePage)
 }
 
 // showDescribePage renders the describe game page.
 func (h *GameHandler) showDescribePage(c *gin.Context) {
-	user, exists := c.Get(util.UserCookie)
-	if !exists {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Requires a logged in user.",
-		}.Render(), IndexPage)
-		return
-	}
-
-	g := c.Param("game")
-
-	game, err := h.games.Find(g)
-	if err != nil {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Game not found.",
-		}.Render(), IndexPage)
-	}
-	game.ID = g
-
+	game := c.MustGet(util.GameKey).(coin.Game)
 	util.Render(c, gin.H{
-		"game": game,
-		"user": user.(coin.User),
+		"game":    game,
+		"payload": publicGame(c, game),
 	}, DescribeGamePage)
 }
 
 // performCreateGame creates a new game.
 func (h *GameHandler) performCreateGame(c *gin.Context) {
-	user, exists := c.Get(util.UserCookie)
-	if !exists {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Requires a logged in user.",
-		}.Render(), IndexPage)
-		return
-	}
+	user := currentUser(c)
 
 	// validate request.
 	r := createGameRequest{}
@@ -139,7 +122,7 @@ func (h *GameHandler) performCreateGame(c *gin.Context) {
 		Title:       r.title,
 		Description: r.description,
 		StartDate:   time.Now().Truncate(time.Second),
-		Creator:     user.(coin.User).Email,
+		Creator:     user.Email,
 		Treasures:   make(map[string]coin.Treasure),
 	}
 
@@ -148,7 +131,7 @@ func (h *GameHandler) performCreateGame(c *gin.Context) {
 		// create token.
 		token, err := uuid.NewV4()
 		if err != nil {
-			h.logger.WithFields(log.Fields{"err": err}).Error("failed to generate uuid")
+			util.Logger(c, h.logger).WithFields(log.Fields{"err": err}).Error("failed to generate uuid")
 			util.Render(c, util.RequestError{
 				Title:   "Failed!",
 				Message: "Failed to create game, please try again.",
@@ -168,18 +151,28 @@ func (h *GameHandler) performCreateGame(c *gin.Context) {
 	}
 
 	// attempt to make payment for the game.
-	err := h.wallets.MakePayment(user.(coin.User).Wallet, len(r.treasures))
+	cost := coin.TreasurePrice.Mul(int64(len(r.treasures)))
+	tx, err := "", error(errWalletPending)
+	if user.Wallet != "" {
+		tx, err = h.wallets.MakePayment(c.Request.Context(), user.Wallet, cost)
+	}
 	if err != nil {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Failed to create game, you require more tokens to create that many treasures (1 treasure = 0.1 Coins).",
-		}.Render(), CreateGamePage)
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": user.Wallet}).Error(err)
+		e := walletError(err, "Failed to create game, please try again.")
+		if errors.Is(err, coin.ErrInsufficientBalance) {
+			e.Message = h.shortfallMessage(c, user, len(r.treasures))
+		}
+		util.RenderStatus(c, e.Code, e.Render(), CreateGamePage)
+		return
 	}
+	// the game is only playable once the payment is confirmed.
+	g.Transaction = tx
+	g.Payment = coin.TransferPending
 
 	// persist game data.
 	gameID, err := h.games.Add(g)
 	if err != nil {
-		h.logger.Error(err)
+		util.Logger(c, h.logger).Error(err)
 		util.Render(c, util.RequestError{
 			Title:   "Failed!",
 			Message: "Failed to create game, please try again.",
@@ -187,100 +180,72 @@ func (h *GameHandler) performCreateGame(c *gin.Context) {
 		return
 	}
 
+	// follow the payment until it settles, or once the tracker reconciles the game if it cannot be tracked now.
+	err = h.transfers.Track(coin.Transfer{
+		ID:     tx,
+		Event:  coin.EventGameCreated,
+		Wallet: user.Wallet,
+		Amount: cost,
+		Game:   gameID,
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"game": gameID, "transaction": tx, "step": "track"}).Error(err)
+	}
+
 	// create qr codes for tokens.
+	codes := make(map[string]string)
 	for _, t := range g.Treasures {
 		discoveryUrl := fmt.Sprintf("%s/games/found/%s/%s?token=%s", h.host, gameID, t.ID, t.Token)
 		codeFile := fmt.Sprintf("%s-%s.png", gameID, t.ID)
-		err := qrcode.WriteFile(discoveryUrl, qrcode.Medium, 256, fmt.Sprintf("%s/%s", "public/codes", codeFile))
+		err := qrcode.WriteFile(discoveryUrl, qrcode.Medium, 256, filepath.Join(h.codes, codeFile))
 		if err != nil {
-			h.logger.Error("failed to generate QR code file.")
+			util.Logger(c, h.logger).Error("failed to generate QR code file.")
 		}
-		t.QRCode = codeFile
-		g.Treasures[t.ID] = t
+		codes[t.ID] = codeFile
 	}
 
-	// save game.
+	// save game, keeping the payment status if the tracker already settled it.
+	err = h.games.Update(gameID, func(game *coin.Game) error {
+		for id, code := range codes {
+			t := game.Treasures[id]
+			t.QRCode = code
+			game.Treasures[id] = t
+		}
+		g = *game
+		return nil
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"game": gameID, "step": "codes"}).Error(err)
+	}
 	g.ID = gameID
-	h.games.Save(g)
+	h.events.GameCreated()
 
 	util.Render(c, gin.H{
 		"MessageTitle":   "Success!",
-		"MessageMessage": "The game has been created, check the treasures for the QR code to hide!",
+		"MessageMessage": "The game has been created, check the treasures for the QR code to hide! Players will see it once your payment is confirmed.",
 		"game":           g,
-		"user":           user.(coin.User),
+		"payload":        g,
 	}, DescribeGamePage)
 }
 
 // showDescribeTreasurePage renders the describe treasure page.
 func (h *GameHandler) showDescribeTreasurePage(c *gin.Context) {
-	user, exists := c.Get(util.UserCookie)
-	if !exists {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Requires a logged in user.",
-		}.Render(), IndexPage)
-		return
-	}
-
-	g := c.Param("game")
-	t := c.Param("treasure")
-
-	game, err := h.games.Find(g)
-	if err != nil {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Game not found.",
-		}.Render(), IndexPage)
-	}
-
-	treasure, ok := game.Treasures[t]
-	if !ok {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Treasure not found.",
-		}.Render(), IndexPage)
-	}
-
+	game := c.MustGet(util.GameKey).(coin.Game)
+	treasure := c.MustGet(util.TreasureKey).(coin.Treasure)
 	util.Render(c, gin.H{
 		"game":     game,
 		"treasure": treasure,
-		"user":     user.(coin.User),
+		"payload":  publicGame(c, game).Treasures[c.Param("treasure")],
 	}, DescribeTreasurePage)
 }
 
 // performFoundTreasure sets a treasure as found.
 func (h *GameHandler) performFoundTreasure(c *gin.Context) {
-	user, exists := c.Get(util.UserCookie)
-	if !exists {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Requires a logged in user.",
-		}.Render(), IndexPage)
-		return
-	}
-
-	g := c.Param("game")
-	t := c.Param("treasure")
+	user := currentUser(c)
+	game := c.MustGet(util.GameKey).(coin.Game)
+	treasure := c.MustGet(util.TreasureKey).(coin.Treasure)
 	token := c.Query("token")
 
-	// get game.
-	game, err := h.games.Find(g)
-	if err != nil {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Game not found.",
-		}.Render(), IndexPage)
-	}
-
-	// get treasure.
-	treasure, ok := game.Treasures[t]
-	if !ok {
-		util.Render(c, util.RequestError{
-			Title:   "Failed!",
-			Message: "Treasure not found.",
-		}.Render(), IndexPage)
-	}
-
 	// check if token is correct.
 	if treasure.Token != token {
 		util.Render(c, gin.H{
@@ -289,50 +254,217 @@ func (h *GameHandler) performFoundTreasure(c *gin.Context) {
 			"ErrorTitle":   "Failed!",
 			"ErrorMessage": "Incorrect treasure token!",
 		}, DescribeTreasurePage)
+		return
 	}
 
-	// check if treasure was already found.
-	if treasure.Found {
+	// claim the treasure before rewarding the user, so it is rewarded once however many players find it at once.
+	found := treasure
+	game, err := h.claimTreasure(game, treasure.ID, user)
+	if errors.Is(err, errTreasureFound) {
 		util.Render(c, gin.H{
 			"game":         game,
-			"treasure":     treasure,
+			"treasure":     game.Treasures[treasure.ID],
 			"ErrorTitle":   "Failed!",
 			"ErrorMessage": "This treasure has already been found!",
 		}, DescribeTreasurePage)
+		return
 	}
-
-	// get rewarded.
-	if err := h.wallets.GetRewarded(user.(coin.User).Wallet); err != nil {
-		h.logger.WithFields(log.Fields{"wallet": user.(coin.User).Wallet}).Error(err)
-		util.Render(c, gin.H{
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"game": game.ID, "treasure": treasure.ID, "step": "claim"}).Error(err)
+		util.RenderStatus(c, http.StatusInternalServerError, gin.H{
 			"game":         game,
 			"treasure":     treasure,
 			"ErrorTitle":   "Failed!",
 			"ErrorMessage": "It seems we messed up somehow, please try again!",
 		}, DescribeTreasurePage)
+		return
+	}
+	treasure = game.Treasures[treasure.ID]
+
+	// get rewarded, later if the wallet provider is unavailable or the wallet of the user is not created yet.
+	tx, err := "", error(errWalletPending)
+	if user.Wallet != "" {
+		tx, err = h.wallets.GetRewarded(c.Request.Context(), user.Wallet)
+	}
+	if errors.Is(err, coin.ErrWalletNotSent) || errors.Is(err, errWalletPending) {
+		h.deferReward(c, user, game, treasure, found)
+		return
+	}
+	if errors.Is(err, coin.ErrWalletUnavailable) {
+		// the reward may have been sent before the provider failed, so it is neither retried nor deferred.
+		// it is left for an admin to check, the treasure showing the outcome as unknown in the admin console.
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": user.Wallet, "game": game.ID, "treasure": treasure.ID}).Error("reward outcome unknown, check it with the wallet provider: ", err)
+		treasure.Reward = coin.TransferUnknown
+		game = h.saveTreasure(c, game, treasure)
+		h.renderFound(c, user, game, treasure, "You have found a lost treasure! The wallet service did not confirm your reward, please contact us if it does not reach your wallet.")
+		return
+	}
+	if err != nil {
+		// the reward was not sent, so the treasure can be found again.
+		h.releaseTreasure(c, game, found, user)
+		util.Logger(c, h.logger).WithFields(log.Fields{"wallet": user.Wallet}).Error(err)
+		e := walletError(err, "It seems we messed up somehow, please try again!")
+		if errors.Is(err, coin.ErrInsufficientBalance) {
+			// the company pool, not the player, ran out of coins.
+			e.Message = "There are no coins left to reward you with, please try again later."
+		}
+		util.RenderStatus(c, e.Code, gin.H{
+			"game":         game,
+			"treasure":     found,
+			"ErrorTitle":   e.Title,
+			"ErrorMessage": e.Message,
+		}, DescribeTreasurePage)
+		return
 	}
 
-	// set the treasure as found.
-	treasure.Found = true
-	treasure.FoundUser = user.(coin.User).Email
-	treasure.FoundDate = time.Now()
+	// record the reward transaction.
+	treasure.Transaction = tx
+	game = h.saveTreasure(c, game, treasure)
+
+	// follow the reward until it settles, or once the tracker reconciles the game if it cannot be tracked now.
+	err = h.transfers.Track(coin.Transfer{
+		ID:       tx,
+		Event:    coin.EventTreasureFound,
+		Wallet:   user.Wallet,
+		Game:     game.ID,
+		Treasure: treasure.ID,
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"game": game.ID, "treasure": treasure.ID, "transaction": tx, "step": "track"}).Error(err)
+	}
 
-	game.ID = g
-	game.Treasures[t] = treasure
-	h.games.Save(game)
+	h.renderFound(c, user, game, treasure, "You have found a lost treasure!")
+}
+
+// deferReward queues the reward of the treasure claimed by the user until the wallet provider recovers.
+// The treasure is released when the reward cannot be queued, so it can be found again.
+func (h *GameHandler) deferReward(c *gin.Context, user coin.User, game coin.Game, treasure, found coin.Treasure) {
+	err := h.queue.Defer(coin.Operation{
+		Kind:     coin.OperationReward,
+		User:     user.Email,
+		Game:     game.ID,
+		Treasure: treasure.ID,
+	})
+	if err != nil {
+		h.releaseTreasure(c, game, found, user)
+		util.Logger(c, h.logger).WithFields(log.Fields{"game": game.ID, "treasure": treasure.ID}).Error(err)
+		e := walletError(coin.ErrWalletUnavailable, "")
+		util.RenderStatus(c, e.Code, gin.H{
+			"game":         game,
+			"treasure":     found,
+			"ErrorTitle":   e.Title,
+			"ErrorMessage": e.Message,
+		}, DescribeTreasurePage)
+		return
+	}
+
+	h.renderFound(c, user, game, treasure, "You have found a lost treasure! Your reward will be sent as soon as the wallet service is available.")
+}
+
+// renderFound announces the treasure found by the user, and renders the message.
+func (h *GameHandler) renderFound(c *gin.Context, user coin.User, game coin.Game, treasure coin.Treasure, message string) {
+	h.events.TreasureClaimed()
+	h.publishFound(game, treasure, user)
 
 	util.Render(c, gin.H{
 		"game":           game,
 		"treasure":       treasure,
 		"MessageTitle":   "Congratulations!",
-		"MessageMessage": "You have found a lost treasure!",
+		"MessageMessage": message,
+		"payload":        publicGame(c, game).Treasures[c.Param("treasure")],
 	}, DescribeTreasurePage)
 }
 
+// Settled publishes the creation of a game once its payment is complete, as the players cannot see it before.
+func (h *GameHandler) Settled(transfer coin.Transfer) {
+	if transfer.Event != coin.EventGameCreated || transfer.Status != coin.TransferComplete {
+		return
+	}
+	game, err := h.games.Find(transfer.Game)
+	if err != nil || game.Transaction != transfer.ID {
+		return
+	}
+	game.ID = transfer.Game
+	h.bus.Publish(events.GameCreated(game))
+}
+
+// saveTreasure stores the treasure in its game, returning the game saved.
+// Only the treasure is changed, so the transfers the tracker settled meanwhile are kept.
+func (h *GameHandler) saveTreasure(c *gin.Context, game coin.Game, treasure coin.Treasure) coin.Game {
+	err := h.games.Update(game.ID, func(g *coin.Game) error {
+		g.Treasures[treasure.ID] = treasure
+		game = *g
+		return nil
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"game": game.ID, "treasure": treasure.ID}).Error(err)
+		game.Treasures[treasure.ID] = treasure
+	}
+	return game
+}
+
+// errTreasureFound is returned when claiming a treasure another player has already found.
+const errTreasureFound = coin.Error("the treasure has already been found")
+
+// claimTreasure sets the treasure as found by the user with its reward pending, returning the game saved.
+// The treasure is checked and claimed at once, so only one of the players finding it together claims it.
+func (h *GameHandler) claimTreasure(game coin.Game, id string, user coin.User) (coin.Game, error) {
+	err := h.games.Update(game.ID, func(g *coin.Game) error {
+		t := g.Treasures[id]
+		if t.Found {
+			game = *g
+			return errTreasureFound
+		}
+
+		// the reward transaction is recorded once it is sent.
+		t.Found = true
+		t.FoundUser = user.Email
+		t.FoundDate = time.Now()
+		t.Reward = coin.TransferPending
+		g.Treasures[id] = t
+		game = *g
+		return nil
+	})
+	return game, err
+}
+
+// releaseTreasure restores the treasure claimed by the user whose reward was not sent, so it can be found again.
+func (h *GameHandler) releaseTreasure(c *gin.Context, game coin.Game, treasure coin.Treasure, user coin.User) {
+	err := h.games.Update(game.ID, func(g *coin.Game) error {
+		if t := g.Treasures[treasure.ID]; t.FoundUser == user.Email && t.Transaction == "" {
+			g.Treasures[treasure.ID] = treasure
+		}
+		return nil
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"game": game.ID, "treasure": treasure.ID, "step": "release"}).Error(err)
+	}
+}
+
+// publishFound publishes the treasure being found by the user, and the game finishing if it was the last one.
+func (h *GameHandler) publishFound(game coin.Game, treasure coin.Treasure, user coin.User) {
+	h.bus.Publish(events.TreasureFound(game, treasure, user.Username))
+	if game.Finished() {
+		h.bus.Publish(events.GameFinished(game))
+	}
+}
+
 /**
  * Requests
  */
 
+// shortfallMessage tells the creator how many coins they are missing to pay for the treasures.
+func (h *GameHandler) shortfallMessage(c *gin.Context, user coin.User, treasures int) string {
+	cost := coin.TreasurePrice.Mul(int64(treasures))
+	msg := fmt.Sprintf("Failed to create game, %d treasures cost %s Coins (1 treasure = %s Coins).", treasures, cost, coin.TreasurePrice)
+
+	balance, err := h.wallets.GetUserBalance(c.Request.Context(), user.Wallet)
+	if err != nil || balance.Cmp(cost) >= 0 {
+		return msg
+	}
+	return msg + fmt.Sprintf(" You are %s Coins short.", cost.Sub(balance))
+}
+
 // CreateGameRequest represents the form data from a performCreateGame request.
 type createGameRequest struct {
 	title       string
@@ -432,11 +564,69 @@ func (r *createGameRequest) Validate(c *gin.Context) *util.RequestError {
 	return nil
 }
 
+// visibleGames filters out the games the current user is not allowed to see.
+func visibleGames(c *gin.Context, games map[string]coin.Game) map[string]coin.Game {
+	user := currentUser(c)
+	visible := make(map[string]coin.Game)
+	for id, g := range games {
+		if g.VisibleTo(user) {
+			visible[id] = g
+		}
+	}
+	return visible
+}
+
+// publicGame returns a copy of the game without the treasure secrets, unless the current user created it.
+// The emails of the other users are never returned, like those of the creator and of the players finding the treasures.
+func publicGame(c *gin.Context, game coin.Game) coin.Game {
+	user := currentUser(c)
+	creator := user.Email != "" && user.Email == game.Creator
+	if !creator {
+		game.Creator = ""
+	}
+
+	treasures := make(map[string]coin.Treasure)
+	for k, t := range game.Treasures {
+		if !creator {
+			t.Token = ""
+			t.QRCode = ""
+		}
+		if user.Email == "" || t.FoundUser != user.Email {
+			t.FoundUser = ""
+		}
+		treasures[k] = t
+	}
+	game.Treasures = treasures
+	return game
+}
+
+// publicGames returns copies of the games without the treasure secrets.
+func publicGames(c *gin.Context, games map[string]coin.Game) map[string]coin.Game {
+	public := make(map[string]coin.Game)
+	for id, g := range games {
+		g.ID = id
+		public[id] = publicGame(c, g)
+	}
+	return public
+}
+
+// TransferTracker defines the interface to follow the transfers until they settle.
+type TransferTracker interface {
+	Track(transfer coin.Transfer) error
+	Update(transfer coin.Transfer) error
+}
+
+// EventPublisher defines the interface to publish the game events.
+type EventPublisher interface {
+	Publish(e events.Event)
+}
+
 // GameManager defines the interface to interact with the game persistence layer.
 type GameManager interface {
 	Add(game coin.Game) (string, error)
 	Find(id string) (coin.Game, error)
 	Save(game coin.Game) error
+	Update(id string, fn func(game *coin.Game) error) error
 	Remove(game coin.Game) error
 	List() map[string]coin.Game
 }
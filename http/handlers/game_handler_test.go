@@ -0,0 +1,222 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/events"
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGameHandler_FoundTreasure tests rewarding the player finding a treasure, later only if the reward was not sent.
+func TestGameHandler_FoundTreasure(t *testing.T) {
+	tests := map[string]struct {
+		err error
+
+		message     string
+		deferred    bool
+		transaction string
+		reward      coin.TransferStatus
+		balance     coin.Amount
+	}{
+		"rewarded": {
+			message:     "You have found a lost treasure!",
+			transaction: "tx-1",
+			reward:      coin.TransferPending,
+			balance:     coin.TreasurePrice,
+		},
+		"not sent": {
+			err:      coin.ErrWalletNotSent,
+			message:  "Your reward will be sent as soon as the wallet service is available.",
+			deferred: true,
+			reward:   coin.TransferPending,
+		},
+		"timeout": {
+			err:     fmt.Errorf("%w: request timed out", coin.ErrWalletUnavailable),
+			message: "The wallet service did not confirm your reward, please contact us if it does not reach your wallet.",
+			reward:  coin.TransferUnknown,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewServer(t)
+			session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy", Wallet: "wallet-luffy"}, "meat")
+			id, err := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "shanks@treasure.coin", Treasures: map[string]coin.Treasure{
+				"one-piece": {ID: "one-piece", Name: "One Piece", Token: "laugh-tale"},
+			}})
+			assert.Nil(t, err)
+
+			wallets, transfers, queue := NewWallet(), &Transfers{}, &Queue{}
+			wallets.Err = tc.err
+			bus := events.NewBus()
+			defer bus.Close()
+			sub := bus.Subscribe("", 0)
+			defer sub.Close()
+			s.Bootstrap(handlers.NewGameHandler(s.Auth, s.Games, s.DB.GameService(), wallets, transfers, queue, &Events{}, bus, "http://localhost", t.TempDir()))
+
+			w := s.Do(NewRequest(http.MethodGet, "/games/found/"+id+"/one-piece?token=laugh-tale", nil), session)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), tc.message)
+			assert.Equal(t, tc.deferred, len(queue.Ops) == 1)
+			assert.Equal(t, tc.balance, wallets.Balance("wallet-luffy"))
+
+			// the treasure is found either way, its reward pending until it settles or is sent, unless it must be checked.
+			g, err := s.DB.GameService().Find(id)
+			assert.Nil(t, err)
+			assert.True(t, g.Treasures["one-piece"].Found)
+			assert.Equal(t, tc.reward, g.Treasures["one-piece"].Reward)
+			assert.Equal(t, tc.transaction, g.Treasures["one-piece"].Transaction)
+
+			// the event names the player by username only.
+			e := <-sub.Events()
+			assert.Equal(t, events.KindTreasureFound, e.Kind)
+			assert.Equal(t, "luffy", e.Player)
+		})
+	}
+}
+
+// TestGameHandler_FoundTreasureOnce tests a treasure found by several players at once rewards only one of them.
+func TestGameHandler_FoundTreasureOnce(t *testing.T) {
+	s := NewServer(t)
+	id, err := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "shanks@treasure.coin", Treasures: map[string]coin.Treasure{
+		"one-piece": {ID: "one-piece", Name: "One Piece", Token: "laugh-tale"},
+	}})
+	assert.Nil(t, err)
+
+	// the rewards take long enough for the players to find the treasure while another is rewarded.
+	wallets := NewWallet()
+	wallets.Delay = 50 * time.Millisecond
+	bus := events.NewBus()
+	defer bus.Close()
+	s.Bootstrap(handlers.NewGameHandler(s.Auth, s.Games, s.DB.GameService(), wallets, &Transfers{}, &Queue{}, &Events{}, bus, "http://localhost", t.TempDir()))
+
+	players := []string{"luffy", "zoro", "nami", "usopp", "sanji"}
+	sessions := make([]string, len(players))
+	for i, p := range players {
+		sessions[i] = s.AddUser(t, coin.User{Email: p + "@treasure.coin", Username: p, Wallet: "wallet-" + p}, "meat")
+	}
+
+	var wg sync.WaitGroup
+	bodies := make([]string, len(players))
+	for i := range players {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := s.Do(NewRequest(http.MethodGet, "/games/found/"+id+"/one-piece?token=laugh-tale", nil), sessions[i])
+			bodies[i] = w.Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	winner := ""
+	for i, p := range players {
+		if strings.Contains(bodies[i], "You have found a lost treasure!") {
+			assert.Empty(t, winner, "rewarded twice")
+			winner = p
+			assert.Equal(t, coin.TreasurePrice, wallets.Balance("wallet-"+p))
+		} else {
+			assert.Contains(t, bodies[i], "This treasure has already been found!")
+			assert.Equal(t, coin.Amount(0), wallets.Balance("wallet-"+p))
+		}
+	}
+	require.NotEmpty(t, winner)
+
+	g, err := s.DB.GameService().Find(id)
+	assert.Nil(t, err)
+	assert.Equal(t, winner+"@treasure.coin", g.Treasures["one-piece"].FoundUser)
+	assert.Equal(t, "tx-1", g.Treasures["one-piece"].Transaction)
+}
+
+// TestGameHandler_FoundTreasureFailed tests a treasure whose reward was not sent can be found again.
+func TestGameHandler_FoundTreasureFailed(t *testing.T) {
+	s := NewServer(t)
+	session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy", Wallet: "wallet-luffy"}, "meat")
+	id, err := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "shanks@treasure.coin", Treasures: map[string]coin.Treasure{
+		"one-piece": {ID: "one-piece", Name: "One Piece", Token: "laugh-tale"},
+	}})
+	assert.Nil(t, err)
+
+	wallets := NewWallet()
+	wallets.Err = coin.ErrInsufficientBalance
+	bus := events.NewBus()
+	defer bus.Close()
+	s.Bootstrap(handlers.NewGameHandler(s.Auth, s.Games, s.DB.GameService(), wallets, &Transfers{}, &Queue{}, &Events{}, bus, "http://localhost", t.TempDir()))
+
+	w := s.Do(NewRequest(http.MethodGet, "/games/found/"+id+"/one-piece?token=laugh-tale", nil), session)
+	assert.Equal(t, http.StatusPaymentRequired, w.Code)
+	assert.Contains(t, w.Body.String(), "There are no coins left to reward you with, please try again later.")
+	g, err := s.DB.GameService().Find(id)
+	assert.Nil(t, err)
+	assert.False(t, g.Treasures["one-piece"].Found)
+	assert.Empty(t, g.Treasures["one-piece"].FoundUser)
+
+	// once the wallet recovers.
+	wallets.Err = nil
+	w = s.Do(NewRequest(http.MethodGet, "/games/found/"+id+"/one-piece?token=laugh-tale", nil), session)
+	assert.Contains(t, w.Body.String(), "You have found a lost treasure!")
+	assert.Equal(t, coin.TreasurePrice, wallets.Balance("wallet-luffy"))
+}
+
+// TestGameHandler_Settled tests announcing a game once its payment is complete.
+func TestGameHandler_Settled(t *testing.T) {
+	s := NewServer(t)
+	id, err := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "shanks@treasure.coin", Transaction: "tx-1", Payment: coin.TransferPending})
+	assert.Nil(t, err)
+
+	bus := events.NewBus()
+	defer bus.Close()
+	sub := bus.Subscribe("", 0)
+	defer sub.Close()
+	h := handlers.NewGameHandler(s.Auth, s.Games, s.DB.GameService(), NewWallet(), &Transfers{}, &Queue{}, &Events{}, bus, "http://localhost", t.TempDir())
+
+	h.Settled(coin.Transfer{ID: "tx-1", Event: coin.EventGameCreated, Game: id, Status: coin.TransferFailed})
+	h.Settled(coin.Transfer{ID: "tx-0", Event: coin.EventGameCreated, Game: id, Status: coin.TransferComplete})
+	h.Settled(coin.Transfer{ID: "tx-2", Event: coin.EventTreasureFound, Game: id, Status: coin.TransferComplete})
+	assert.Len(t, sub.Events(), 0)
+
+	h.Settled(coin.Transfer{ID: "tx-1", Event: coin.EventGameCreated, Game: id, Status: coin.TransferComplete})
+	require.Len(t, sub.Events(), 1)
+	e := <-sub.Events()
+	assert.Equal(t, events.KindGameCreated, e.Kind)
+	assert.Equal(t, id, e.Game)
+	assert.Equal(t, "Grand Line", e.GameTitle)
+}
+
+// TestGameHandler_PublicGames tests the games returned to the API tokens name no one by email, and only show the
+// treasure secrets to their creator.
+func TestGameHandler_PublicGames(t *testing.T) {
+	s := NewServer(t)
+	s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy"}, "meat")
+	s.AddUser(t, coin.User{Email: "shanks@treasure.coin", Username: "shanks", Role: coin.RoleCreator}, "sake")
+	id, err := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "shanks@treasure.coin", Treasures: map[string]coin.Treasure{
+		"one-piece": {ID: "one-piece", Name: "One Piece", Token: "laugh-tale", Found: true, FoundUser: "nami@treasure.coin"},
+	}})
+	assert.Nil(t, err)
+	bus := events.NewBus()
+	defer bus.Close()
+	s.Bootstrap(handlers.NewGameHandler(s.Auth, s.Games, s.DB.GameService(), NewWallet(), &Transfers{}, &Queue{}, &Events{}, bus, "http://localhost", t.TempDir()))
+
+	routes := []string{"/games/list", "/games/describe/" + id, "/games/describe/" + id + "/treasure/one-piece"}
+	for _, email := range []string{"luffy@treasure.coin", "shanks@treasure.coin"} {
+		secret, _, err := s.Auth.CreateToken(email, "cli", []coin.Scope{coin.ScopeGamesRead}, 0)
+		require.Nil(t, err)
+		for _, route := range routes {
+			req := NewJSONRequest(http.MethodGet, route, nil)
+			req.Header.Set("Authorization", "Bearer "+secret)
+			w := s.Do(req, "")
+			assert.Equal(t, http.StatusOK, w.Code, route)
+			assert.NotContains(t, w.Body.String(), "nami@treasure.coin", route)
+			assert.Equal(t, email == "shanks@treasure.coin", strings.Contains(w.Body.String(), "laugh-tale"), route)
+			if route != routes[2] {
+				assert.Equal(t, email == "shanks@treasure.coin", strings.Contains(w.Body.String(), "shanks@treasure.coin"), route)
+			}
+		}
+	}
+}
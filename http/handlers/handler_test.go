@@ -0,0 +1,287 @@
+package handlers_test
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/web"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Server is a test wrapper serving the handlers over a new database, rendering the real pages.
+type Server struct {
+	Router *gin.Engine
+	DB     *database.Client
+	Auth   *middlewares.AuthMiddleware
+	Games  *middlewares.GameMiddleware
+}
+
+// NewServer returns a new instance of Server, its database removed when the test ends.
+func NewServer(t *testing.T) *Server {
+	log.SetLevel(log.DebugLevel)
+	gin.SetMode(gin.TestMode)
+
+	db := database.NewClient(filepath.Join(t.TempDir(), "app.db"))
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	templates := template.Must(template.New("").Funcs(template.FuncMap{
+		"asset": func(name string) string { return "/assets/" + name },
+	}).ParseFS(web.Templates(""), "*.html"))
+
+	// the user status is set once for every handler, like the server does.
+	auth := middlewares.NewAuthMiddleware(db.UserService(), db.SessionService(), db.TokenService())
+	router := gin.New()
+	router.SetHTMLTemplate(templates)
+	router.Use(middlewares.NewErrorMiddleware().HandleErrors(), auth.SetUserStatus())
+
+	return &Server{
+		Router: router,
+		DB:     db,
+		Auth:   auth,
+		Games:  middlewares.NewGameMiddleware(db.GameService()),
+	}
+}
+
+// Bootstrap registers the routes of the handlers.
+func (s *Server) Bootstrap(handlers ...interface{ Bootstrap(router *gin.Engine) }) {
+	for _, h := range handlers {
+		h.Bootstrap(s.Router)
+	}
+}
+
+// AddUser stores the user with the password, returning a session token signing them in.
+// Users without a password only sign in through an identity provider.
+func (s *Server) AddUser(t *testing.T, user coin.User, password string) string {
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.Password = string(hash)
+	}
+	if err := s.DB.UserService().Add(user); err != nil {
+		t.Fatal(err)
+	}
+
+	session := middlewares.CreateSessionToken()
+	if err := s.DB.SessionService().Add(session, user.Email); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+// Do sends a request signed in with the session, if any.
+func (s *Server) Do(req *http.Request, session string) *httptest.ResponseRecorder {
+	if session != "" {
+		req.AddCookie(&http.Cookie{Name: middlewares.TokenCookie, Value: session})
+	}
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+// NewRequest returns a request posting the form, if any.
+func NewRequest(method, target string, form url.Values) *http.Request {
+	if form == nil {
+		return httptest.NewRequest(method, target, nil)
+	}
+	req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+// NewJSONRequest returns a request like NewRequest, asking for a JSON response.
+func NewJSONRequest(method, target string, form url.Values) *http.Request {
+	req := NewRequest(method, target, form)
+	req.Header.Set("Accept", "application/json")
+	return req
+}
+
+// Wallet is an in-memory wallet service, paying the rewards from the company wallet.
+type Wallet struct {
+	mu           sync.Mutex
+	balances     map[string]coin.Amount
+	transactions []coin.Transaction
+
+	// Err fails every call when set.
+	Err error
+	// Delay slows down the transfers, like a remote wallet provider.
+	Delay time.Duration
+}
+
+// company is the wallet the rewards are paid from and the payments are made to.
+const company = "company"
+
+// NewWallet returns a new instance of Wallet.
+func NewWallet() *Wallet {
+	return &Wallet{balances: map[string]coin.Amount{company: coin.Coin.Mul(1000)}}
+}
+
+// Balance returns the balance of the wallet.
+func (w *Wallet) Balance(wallet string) coin.Amount {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.balances[wallet]
+}
+
+// Record adds the transaction to the history of its wallets, without moving any coins.
+func (w *Wallet) Record(tr coin.Transaction) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.transactions = append(w.transactions, tr)
+}
+
+// move transfers the amount between the wallets, returning the id of the transaction.
+func (w *Wallet) move(from, to string, amount coin.Amount, event string) (string, error) {
+	time.Sleep(w.Delay)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Err != nil {
+		return "", w.Err
+	}
+	if w.balances[from].Cmp(amount) < 0 {
+		return "", coin.ErrInsufficientBalance
+	}
+	w.balances[from] = w.balances[from].Sub(amount)
+	w.balances[to] = w.balances[to].Add(amount)
+
+	id := "tx-" + strconv.Itoa(len(w.transactions)+1)
+	w.transactions = append(w.transactions, coin.Transaction{ID: id, FromWallet: from, ToWallet: to, Event: event, Date: time.Now(), Amount: amount})
+	return id, nil
+}
+
+func (w *Wallet) CreateUser(ctx context.Context, user string) (string, error) {
+	if w.Err != nil {
+		return "", w.Err
+	}
+	return "wallet-" + user, nil
+}
+func (w *Wallet) GetUserBalance(ctx context.Context, user string) (coin.Amount, error) {
+	if w.Err != nil {
+		return 0, w.Err
+	}
+	return w.Balance(user), nil
+}
+func (w *Wallet) Airdrop(ctx context.Context, user string, amount coin.Amount) error {
+	_, err := w.move(company, user, amount, coin.EventAirdrop)
+	return err
+}
+func (w *Wallet) GetRewarded(ctx context.Context, user string) (string, error) {
+	return w.move(company, user, coin.TreasurePrice, coin.EventTreasureFound)
+}
+func (w *Wallet) MakePayment(ctx context.Context, user string, amount coin.Amount) (string, error) {
+	return w.move(user, company, amount, coin.EventGameCreated)
+}
+func (w *Wallet) Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	return w.move(from, to, amount, coin.EventTransfer)
+}
+func (w *Wallet) Tip(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	return w.move(from, to, amount, coin.EventTip)
+}
+func (w *Wallet) GetUserTransactions(ctx context.Context, user string) ([]coin.Transaction, error) {
+	var t []coin.Transaction
+	err := w.EachUserTransaction(ctx, user, func(tr coin.Transaction) error {
+		t = append(t, tr)
+		return nil
+	})
+	return t, err
+}
+func (w *Wallet) EachUserTransaction(ctx context.Context, user string, fn func(coin.Transaction) error) error {
+	w.mu.Lock()
+	if w.Err != nil {
+		w.mu.Unlock()
+		return w.Err
+	}
+	var t []coin.Transaction
+	for i := len(w.transactions) - 1; i >= 0; i-- {
+		tr := w.transactions[i]
+		switch user {
+		case tr.ToWallet:
+			t = append(t, tr)
+		case tr.FromWallet:
+			tr.Amount = tr.Amount.Neg()
+			t = append(t, tr)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, tr := range t {
+		if err := fn(tr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (w *Wallet) RemoveTokens(ctx context.Context, user string) error {
+	_, err := w.move(user, company, w.Balance(user), coin.EventTokensReturned)
+	return err
+}
+
+// Events counts the game events recorded.
+type Events struct {
+	mu                                      sync.Mutex
+	signUps, gamesCreated, treasuresClaimed int
+}
+
+// SignUps returns the number of sign ups recorded.
+func (e *Events) SignUps() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.signUps
+}
+
+func (e *Events) SignedUp() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.signUps++
+}
+func (e *Events) GameCreated() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.gamesCreated++
+}
+func (e *Events) TreasureClaimed() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.treasuresClaimed++
+}
+
+// Queue is an in-memory queue of the deferred wallet operations.
+type Queue struct {
+	mu  sync.Mutex
+	Ops []coin.Operation
+}
+
+func (q *Queue) Defer(op coin.Operation) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.Ops = append(q.Ops, op)
+	return nil
+}
+func (q *Queue) MoveUser(old, email string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.Ops {
+		if q.Ops[i].User == old {
+			q.Ops[i].User = email
+		}
+	}
+	return nil
+}
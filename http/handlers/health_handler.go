@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// readinessTimeout bounds every readiness check.
+const readinessTimeout = 2 * time.Second
+
+// HealthHandler handles the liveness, readiness and metrics routes in the server.
+type HealthHandler struct {
+	// custom logger object.
+	logger *log.Entry
+
+	// external services.
+	db      DatabasePinger
+	wallets WalletPinger
+	metrics http.Handler
+
+	// bearer token the metrics are scraped with, the metrics are not served without it.
+	token string
+}
+
+// NewHealthHandler returns a new instance of HealthHandler.
+func NewHealthHandler(db DatabasePinger, wallets WalletPinger, metrics http.Handler, token string) *HealthHandler {
+	h := &HealthHandler{
+		logger:  log.WithFields(log.Fields{"package": "http", "module": "health-handler"}),
+		db:      db,
+		wallets: wallets,
+		metrics: metrics,
+		token:   token,
+	}
+
+	return h
+}
+
+// Bootstrap registers the handler routes in the server.
+func (h *HealthHandler) Bootstrap(router *gin.Engine) {
+	h.logger.Info("Bootstrapping health handler")
+
+	// health routes.
+	router.GET(HealthRoute, h.showHealth)
+	router.GET(ReadinessRoute, h.showReadiness)
+	if h.token != "" {
+		router.GET(MetricsRoute, h.requireToken, gin.WrapH(h.metrics))
+	}
+}
+
+// requireToken only lets the requests bearing the metrics token through.
+func (h *HealthHandler) requireToken(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	c.Next()
+}
+
+// showHealth reports the server is running.
+func (h *HealthHandler) showHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// showReadiness reports whether the database and the wallet provider can be reached.
+func (h *HealthHandler) showReadiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	// the failures are only logged, their details may hold credentials.
+	checks := gin.H{"database": "ok", "wallet": "ok"}
+	status, ready := http.StatusOK, "ready"
+	if err := h.db.Ping(); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"check": "database"}).Warn(err)
+		checks["database"], status, ready = "unavailable", http.StatusServiceUnavailable, "unavailable"
+	}
+	if err := h.wallets.Ping(ctx); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"check": "wallet"}).Warn(err)
+		checks["wallet"], status, ready = "unavailable", http.StatusServiceUnavailable, "unavailable"
+	}
+	c.JSON(status, gin.H{"status": ready, "checks": checks})
+}
+
+// DatabasePinger defines the interface to check the database can be read.
+type DatabasePinger interface {
+	Ping() error
+}
+
+// WalletPinger defines the interface to check the wallet provider can be reached.
+type WalletPinger interface {
+	Ping(ctx context.Context) error
+}
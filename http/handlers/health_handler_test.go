@@ -0,0 +1,50 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+// Pinger is a wallet provider that can always be reached.
+type Pinger struct{}
+
+func (Pinger) Ping(ctx context.Context) error { return nil }
+
+// TestHealthHandler_Metrics tests the metrics are only served to the requests bearing the token.
+func TestHealthHandler_Metrics(t *testing.T) {
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("requests_total 1")) })
+
+	tests := map[string]struct {
+		token         string
+		authorization string
+
+		code int
+	}{
+		"token":         {token: "scraper", authorization: "Bearer scraper", code: http.StatusOK},
+		"wrong token":   {token: "scraper", authorization: "Bearer other", code: http.StatusUnauthorized},
+		"no token sent": {token: "scraper", code: http.StatusUnauthorized},
+		"not served":    {authorization: "Bearer ", code: http.StatusNotFound},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := NewServer(t)
+			s.Bootstrap(handlers.NewHealthHandler(s.DB, Pinger{}, metrics, tc.token))
+
+			req := NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := s.Do(req, "")
+			assert.Equal(t, tc.code, w.Code)
+			assert.Equal(t, tc.code == http.StatusOK, w.Body.String() == "requests_total 1")
+
+			// the health checks stay public.
+			w = s.Do(NewRequest(http.MethodGet, "/readyz", nil), "")
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxWebhooks bounds how many webhooks a user can register.
+const maxWebhooks = 10
+
+// maxWebhookURL bounds the length of the webhook urls.
+const maxWebhookURL = 2048
+
+// deliveryLogSize is how many of the latest deliveries of every webhook are shown.
+const deliveryLogSize = 20
+
+// HookHandler handles the webhooks notified of the game events, registered by the creators for their games
+// and by the admins for every game.
+type HookHandler struct {
+	// custom logger object.
+	logger *log.Entry
+
+	// handler path
+	path string
+
+	// router group.
+	group *gin.RouterGroup
+
+	// middleware for handling user auth.
+	auth *middlewares.AuthMiddleware
+
+	// external services.
+	hooks      WebhookManager
+	deliveries DeliveryManager
+	games      GameManager
+	dispatcher DeliveryRetrier
+}
+
+// NewHookHandler returns a new instance of HookHandler.
+func NewHookHandler(auth *middlewares.AuthMiddleware, hooks WebhookManager, deliveries DeliveryManager, games GameManager, dispatcher DeliveryRetrier) *HookHandler {
+	h := &HookHandler{
+		logger:     log.WithFields(log.Fields{"package": "http", "module": "hook-handler"}),
+		path:       "/hooks",
+		auth:       auth,
+		hooks:      hooks,
+		deliveries: deliveries,
+		games:      games,
+		dispatcher: dispatcher,
+	}
+
+	return h
+}
+
+// Bootstrap registers the handler routes in the server.
+func (h *HookHandler) Bootstrap(router *gin.Engine) {
+	h.logger.Info("Bootstrapping hook handler")
+
+	// hook routes.
+	h.group = router.Group(h.path, h.auth.RequireSession(), h.auth.RequireRole(coin.RoleCreator))
+	h.group.GET(ListHooksRoute, h.showHooksPage)
+	h.group.POST(CreateHookRoute, h.performCreateHook)
+	h.group.POST(RemoveHookRoute, h.performRemoveHook)
+	h.group.POST(RetryDeliveryRoute, h.performRetryDelivery)
+}
+
+// showHooksPage renders the webhooks of the user and their delivery log.
+func (h *HookHandler) showHooksPage(c *gin.Context) {
+	h.render(c, gin.H{})
+}
+
+// performCreateHook registers a webhook, showing its secret once.
+func (h *HookHandler) performCreateHook(c *gin.Context) {
+	user := currentUser(c)
+
+	// validate request.
+	r := createHookRequest{}
+	if err := r.Validate(c); err != nil {
+		h.renderStatus(c, http.StatusBadRequest, err.Render())
+		return
+	}
+
+	// only admins follow every game, and creators their own games.
+	if r.game == "" && !user.HasRole(coin.RoleAdmin) {
+		h.renderStatus(c, http.StatusForbidden, util.RequestError{
+			Title:   "Failed!",
+			Message: "Only admins can register webhooks for every game.",
+		}.Render())
+		return
+	}
+	if r.game != "" {
+		game, err := h.games.Find(r.game)
+		if err != nil || (game.Creator != user.Email && !user.HasRole(coin.RoleAdmin)) {
+			h.renderStatus(c, http.StatusNotFound, util.RequestError{
+				Title:   "Failed!",
+				Message: "Game not found.",
+			}.Render())
+			return
+		}
+	}
+	if len(h.hooks.FindByOwner(user.Email)) >= maxWebhooks {
+		h.renderStatus(c, http.StatusConflict, util.RequestError{
+			Title:   "Failed!",
+			Message: "You cannot register more webhooks, please remove one first.",
+		}.Render())
+		return
+	}
+
+	// generate the secret signing the deliveries.
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"err": err}).Error("failed to generate webhook secret")
+		h.renderStatus(c, http.StatusInternalServerError, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+	secret := hex.EncodeToString(b)
+
+	id, err := h.hooks.Add(coin.Webhook{
+		URL:         r.url,
+		Secret:      secret,
+		Game:        r.game,
+		Owner:       user.Email,
+		CreatedDate: time.Now().Truncate(time.Second),
+	})
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"owner": user.Email}).Error(err)
+		h.renderStatus(c, http.StatusInternalServerError, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+	util.Logger(c, h.logger).WithFields(log.Fields{"webhook": id, "game": r.game, "owner": user.Email}).Info("webhook registered")
+
+	data := util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The webhook has been registered, copy its secret now as it will not be shown again.",
+	}.Render()
+	data["created_secret"] = secret
+	h.render(c, data)
+}
+
+// performRemoveHook removes a webhook and its delivery log.
+func (h *HookHandler) performRemoveHook(c *gin.Context) {
+	hook, ok := h.findHook(c)
+	if !ok {
+		return
+	}
+
+	if err := h.hooks.Remove(hook); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"webhook": hook.ID}).Error(err)
+		h.renderStatus(c, http.StatusInternalServerError, util.RequestError{
+			Title:   "Failed!",
+			Message: "It seems we messed up somehow, please try again.",
+		}.Render())
+		return
+	}
+	if err := h.deliveries.Remove(h.deliveries.FindByWebhook(hook.ID)...); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"webhook": hook.ID}).Error(err)
+	}
+	util.Logger(c, h.logger).WithFields(log.Fields{"webhook": hook.ID, "by": currentUser(c).Email}).Info("webhook removed")
+
+	h.render(c, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The webhook has been removed.",
+	}.Render())
+}
+
+// performRetryDelivery sends a delivery given up again.
+func (h *HookHandler) performRetryDelivery(c *gin.Context) {
+	hook, ok := h.findHook(c)
+	if !ok {
+		return
+	}
+
+	d, err := h.deliveries.Find(c.Param("delivery"))
+	if err != nil || d.Webhook != hook.ID {
+		h.renderStatus(c, http.StatusNotFound, util.RequestError{
+			Title:   "Failed!",
+			Message: "Delivery not found.",
+		}.Render())
+		return
+	}
+	if err := h.dispatcher.Retry(d.ID); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"webhook": hook.ID, "delivery": d.ID}).Warn(err)
+		h.renderStatus(c, http.StatusConflict, util.RequestError{
+			Title:   "Failed!",
+			Message: "Only the deliveries given up can be retried.",
+		}.Render())
+		return
+	}
+
+	h.render(c, util.RequestSuccess{
+		Title:   "Success!",
+		Message: "The delivery will be sent again shortly.",
+	}.Render())
+}
+
+// findHook loads the webhook in the 'hook' route parameter, if the current user can manage it.
+func (h *HookHandler) findHook(c *gin.Context) (coin.Webhook, bool) {
+	user := currentUser(c)
+	hook, err := h.hooks.Find(c.Param("hook"))
+	if err != nil || (hook.Owner != user.Email && !user.HasRole(coin.RoleAdmin)) {
+		h.renderStatus(c, http.StatusNotFound, util.RequestError{
+			Title:   "Failed!",
+			Message: "Webhook not found.",
+		}.Render())
+		return coin.Webhook{}, false
+	}
+	return hook, true
+}
+
+// render renders the webhooks page along with the supplied messages.
+func (h *HookHandler) render(c *gin.Context, data gin.H) {
+	h.renderStatus(c, http.StatusOK, data)
+}
+
+// renderStatus renders the webhooks page with the status code: admins see every webhook, creators their own.
+func (h *HookHandler) renderStatus(c *gin.Context, code int, data gin.H) {
+	user := currentUser(c)
+	games := h.games.List()
+
+	var hooks []coin.Webhook
+	if user.HasRole(coin.RoleAdmin) {
+		hooks = h.hooks.List()
+	} else {
+		hooks = h.hooks.FindByOwner(user.Email)
+	}
+
+	views := make([]hookView, 0, len(hooks))
+	for _, hook := range hooks {
+		// the latest deliveries first.
+		all := h.deliveries.FindByWebhook(hook.ID)
+		deliveries := make([]coin.Delivery, 0, deliveryLogSize)
+		for i := len(all) - 1; i >= 0 && len(deliveries) < deliveryLogSize; i-- {
+			deliveries = append(deliveries, all[i])
+		}
+		views = append(views, hookView{
+			Webhook:    hook,
+			GameTitle:  games[hook.Game].Title,
+			Deliveries: deliveries,
+		})
+	}
+
+	// the games the user can register webhooks for.
+	own := make(map[string]coin.Game)
+	for id, g := range games {
+		if g.Creator == user.Email || user.HasRole(coin.RoleAdmin) {
+			own[id] = g
+		}
+	}
+
+	data["hooks"] = views
+	data["games"] = own
+	util.RenderStatus(c, code, data, HooksPage)
+}
+
+// hookView represents a webhook shown with its delivery log.
+type hookView struct {
+	coin.Webhook
+	GameTitle  string
+	Deliveries []coin.Delivery
+}
+
+// createHookRequest represents the form data from a performCreateHook request.
+type createHookRequest struct {
+	url  string
+	game string
+}
+
+// Validate validates a createHookRequest request.
+func (r *createHookRequest) Validate(c *gin.Context) *util.RequestError {
+	r.url = strings.TrimSpace(c.PostForm("url"))
+	r.game = c.PostForm("game")
+
+	u, err := url.Parse(r.url)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(r.url) > maxWebhookURL {
+		return &util.RequestError{
+			Title:   "Failed!",
+			Message: "Please provide a valid http or https webhook url.",
+		}
+	}
+	return nil
+}
+
+// WebhookManager defines the interface to interact with the webhook persistence layer.
+type WebhookManager interface {
+	Add(hook coin.Webhook) (string, error)
+	Find(id string) (coin.Webhook, error)
+	Save(hook coin.Webhook) error
+	Remove(hook coin.Webhook) error
+	List() []coin.Webhook
+	FindByOwner(email string) []coin.Webhook
+}
+
+// DeliveryManager defines the interface to interact with the webhook delivery persistence layer.
+type DeliveryManager interface {
+	Find(id string) (coin.Delivery, error)
+	Remove(deliveries ...coin.Delivery) error
+	FindByWebhook(hook string) []coin.Delivery
+}
+
+// DeliveryRetrier defines the interface to send the deliveries given up again.
+type DeliveryRetrier interface {
+	Retry(id string) error
+}
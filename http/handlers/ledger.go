@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+)
+
+// ledgerPageSize is how many transactions are shown per profile page.
+const ledgerPageSize = 10
+
+// ledgerDateFormat is the format of the dates in the transaction filter.
+const ledgerDateFormat = "2006-01-02"
+
+// errLedgerPageFull stops reading the ledger once the page has been collected.
+const errLedgerPageFull = coin.Error("ledger page is full")
+
+// ledgerQuery represents the transactions requested by the user.
+type ledgerQuery struct {
+	filter coin.TransactionFilter
+	page   int
+
+	// values holds the validated query, used to build the pagination and export links.
+	values url.Values
+}
+
+// parseLedgerQuery reads the transaction filter and page from the request.
+func parseLedgerQuery(c *gin.Context) (ledgerQuery, *util.RequestError) {
+	q := ledgerQuery{page: 1, values: url.Values{}}
+
+	if from := c.Query("from"); from != "" {
+		d, err := time.ParseInLocation(ledgerDateFormat, from, time.Local)
+		if err != nil {
+			return q, &util.RequestError{Code: http.StatusBadRequest, Title: "Failed!", Message: "Please provide a valid start date."}
+		}
+		q.filter.From = d
+		q.values.Set("from", from)
+	}
+
+	// the end date is inclusive.
+	if to := c.Query("to"); to != "" {
+		d, err := time.ParseInLocation(ledgerDateFormat, to, time.Local)
+		if err != nil {
+			return q, &util.RequestError{Code: http.StatusBadRequest, Title: "Failed!", Message: "Please provide a valid end date."}
+		}
+		q.filter.To = d.AddDate(0, 0, 1)
+		q.values.Set("to", to)
+	}
+
+	if event := c.Query("event"); event != "" {
+		q.filter.Event = event
+		q.values.Set("event", event)
+	}
+
+	if page := c.Query("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return q, &util.RequestError{Code: http.StatusBadRequest, Title: "Failed!", Message: "Please provide a valid page."}
+		}
+		q.page = n
+	}
+	return q, nil
+}
+
+// url returns the address of the supplied path with the query filter and the extra parameters.
+func (q ledgerQuery) url(path string, extra ...string) string {
+	v := url.Values{}
+	for k, vs := range q.values {
+		v[k] = vs
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		v.Set(extra[i], extra[i+1])
+	}
+	if len(v) == 0 {
+		return path
+	}
+	return path + "?" + v.Encode()
+}
+
+// ledgerPage represents a page of the filtered transactions of a user.
+type ledgerPage struct {
+	Transactions []coin.Transaction
+	Page         int
+	HasNext      bool
+}
+
+// loadLedgerPage reads the ledger of the wallet up to the requested page.
+func loadLedgerPage(c *gin.Context, wallets WalletService, wallet string, q ledgerQuery) (ledgerPage, error) {
+	p := ledgerPage{Page: q.page, Transactions: []coin.Transaction{}}
+	skip := (q.page - 1) * ledgerPageSize
+	if wallet == "" {
+		// the wallet is not created yet.
+		return p, nil
+	}
+
+	err := wallets.EachUserTransaction(c.Request.Context(), wallet, func(t coin.Transaction) error {
+		if !q.filter.Match(t) {
+			return nil
+		}
+		if skip > 0 {
+			skip--
+			return nil
+		}
+		if len(p.Transactions) == ledgerPageSize {
+			p.HasNext = true
+			return errLedgerPageFull
+		}
+		p.Transactions = append(p.Transactions, t)
+		return nil
+	})
+	if err == errLedgerPageFull {
+		err = nil
+	}
+	return p, err
+}
+
+// enrichTransactions links the transactions to the games and treasures they paid for.
+func enrichTransactions(games map[string]coin.Game, transactions []coin.Transaction) {
+	// index the games and treasures by transaction.
+	index := make(map[string]coin.Transaction)
+	for id, g := range games {
+		if g.Transaction != "" {
+			index[g.Transaction] = coin.Transaction{Game: id, GameTitle: g.Title, Status: g.Payment}
+		}
+		for tid, t := range g.Treasures {
+			if t.Transaction != "" {
+				index[t.Transaction] = coin.Transaction{Game: id, GameTitle: g.Title, Treasure: tid, TreasureName: t.Name, Status: t.Reward}
+			}
+		}
+	}
+
+	for i, t := range transactions {
+		if ref, ok := index[t.ID]; ok && t.ID != "" {
+			transactions[i].Game = ref.Game
+			transactions[i].GameTitle = ref.GameTitle
+			transactions[i].Treasure = ref.Treasure
+			transactions[i].TreasureName = ref.TreasureName
+			transactions[i].Status = ref.Status
+		}
+	}
+}
+
+// addLedger adds the requested page of the user transactions to the profile page data.
+// Invalid queries are reported in the page and the first unfiltered page is shown instead.
+func addLedger(c *gin.Context, wallets WalletService, games GameManager, user coin.User, data gin.H) error {
+	q, e := parseLedgerQuery(c)
+	if e != nil {
+		q = ledgerQuery{page: 1, values: url.Values{}}
+		if _, exists := data["ErrorTitle"]; !exists {
+			for k, v := range e.Render() {
+				data[k] = v
+			}
+		}
+	}
+
+	p, err := loadLedgerPage(c, wallets, user.Wallet, q)
+	enrichTransactions(games.List(), p.Transactions)
+
+	ledger := gin.H{
+		"page":   p.Page,
+		"from":   q.values.Get("from"),
+		"to":     q.values.Get("to"),
+		"event":  q.filter.Event,
+		"events": coin.Events,
+		"csv":    q.url(ExportTransactionsRoute, "format", "csv"),
+		"json":   q.url(ExportTransactionsRoute, "format", "json"),
+	}
+	if p.Page > 1 {
+		ledger["prev"] = q.url(ProfileRoute, "page", strconv.Itoa(p.Page-1))
+	}
+	if p.HasNext {
+		ledger["next"] = q.url(ProfileRoute, "page", strconv.Itoa(p.Page+1))
+	}
+
+	data["transactions"] = p.Transactions
+	data["ledger"] = ledger
+	return err
+}
+
+// addBalance adds the user balance to the profile page data, along with when it was fetched if it is cached.
+func addBalance(c *gin.Context, wallets WalletService, user coin.User, data gin.H) error {
+	if user.Wallet == "" {
+		// the wallet is created once the wallet provider recovers.
+		data["wallet_pending"] = true
+		return nil
+	}
+
+	b, err := wallets.GetUserBalance(c.Request.Context(), user.Wallet)
+	if err != nil {
+		data["balance_unavailable"] = true
+		return err
+	}
+	data["balance"] = b
+
+	// the cache reports when the balance was fetched.
+	if wc, ok := wallets.(WalletCache); ok {
+		if date, stale := wc.BalanceDate(user.Wallet); !date.IsZero() {
+			data["balance_date"] = date
+			data["balance_stale"] = stale
+		}
+	}
+	return nil
+}
+
+// writeTransactionsCSV writes the transactions as CSV, one row per transaction.
+func writeTransactionsCSV(w *csv.Writer, transactions []coin.Transaction) error {
+	if err := w.Write([]string{"id", "date", "event", "from_wallet", "to_wallet", "amount", "game", "treasure"}); err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		row := []string{t.ID, t.Date.Format(time.RFC3339), t.Event, t.FromWallet, t.ToWallet, t.Amount.Signed(), t.GameTitle, t.TreasureName}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// exportFilename returns the name of an exported transaction history file.
+func exportFilename(user coin.User, format string) string {
+	return fmt.Sprintf("treasure-coin-%s-transactions.%s", user.Username, format)
+}
@@ -0,0 +1,186 @@
+package handlers_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+// NewLedgerServer returns a server signed in as a user with 25 transactions, one a day from the first of January,
+// alternating between airdrops and transfers. It returns the session of the user and the transactions, newest first.
+func NewLedgerServer(t *testing.T) (*Server, string, []coin.Transaction) {
+	s := NewServer(t)
+	session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy", Wallet: "wallet-luffy"}, "meat")
+	wallets := NewWallet()
+	s.Bootstrap(handlers.NewDefaultHandler(s.Auth, s.DB.GameService(), s.DB.UserService(), wallets))
+
+	var ledger []coin.Transaction
+	for i := 0; i < 25; i++ {
+		tr := coin.Transaction{
+			ID:         "tx-" + strconv.Itoa(i),
+			FromWallet: company,
+			ToWallet:   "wallet-luffy",
+			Event:      coin.EventAirdrop,
+			Date:       time.Date(2026, 1, 1+i, 12, 0, 0, 0, time.Local),
+			Amount:     coin.Coin,
+		}
+		if i%2 == 1 {
+			tr.Event = coin.EventTransfer
+		}
+		wallets.Record(tr)
+		ledger = append([]coin.Transaction{tr}, ledger...)
+	}
+	return s, session, ledger
+}
+
+// ids returns the ids of the transactions.
+func ids(transactions []coin.Transaction) []string {
+	ids := make([]string, 0, len(transactions))
+	for _, t := range transactions {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+// TestLedger_Page tests paging and filtering the transactions shown in the profile.
+func TestLedger_Page(t *testing.T) {
+	s, session, ledger := NewLedgerServer(t)
+
+	tests := map[string]struct {
+		query string
+
+		ids     []coin.Transaction
+		page    int
+		hasNext bool
+	}{
+		"first page":     {query: "", ids: ledger[:10], page: 1, hasNext: true},
+		"middle page":    {query: "?page=2", ids: ledger[10:20], page: 2, hasNext: true},
+		"last page":      {query: "?page=3", ids: ledger[20:], page: 3},
+		"past the end":   {query: "?page=4", ids: nil, page: 4},
+		"event":          {query: "?event=Transfer&page=2", ids: []coin.Transaction{ledger[21], ledger[23]}, page: 2},
+		"date range":     {query: "?from=2026-01-05&to=2026-01-09", ids: ledger[16:21], page: 1},
+		"range by event": {query: "?from=2026-01-05&to=2026-01-09&event=Airdrop", ids: []coin.Transaction{ledger[16], ledger[18], ledger[20]}, page: 1},
+		"invalid page":   {query: "?page=0&event=Transfer", ids: ledger[:10], page: 1, hasNext: true},
+		"invalid date":   {query: "?from=yesterday", ids: ledger[:10], page: 1, hasNext: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := s.Do(NewJSONRequest(http.MethodGet, "/me"+tc.query, nil), session)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var payload struct {
+				Transactions []coin.Transaction `json:"transactions"`
+				Page         int                `json:"page"`
+				HasNext      bool               `json:"has_next"`
+			}
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &payload))
+			assert.Equal(t, ids(tc.ids), ids(payload.Transactions))
+			assert.Equal(t, tc.page, payload.Page)
+			assert.Equal(t, tc.hasNext, payload.HasNext)
+		})
+	}
+}
+
+// TestLedger_Links tests the profile links to the neighbouring pages and the exports keep the filter, and reports
+// invalid filters.
+func TestLedger_Links(t *testing.T) {
+	s, session, _ := NewLedgerServer(t)
+
+	tests := map[string]struct {
+		query string
+
+		contains []string
+		excludes []string
+	}{
+		"first page": {
+			query:    "",
+			contains: []string{`href="/me?page=2"`, `href="/me/transactions?format=csv"`, `href="/me/transactions?format=json"`},
+			excludes: []string{"Newer"},
+		},
+		"filtered page": {
+			query:    "?event=Airdrop&page=2",
+			contains: []string{`href="/me?event=Airdrop&amp;page=1"`, `href="/me/transactions?event=Airdrop&amp;format=csv"`},
+			excludes: []string{"Older"},
+		},
+		"invalid page":       {query: "?page=x", contains: []string{"Please provide a valid page."}},
+		"invalid start date": {query: "?from=2026-13-01", contains: []string{"Please provide a valid start date."}},
+		"invalid end date":   {query: "?to=tomorrow", contains: []string{"Please provide a valid end date."}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := s.Do(NewRequest(http.MethodGet, "/me"+tc.query, nil), session)
+			assert.Equal(t, http.StatusOK, w.Code)
+			for _, c := range tc.contains {
+				assert.Contains(t, w.Body.String(), c)
+			}
+			for _, c := range tc.excludes {
+				assert.NotContains(t, w.Body.String(), c)
+			}
+		})
+	}
+}
+
+// TestLedger_Export tests exporting the whole filtered transaction history as CSV and JSON.
+func TestLedger_Export(t *testing.T) {
+	s, session, ledger := NewLedgerServer(t)
+
+	// the first transfer paid for a game.
+	_, err := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "luffy@treasure.coin", Transaction: "tx-1", Payment: coin.TransferComplete})
+	assert.Nil(t, err)
+
+	tests := map[string]struct {
+		query string
+
+		code    int
+		rows    []coin.Transaction
+		message string
+	}{
+		"csv":            {query: "?format=csv", code: http.StatusOK, rows: ledger},
+		"default format": {query: "", code: http.StatusOK, rows: ledger},
+		"csv by event":   {query: "?format=csv&event=Transfer&to=2026-01-04", code: http.StatusOK, rows: []coin.Transaction{ledger[21], ledger[23]}},
+		"json":           {query: "?format=json&from=2026-01-24", code: http.StatusOK, rows: ledger[:2]},
+		"json by event":  {query: "?format=json&event=Tip", code: http.StatusOK, rows: []coin.Transaction{}},
+		"unknown format": {query: "?format=xml", code: http.StatusBadRequest, message: "Please choose either the csv or json format."},
+		"invalid date":   {query: "?format=csv&from=soon", code: http.StatusBadRequest, message: "Please provide a valid start date."},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := s.Do(NewRequest(http.MethodGet, "/me/transactions"+tc.query, nil), session)
+			assert.Equal(t, tc.code, w.Code)
+			if tc.message != "" {
+				assert.Contains(t, w.Body.String(), tc.message)
+				return
+			}
+
+			if strings.Contains(tc.query, "json") {
+				assert.Equal(t, "attachment; filename=treasure-coin-luffy-transactions.json", w.Header().Get("Content-Disposition"))
+				var transactions []coin.Transaction
+				assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &transactions))
+				assert.Equal(t, ids(tc.rows), ids(transactions))
+				return
+			}
+
+			assert.Equal(t, "attachment; filename=treasure-coin-luffy-transactions.csv", w.Header().Get("Content-Disposition"))
+			assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+			records, err := csv.NewReader(w.Body).ReadAll()
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"id", "date", "event", "from_wallet", "to_wallet", "amount", "game", "treasure"}, records[0])
+			assert.Len(t, records, len(tc.rows)+1)
+			for i, tr := range tc.rows {
+				game := ""
+				if tr.ID == "tx-1" {
+					game = "Grand Line"
+				}
+				assert.Equal(t, []string{tr.ID, tr.Date.Format(time.RFC3339), tr.Event, company, "wallet-luffy", "+1", game, ""}, records[i+1])
+			}
+		})
+	}
+}
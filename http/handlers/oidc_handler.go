@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// oidcStateCookie holds the state of an ongoing OpenID Connect sign in.
+const oidcStateCookie = "oidc_state"
+
+// OIDCHandler handles the OpenID Connect sign in routes in the server.
+type OIDCHandler struct {
+	// custom logger object.
+	logger *log.Entry
+
+	// handler path
+	path string
+
+	// router group.
+	group *gin.RouterGroup
+
+	// middleware for handling user auth.
+	auth *middlewares.AuthMiddleware
+
+	// external services.
+	provider IdentityProvider
+	users    UserManager
+	wallets  WalletService
+	queue    WalletQueue
+	events   GameEvents
+
+	// coins airdropped to every new user.
+	airdrop coin.Amount
+}
+
+// NewOIDCHandler returns a new instance of OIDCHandler.
+func NewOIDCHandler(auth *middlewares.AuthMiddleware, provider IdentityProvider, users UserManager, wallets WalletService, queue WalletQueue, events GameEvents, airdrop coin.Amount) *OIDCHandler {
+	h := &OIDCHandler{
+		logger:   log.WithFields(log.Fields{"package": "http", "module": "oidc-handler"}),
+		path:     "/auth/oidc",
+		auth:     auth,
+		provider: provider,
+		users:    users,
+		wallets:  wallets,
+		queue:    queue,
+		events:   events,
+		airdrop:  airdrop,
+	}
+
+	return h
+}
+
+// Bootstrap registers the handler routes in the server.
+// It must be registered before the handlers rendering the sign in page.
+func (h *OIDCHandler) Bootstrap(router *gin.Engine) {
+	h.logger.Info("Bootstrapping oidc handler")
+
+	// advertise the provider in the sign in pages.
+	router.Use(func(c *gin.Context) {
+		c.Set(util.IdentityProviderKey, h.provider.Name())
+	})
+
+	// oidc routes.
+	h.group = router.Group(h.path)
+	h.group.GET(OIDCLoginRoute, h.performLogin)
+	h.group.GET(OIDCCallbackRoute, h.performCallback)
+}
+
+// performLogin sends the user to the identity provider.
+func (h *OIDCHandler) performLogin(c *gin.Context) {
+	state, err := randomString()
+	if err != nil {
+		util.Logger(c, h.logger).Error(err)
+		h.renderError(c, "It seems we messed up somehow, please try again.")
+		return
+	}
+	nonce, err := randomString()
+	if err != nil {
+		util.Logger(c, h.logger).Error(err)
+		h.renderError(c, "It seems we messed up somehow, please try again.")
+		return
+	}
+
+	// remember the sign in attempt until the provider sends the user back.
+	v := url.Values{}
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("next", redirectTarget(c.Query("next")))
+	util.SetCookie(c, oidcStateCookie, v.Encode(), 600, h.path)
+
+	c.Redirect(http.StatusFound, h.provider.AuthCodeURL(state, nonce))
+}
+
+// performCallback signs the user in with the identity returned by the provider.
+func (h *OIDCHandler) performCallback(c *gin.Context) {
+	// check the sign in attempt.
+	cookie, err := c.Cookie(oidcStateCookie)
+	util.SetCookie(c, oidcStateCookie, "", -1, h.path)
+	v, _ := url.ParseQuery(cookie)
+	if err != nil || v.Get("state") == "" || v.Get("state") != c.Query("state") {
+		h.renderError(c, "The sign in attempt has expired, please try again.")
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		util.Logger(c, h.logger).WithFields(log.Fields{"error": e, "description": c.Query("error_description")}).Warn("identity provider refused the sign in")
+		h.renderError(c, "The identity provider refused the sign in.")
+		return
+	}
+
+	// verify the identity of the user.
+	identity, err := h.provider.Exchange(c.Request.Context(), c.Query("code"), v.Get("nonce"))
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"step": "exchange"}).Error(err)
+		h.renderError(c, "It seems we messed up somehow, please try again.")
+		return
+	}
+	if !identity.EmailVerified {
+		h.renderError(c, "Your email has not been verified by the identity provider.")
+		return
+	}
+
+	// find the linked account, or create a new one.
+	u, err := h.users.Find(identity.Email)
+	if err != nil {
+		u, err = h.createUser(c.Request.Context(), identity)
+		if err != nil {
+			util.Logger(c, h.logger).WithFields(log.Fields{"email": identity.Email, "step": "create"}).Error(err)
+			h.renderError(c, walletError(err, "It seems we messed up somehow, please try again.").Message)
+			return
+		}
+	} else if u.IdentitySubject == "" {
+		// link the existing account to the identity.
+		u.IdentityIssuer = identity.Issuer
+		u.IdentitySubject = identity.Subject
+		if err := h.users.Save(u); err != nil {
+			util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email, "step": "link"}).Error(err)
+			h.renderError(c, "It seems we messed up somehow, please try again.")
+			return
+		}
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email, "issuer": identity.Issuer}).Info("account linked to identity")
+	} else if u.IdentityIssuer != identity.Issuer || u.IdentitySubject != identity.Subject {
+		util.Logger(c, h.logger).WithFields(log.Fields{"email": u.Email, "issuer": identity.Issuer}).Warn("account linked to a different identity")
+		h.renderError(c, "This account is linked to a different identity.")
+		return
+	}
+
+	// check if the account has been disabled.
+	if u.Disabled {
+		h.renderError(c, "This account has been disabled.")
+		return
+	}
+
+	// log the user in.
+	h.auth.AddSession(c, u.Email)
+
+	next := v.Get("next")
+	if next == "" {
+		next = IndexRoute
+	}
+	c.Redirect(http.StatusFound, next)
+}
+
+// createUser creates the account and wallet of a user signing in for the first time.
+func (h *OIDCHandler) createUser(ctx context.Context, identity coin.Identity) (coin.User, error) {
+	// create a user wallet and airdrop the users some tokens, later if the wallet provider is unavailable.
+	w, deferred, err := createWallet(ctx, h.wallets, identity.Username, h.airdrop)
+	if err != nil {
+		return coin.User{}, err
+	}
+
+	user := coin.User{
+		Email:           identity.Email,
+		Username:        identity.Username,
+		Wallet:          w,
+		Role:            coin.DefaultRole,
+		IdentityIssuer:  identity.Issuer,
+		IdentitySubject: identity.Subject,
+	}
+	if err := h.users.Add(user); err != nil {
+		return coin.User{}, err
+	}
+	h.events.SignedUp()
+
+	// create the wallet once the wallet provider recovers.
+	if deferred {
+		if err := deferSignUp(h.queue, user, h.airdrop); err != nil {
+			h.logger.WithContext(ctx).WithFields(log.Fields{"email": user.Email, "step": "defer"}).Error(err)
+		}
+	}
+
+	h.logger.WithContext(ctx).WithFields(log.Fields{"email": user.Email, "issuer": identity.Issuer}).Info("account created from identity")
+	return user, nil
+}
+
+// renderError renders the sign in page with an error.
+func (h *OIDCHandler) renderError(c *gin.Context, message string) {
+	util.Render(c, util.RequestError{
+		Title:   "Failed!",
+		Message: message,
+	}.Render(), SignInPage)
+}
+
+// randomString returns a random value for the sign in state.
+func randomString() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// IdentityProvider defines the interface to interact with an external identity provider.
+type IdentityProvider interface {
+	Name() string
+	AuthCodeURL(state, nonce string) string
+	Exchange(ctx context.Context, code, nonce string) (coin.Identity, error)
+}
@@ -0,0 +1,209 @@
+package handlers_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/openid/openidtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// callback is the address the identity provider sends the users back to.
+const callback = "http://treasure.coin/auth/oidc/callback"
+
+// NewOIDCHandler returns the oidc handler of the server, signing the users in with the provider.
+func NewOIDCHandler(t *testing.T, s *Server, p *openidtest.Provider, wallets *Wallet, events *Events) *handlers.OIDCHandler {
+	client, err := p.Client(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handlers.NewOIDCHandler(s.Auth, client, s.DB.UserService(), wallets, &Queue{}, events, coin.Coin.Mul(10))
+}
+
+// SignInOIDC signs in with the provider, returning the response to the callback.
+func SignInOIDC(t *testing.T, s *Server, p *openidtest.Provider, next string) *http.Response {
+	w := s.Do(NewRequest(http.MethodGet, "/auth/oidc/login?next="+url.QueryEscape(next), nil), "")
+	assert.Equal(t, http.StatusFound, w.Code)
+	state := w.Result().Cookies()
+
+	// sign in at the provider, stopping at the redirect back to the callback.
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	req := NewRequest(http.MethodGet, resp.Header.Get("Location"), nil)
+	for _, c := range state {
+		req.AddCookie(c)
+	}
+	return s.Do(req, "").Result()
+}
+
+// session returns the session token set by the response, if any.
+func session(resp *http.Response) string {
+	for _, c := range resp.Cookies() {
+		if c.Name == middlewares.TokenCookie {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// TestOIDCHandler_Callback tests signing in with the identity provider.
+func TestOIDCHandler_Callback(t *testing.T) {
+	tests := map[string]struct {
+		user   *coin.User
+		claims map[string]interface{}
+
+		location string
+		message  string
+		subject  string
+	}{
+		"first login": {
+			location: "/me",
+			subject:  "luffy",
+		},
+		"account linking": {
+			user:     &coin.User{Email: "luffy@treasure.coin", Username: "luffy", Wallet: "wallet-luffy"},
+			location: "/me",
+			subject:  "luffy",
+		},
+		"linked account": {
+			user:     &coin.User{Email: "luffy@treasure.coin", Username: "luffy", Wallet: "wallet-luffy", IdentitySubject: "luffy"},
+			location: "/me",
+			subject:  "luffy",
+		},
+		"subject mismatch": {
+			user:    &coin.User{Email: "luffy@treasure.coin", Username: "luffy", Wallet: "wallet-luffy", IdentitySubject: "zoro"},
+			message: "This account is linked to a different identity.",
+			subject: "zoro",
+		},
+		"unverified email": {
+			claims:  map[string]interface{}{"email_verified": false},
+			message: "Your email has not been verified by the identity provider.",
+		},
+		"disabled account": {
+			user:    &coin.User{Email: "luffy@treasure.coin", Username: "luffy", Wallet: "wallet-luffy", Disabled: true},
+			message: "This account has been disabled.",
+			subject: "luffy",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := openidtest.NewProvider()
+			defer p.Close()
+			for k, v := range tc.claims {
+				p.Claims[k] = v
+			}
+
+			s := NewServer(t)
+			wallets, events := NewWallet(), &Events{}
+			if tc.user != nil {
+				u := *tc.user
+				if u.IdentitySubject != "" {
+					u.IdentityIssuer = p.URL
+				}
+				s.AddUser(t, u, "meat")
+			}
+			s.Bootstrap(NewOIDCHandler(t, s, p, wallets, events))
+
+			resp := SignInOIDC(t, s, p, "/me")
+			if tc.location != "" {
+				assert.Equal(t, http.StatusFound, resp.StatusCode)
+				assert.Equal(t, tc.location, resp.Header.Get("Location"))
+				assert.NotEmpty(t, session(resp))
+			} else {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Contains(t, string(body), tc.message)
+				assert.Empty(t, session(resp))
+			}
+
+			u, err := s.DB.UserService().Find("luffy@treasure.coin")
+			if tc.subject == "" {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.subject, u.IdentitySubject)
+			if tc.user == nil {
+				assert.Equal(t, p.URL, u.IdentityIssuer)
+				assert.Empty(t, u.Password)
+				assert.Equal(t, coin.Coin.Mul(10), wallets.Balance(u.Wallet))
+				assert.Equal(t, 1, events.SignUps())
+			} else {
+				assert.Equal(t, 0, events.SignUps())
+			}
+		})
+	}
+}
+
+// TestOIDCHandler_State tests the callback is refused without the state of a sign in started by the user.
+func TestOIDCHandler_State(t *testing.T) {
+	p := openidtest.NewProvider()
+	defer p.Close()
+	s := NewServer(t)
+	s.Bootstrap(NewOIDCHandler(t, s, p, NewWallet(), &Events{}))
+
+	w := s.Do(NewRequest(http.MethodGet, "/auth/oidc/callback?code="+openidtest.Code+"&state=forged", nil), "")
+	assert.Contains(t, w.Body.String(), "The sign in attempt has expired, please try again.")
+	_, err := s.DB.UserService().Find("luffy@treasure.coin")
+	assert.NotNil(t, err)
+}
+
+// TestAccountHandler_Reauthenticate tests the accounts without a password must have signed in recently to change
+// their credentials or delete the account.
+func TestAccountHandler_Reauthenticate(t *testing.T) {
+	requests := map[string]url.Values{
+		"/account/email":    {"email": {"nami@treasure.coin"}},
+		"/account/password": {"password": {"tangerine"}, "confirm_password": {"tangerine"}},
+		"/account/delete":   nil,
+	}
+	for route, form := range requests {
+		t.Run(route, func(t *testing.T) {
+			p := openidtest.NewProvider()
+			defer p.Close()
+			s := NewServer(t)
+			s.Bootstrap(NewOIDCHandler(t, s, p, NewWallet(), &Events{}), NewAccountHandler(s, NewWallet()))
+			s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy", Wallet: "wallet-luffy", IdentityIssuer: p.URL, IdentitySubject: "luffy"}, "")
+
+			// a session started an hour ago.
+			stale := fmt.Sprintf("%d.stale", time.Now().Add(-time.Hour).Unix())
+			assert.Nil(t, s.DB.SessionService().Add(stale, "luffy@treasure.coin"))
+			if form == nil {
+				form = url.Values{}
+			}
+			w := s.Do(NewRequest(http.MethodPost, route, form), stale)
+			assert.Contains(t, w.Body.String(), "Please sign in again to confirm it is you")
+			before, err := s.DB.UserService().Find("luffy@treasure.coin")
+			assert.Nil(t, err)
+			assert.Empty(t, before.Password)
+
+			// signing in again with the provider.
+			fresh := session(SignInOIDC(t, s, p, "/me"))
+			w = s.Do(NewRequest(http.MethodPost, route, form), fresh)
+			assert.NotContains(t, w.Body.String(), "Please sign in again to confirm it is you")
+			assert.NotContains(t, w.Body.String(), "Invalid credentials provided.")
+			after, err := s.DB.UserService().Find("luffy@treasure.coin")
+			switch route {
+			case "/account/email":
+				assert.NotNil(t, err)
+				_, err = s.DB.UserService().Find("nami@treasure.coin")
+				assert.Nil(t, err)
+			case "/account/password":
+				assert.Nil(t, err)
+				assert.NotEmpty(t, after.Password)
+			case "/account/delete":
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
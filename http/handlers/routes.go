@@ -9,9 +9,10 @@ const (
 
 // default routes.
 const (
-	IndexRoute   = "/"
-	ProfileRoute = "/me"
-	AboutRoute   = "/about"
+	IndexRoute              = "/"
+	ProfileRoute            = "/me"
+	ExportTransactionsRoute = "/me/transactions"
+	AboutRoute              = "/about"
 )
 
 // auth pages.
@@ -27,6 +28,12 @@ const (
 	SignOutRoute = "/signout"
 )
 
+// oidc routes.
+const (
+	OIDCLoginRoute    = "/login"
+	OIDCCallbackRoute = "/callback"
+)
+
 // game pages.
 const (
 	CreateGamePage       = "create_game.html"
@@ -43,3 +50,73 @@ const (
 	DescribeTreasureRoute = "/describe/:game/treasure/:treasure"
 	FoundTreasureRoute    = "/found/:game/:treasure"
 )
+
+// event routes.
+const (
+	EventsRoute     = "/events"
+	GameEventsRoute = "/events/:game"
+)
+
+// hook pages.
+const (
+	HooksPage = "webhooks.html"
+)
+
+// hook routes.
+const (
+	ListHooksRoute     = "/"
+	CreateHookRoute    = "/"
+	RemoveHookRoute    = "/:hook/remove"
+	RetryDeliveryRoute = "/:hook/deliveries/:delivery/retry"
+)
+
+// account routes.
+const (
+	UpdateProfileRoute  = "/profile"
+	ChangeEmailRoute    = "/email"
+	ChangePasswordRoute = "/password"
+	ExportAccountRoute  = "/export"
+	DeleteAccountRoute  = "/delete"
+	CreateTokenRoute    = "/tokens"
+	RevokeTokenRoute    = "/tokens/:token/revoke"
+)
+
+// wallet pages.
+const (
+	SendCoinsPage = "send_coins.html"
+)
+
+// wallet routes.
+const (
+	SendCoinsRoute  = "/send"
+	TipCreatorRoute = "/tip/:game"
+)
+
+// webhook routes.
+const (
+	WalletWebhookRoute = "/wallet"
+)
+
+// admin pages.
+const (
+	AdminPage = "admin.html"
+)
+
+// admin routes.
+const (
+	AdminRoute              = "/"
+	AdminUserRoleRoute      = "/users/role"
+	AdminDisableUserRoute   = "/users/disable"
+	AdminEnableUserRoute    = "/users/enable"
+	AdminAirdropRoute       = "/users/airdrop"
+	AdminHideGameRoute      = "/games/:game/hide"
+	AdminShowGameRoute      = "/games/:game/show"
+	AdminResetTreasureRoute = "/games/:game/reset/:treasure"
+)
+
+// health routes.
+const (
+	HealthRoute    = "/healthz"
+	ReadinessRoute = "/readyz"
+	MetricsRoute   = "/metrics"
+)
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// TransferLimits bounds the coins a player can send to other players.
+type TransferLimits struct {
+	// largest single transfer.
+	Max coin.Amount
+	// total sent over the last 24 hours.
+	Daily coin.Amount
+}
+
+// WalletHandler handles the peer-to-peer transfer routes in the server.
+type WalletHandler struct {
+	// custom logger object.
+	logger *log.Entry
+
+	// handler path
+	path string
+
+	// router group.
+	group *gin.RouterGroup
+
+	// middleware for handling user auth.
+	auth *middlewares.AuthMiddleware
+
+	// middleware for loading games.
+	gm *middlewares.GameMiddleware
+
+	// external services.
+	users     UserManager
+	wallets   WalletService
+	transfers TransferTracker
+	history   TransferHistory
+
+	limits TransferLimits
+
+	// serializes the transfers of each wallet, so concurrent requests cannot exceed the daily limit together.
+	mu      sync.Mutex
+	senders map[string]*walletLock
+
+	// transfers sent that could not be tracked, counted in the limits until they are.
+	untracked map[string][]coin.Transfer
+}
+
+// walletLock represents the lock of a wallet and the requests holding or waiting for it.
+type walletLock struct {
+	sync.Mutex
+	requests int
+}
+
+// NewWalletHandler returns a new instance of WalletHandler.
+func NewWalletHandler(auth *middlewares.AuthMiddleware, gm *middlewares.GameMiddleware, users UserManager, wallets WalletService, transfers TransferTracker, history TransferHistory, limits TransferLimits) *WalletHandler {
+	h := &WalletHandler{
+		logger:    log.WithFields(log.Fields{"package": "http", "module": "wallet-handler"}),
+		path:      "/wallet",
+		auth:      auth,
+		gm:        gm,
+		users:     users,
+		wallets:   wallets,
+		transfers: transfers,
+		history:   history,
+		limits:    limits,
+		senders:   make(map[string]*walletLock),
+		untracked: make(map[string][]coin.Transfer),
+	}
+
+	return h
+}
+
+// Bootstrap registers the handler routes in the server.
+func (h *WalletHandler) Bootstrap(router *gin.Engine) {
+	h.logger.Info("Bootstrapping wallet handler")
+
+	// wallet routes.
+	h.group = router.Group(h.path, h.auth.RequireAuth())
+	h.group.GET(SendCoinsRoute, h.auth.RequireScope(coin.ScopeWalletRead), h.showSendPage)
+	h.group.POST(SendCoinsRoute, h.auth.RequireScope(coin.ScopeWalletWrite), h.performSendCoins)
+	h.group.POST(TipCreatorRoute, h.auth.RequireScope(coin.ScopeWalletWrite), h.gm.LoadGame(), h.performTipCreator)
+}
+
+// showSendPage renders the send coins page.
+func (h *WalletHandler) showSendPage(c *gin.Context) {
+	h.render(c, gin.H{"to": c.Query("to")})
+}
+
+// performSendCoins sends coins to another player, once the sender confirms the transfer.
+func (h *WalletHandler) performSendCoins(c *gin.Context) {
+	r := sendCoinsRequest{event: coin.EventTransfer}
+	if err := r.Validate(c, h.users); err != nil {
+		h.render(c, gin.H{"to": r.to, "amount": r.amount}, err.Render())
+		return
+	}
+	h.send(c, r)
+}
+
+// performTipCreator sends coins to the creator of a game, once the sender confirms the tip.
+func (h *WalletHandler) performTipCreator(c *gin.Context) {
+	game := c.MustGet(util.GameKey).(coin.Game)
+
+	creator, err := h.users.Find(game.Creator)
+	if err != nil || creator.Disabled {
+		h.render(c, gin.H{}, util.RequestError{
+			Title:   "Failed!",
+			Message: "The creator of this game can no longer receive tips.",
+		}.Render())
+		return
+	}
+
+	r := sendCoinsRequest{event: coin.EventTip, game: game, recipient: creator}
+	if err := r.Validate(c, h.users); err != nil {
+		h.render(c, gin.H{"game": game, "amount": r.amount}, err.Render())
+		return
+	}
+	h.send(c, r)
+}
+
+// send checks the limits of a validated request and executes it once confirmed.
+func (h *WalletHandler) send(c *gin.Context, r sendCoinsRequest) {
+	user := currentUser(c)
+	data := gin.H{"to": r.recipient.Username, "amount": r.amount}
+	if r.event == coin.EventTip {
+		data["game"] = r.game
+	}
+
+	// check the wallet of the user has been created.
+	if user.Wallet == "" {
+		e := walletError(errWalletPending, "")
+		util.RenderStatus(c, e.Code, h.pageData(c, data, e.Render()), SendCoinsPage)
+		return
+	}
+
+	// check the limits.
+	if e := h.checkLimits(user, r.value); e != nil {
+		h.render(c, data, e.Render())
+		return
+	}
+
+	// ask the sender to confirm the transfer.
+	if !r.confirmed {
+		data["confirm"] = true
+		data["payload"] = gin.H{"to": r.recipient.Username, "amount": r.value, "event": r.event, "confirm": "yes"}
+		h.render(c, data)
+		return
+	}
+
+	// check the limits again with the other transfers of the user on hold until this one is recorded.
+	unlock := h.lock(user.Wallet)
+	defer unlock()
+	h.retrack(c, user.Wallet)
+	if e := h.checkLimits(user, r.value); e != nil {
+		h.render(c, data, e.Render())
+		return
+	}
+
+	// execute the transfer.
+	var tx string
+	var err error
+	if r.event == coin.EventTip {
+		tx, err = h.wallets.Tip(c.Request.Context(), user.Wallet, r.recipient.Wallet, r.value)
+	} else {
+		tx, err = h.wallets.Transfer(c.Request.Context(), user.Wallet, r.recipient.Wallet, r.value)
+	}
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"from": user.Wallet, "to": r.recipient.Wallet}).Error(err)
+		e := walletError(err, "Failed to send the coins, please try again.")
+		util.RenderStatus(c, e.Code, h.pageData(c, data, e.Render()), SendCoinsPage)
+		return
+	}
+
+	// follow the transfer until it settles.
+	transfer := coin.Transfer{
+		ID:        tx,
+		Event:     r.event,
+		Wallet:    user.Wallet,
+		Recipient: r.recipient.Wallet,
+		Amount:    r.value,
+		Game:      r.game.ID,
+	}
+	if err := h.transfers.Track(transfer); err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"transaction": tx, "step": "track"}).Error(err)
+		h.addUntracked(transfer)
+	}
+
+	util.Logger(c, h.logger).WithFields(log.Fields{"from": user.Email, "to": r.recipient.Email, "amount": r.value, "event": r.event}).Info("coins sent")
+	// the wallet of the recipient is not shown to the sender.
+	payload := transfer
+	payload.Recipient = ""
+	h.render(c, gin.H{"payload": payload}, util.RequestSuccess{
+		Title:   "Success!",
+		Message: fmt.Sprintf("%s Coins are on their way to %s.", r.value, r.recipient.Username),
+	}.Render())
+}
+
+// lock locks the transfers of the wallet, returning the function unlocking them.
+func (h *WalletHandler) lock(wallet string) func() {
+	h.mu.Lock()
+	l, ok := h.senders[wallet]
+	if !ok {
+		l = &walletLock{}
+		h.senders[wallet] = l
+	}
+	l.requests++
+	h.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		h.mu.Lock()
+		if l.requests--; l.requests == 0 {
+			delete(h.senders, wallet)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// addUntracked keeps a transfer that could not be tracked, so it is still counted in the limits.
+func (h *WalletHandler) addUntracked(transfer coin.Transfer) {
+	transfer.Status = coin.TransferPending
+	transfer.CreatedDate = time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.untracked[transfer.Wallet] = append(h.untracked[transfer.Wallet], transfer)
+}
+
+// retrack tries again to track the transfers of the wallet that could not be tracked.
+func (h *WalletHandler) retrack(c *gin.Context, wallet string) {
+	h.mu.Lock()
+	pending := h.untracked[wallet]
+	delete(h.untracked, wallet)
+	h.mu.Unlock()
+
+	for _, t := range pending {
+		if err := h.transfers.Track(t); err != nil {
+			util.Logger(c, h.logger).WithFields(log.Fields{"transaction": t.ID, "step": "retrack"}).Error(err)
+			h.mu.Lock()
+			h.untracked[wallet] = append(h.untracked[wallet], t)
+			h.mu.Unlock()
+		}
+	}
+}
+
+// checkLimits checks the amount is within the single and daily transfer limits of the user.
+func (h *WalletHandler) checkLimits(user coin.User, amount coin.Amount) *util.RequestError {
+	if h.limits.Max.Sign() > 0 && amount.Cmp(h.limits.Max) > 0 {
+		return &util.RequestError{
+			Title:   "Failed!",
+			Message: fmt.Sprintf("You can send at most %s Coins at a time.", h.limits.Max),
+		}
+	}
+
+	if h.limits.Daily.Sign() > 0 {
+		sent := h.sentToday(user)
+		if sent.Add(amount).Cmp(h.limits.Daily) > 0 {
+			return &util.RequestError{
+				Title:   "Failed!",
+				Message: fmt.Sprintf("You can send at most %s Coins a day, and have %s Coins left for today.", h.limits.Daily, remaining(h.limits.Daily, sent)),
+			}
+		}
+	}
+	return nil
+}
+
+// sentToday returns the coins the user sent to other players over the last 24 hours, tracked or not.
+func (h *WalletHandler) sentToday(user coin.User) coin.Amount {
+	since := time.Now().Add(-24 * time.Hour)
+
+	transfers := h.history.FindByWallet(user.Wallet)
+	h.mu.Lock()
+	transfers = append(transfers, h.untracked[user.Wallet]...)
+	h.mu.Unlock()
+
+	var sent coin.Amount
+	for _, t := range transfers {
+		if t.Event != coin.EventTransfer && t.Event != coin.EventTip {
+			continue
+		}
+		if t.Status == coin.TransferFailed || t.CreatedDate.Before(since) {
+			continue
+		}
+		sent = sent.Add(t.Amount)
+	}
+	return sent
+}
+
+// remaining returns what is left of the limit, never negative.
+func remaining(limit, used coin.Amount) coin.Amount {
+	if used.Cmp(limit) >= 0 {
+		return 0
+	}
+	return limit.Sub(used)
+}
+
+// render renders the send coins page with the user balance and limits.
+func (h *WalletHandler) render(c *gin.Context, data gin.H, messages ...map[string]interface{}) {
+	util.Render(c, h.pageData(c, data, messages...), SendCoinsPage)
+}
+
+// pageData adds the user balance, the limits and the messages to the page data.
+func (h *WalletHandler) pageData(c *gin.Context, data gin.H, messages ...map[string]interface{}) gin.H {
+	for _, m := range messages {
+		for k, v := range m {
+			data[k] = v
+		}
+	}
+
+	user := currentUser(c)
+	if user.Wallet != "" {
+		if balance, err := h.wallets.GetUserBalance(c.Request.Context(), user.Wallet); err == nil {
+			data["balance"] = balance
+		}
+	}
+	data["limits"] = gin.H{
+		"max":   h.limits.Max,
+		"daily": h.limits.Daily,
+		"left":  remaining(h.limits.Daily, h.sentToday(user)),
+	}
+	return data
+}
+
+/**
+ * Requests
+ */
+
+// sendCoinsRequest represents the form data of a performSendCoins or performTipCreator request.
+type sendCoinsRequest struct {
+	event string
+	game  coin.Game
+
+	to        string
+	amount    string
+	confirmed bool
+
+	recipient coin.User
+	value     coin.Amount
+}
+
+// Validate validates a sendCoinsRequest request, looking up the recipient by username unless already set.
+func (r *sendCoinsRequest) Validate(c *gin.Context, users UserManager) *util.RequestError {
+	user := currentUser(c)
+	r.to = c.PostForm("to")
+	r.amount = c.PostForm("amount")
+	r.confirmed = c.PostForm("confirm") == "yes"
+
+	// validate the recipient.
+	if r.recipient.Email == "" {
+		if r.to == "" {
+			return &util.RequestError{
+				Title:   "Failed!",
+				Message: "Please provide the username of the player to send the coins to.",
+			}
+		}
+		found := users.FindByUsername(r.to)
+		switch {
+		case len(found) == 0:
+			return &util.RequestError{
+				Title:   "Failed!",
+				Message: "There is no player with that username.",
+			}
+		case len(found) > 1:
+			return &util.RequestError{
+				Title:   "Failed!",
+				Message: "Several players share that username, ask them to pick a unique one.",
+			}
+		}
+		r.recipient = found[0]
+	}
+	if r.recipient.Email == user.Email {
+		return &util.RequestError{
+			Title:   "Failed!",
+			Message: "You cannot send coins to yourself.",
+		}
+	}
+	if r.recipient.Disabled || r.recipient.Wallet == "" {
+		return &util.RequestError{
+			Title:   "Failed!",
+			Message: "That player cannot receive coins.",
+		}
+	}
+
+	// validate the amount.
+	value, err := coin.ParseAmount(r.amount)
+	if err != nil || value.Sign() <= 0 {
+		return &util.RequestError{
+			Title:   "Failed!",
+			Message: "Please provide a valid amount of coins.",
+		}
+	}
+	r.value = value
+
+	return nil
+}
+
+// TransferHistory defines the interface to retrieve the transfers sent by a wallet.
+type TransferHistory interface {
+	FindByWallet(wallet string) []coin.Transfer
+}
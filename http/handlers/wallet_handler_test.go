@@ -0,0 +1,198 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+// Transfers is an in-memory transfer tracker, also serving the history of the transfers sent.
+type Transfers struct {
+	mu      sync.Mutex
+	tracked []coin.Transfer
+
+	// Err fails the tracking when set.
+	Err error
+}
+
+// Tracked returns the transfers tracked.
+func (t *Transfers) Tracked() []coin.Transfer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]coin.Transfer(nil), t.tracked...)
+}
+
+// SetErr changes the error failing the tracking.
+func (t *Transfers) SetErr(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Err = err
+}
+
+func (t *Transfers) Track(transfer coin.Transfer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Err != nil {
+		return t.Err
+	}
+	transfer.Status = coin.TransferPending
+	transfer.CreatedDate = time.Now()
+	t.tracked = append(t.tracked, transfer)
+	return nil
+}
+func (t *Transfers) Update(transfer coin.Transfer) error { return nil }
+func (t *Transfers) FindByWallet(wallet string) []coin.Transfer {
+	var found []coin.Transfer
+	for _, tr := range t.Tracked() {
+		if tr.Wallet == wallet {
+			found = append(found, tr)
+		}
+	}
+	return found
+}
+
+// NewWalletServer returns a server signed in as luffy, who has 10 coins to send to zoro.
+func NewWalletServer(t *testing.T, limits handlers.TransferLimits) (*Server, string, *Wallet, *Transfers) {
+	s := NewServer(t)
+	session := s.AddUser(t, coin.User{Email: "luffy@treasure.coin", Username: "luffy", Wallet: "wallet-luffy"}, "meat")
+	s.AddUser(t, coin.User{Email: "zoro@treasure.coin", Username: "zoro", Wallet: "wallet-zoro"}, "sword")
+
+	wallets, transfers := NewWallet(), &Transfers{}
+	assert.Nil(t, wallets.Airdrop(context.Background(), "wallet-luffy", coin.Coin.Mul(10)))
+	s.Bootstrap(handlers.NewWalletHandler(s.Auth, s.Games, s.DB.UserService(), wallets, transfers, transfers, limits))
+	return s, session, wallets, transfers
+}
+
+// send returns the form sending the coins to the player, confirmed or not.
+func send(to string, coins int64, confirmed bool) url.Values {
+	form := url.Values{"to": {to}, "amount": {coin.Coin.Mul(coins).String()}}
+	if confirmed {
+		form.Set("confirm", "yes")
+	}
+	return form
+}
+
+// TestWalletHandler_Send tests sending coins to another player.
+func TestWalletHandler_Send(t *testing.T) {
+	tests := map[string]struct {
+		limits handlers.TransferLimits
+		// coins sent earlier today.
+		sent int64
+		form url.Values
+
+		message string
+		balance int64
+	}{
+		"confirmation": {
+			form:    send("zoro", 1, false),
+			message: "Send <strong>1 Coins</strong> to <strong>zoro</strong>? Transfers cannot be undone.",
+			balance: 10,
+		},
+		"confirmed": {
+			form:    send("zoro", 1, true),
+			message: "1 Coins are on their way to zoro.",
+			balance: 9,
+		},
+		"self": {
+			form:    send("luffy", 1, true),
+			message: "You cannot send coins to yourself.",
+			balance: 10,
+		},
+		"duplicate usernames": {
+			form:    send("sanji", 1, true),
+			message: "Several players share that username, ask them to pick a unique one.",
+			balance: 10,
+		},
+		"unknown player": {
+			form:    send("buggy", 1, true),
+			message: "There is no player with that username.",
+			balance: 10,
+		},
+		"invalid amount": {
+			form:    url.Values{"to": {"zoro"}, "amount": {"-1"}, "confirm": {"yes"}},
+			message: "Please provide a valid amount of coins.",
+			balance: 10,
+		},
+		"single limit": {
+			limits:  handlers.TransferLimits{Max: coin.Coin.Mul(2)},
+			form:    send("zoro", 3, false),
+			message: "You can send at most 2 Coins at a time.",
+			balance: 10,
+		},
+		"daily limit": {
+			limits:  handlers.TransferLimits{Daily: coin.Coin.Mul(5)},
+			sent:    4,
+			form:    send("zoro", 2, true),
+			message: "You can send at most 5 Coins a day, and have 1 Coins left for today.",
+			balance: 10,
+		},
+		"within the daily limit": {
+			limits:  handlers.TransferLimits{Daily: coin.Coin.Mul(5)},
+			sent:    4,
+			form:    send("zoro", 1, true),
+			message: "1 Coins are on their way to zoro.",
+			balance: 9,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, session, wallets, transfers := NewWalletServer(t, tc.limits)
+			s.AddUser(t, coin.User{Email: "sanji@treasure.coin", Username: "sanji", Wallet: "wallet-sanji"}, "cook")
+			s.AddUser(t, coin.User{Email: "vinsmoke@treasure.coin", Username: "sanji", Wallet: "wallet-vinsmoke"}, "cook")
+			if tc.sent > 0 {
+				transfers.Track(coin.Transfer{ID: "earlier", Event: coin.EventTransfer, Wallet: "wallet-luffy", Amount: coin.Coin.Mul(tc.sent)})
+			}
+
+			w := s.Do(NewRequest(http.MethodPost, "/wallet/send", tc.form), session)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), tc.message)
+			assert.Equal(t, coin.Coin.Mul(tc.balance), wallets.Balance("wallet-luffy"))
+			assert.Equal(t, coin.Coin.Mul(10-tc.balance), wallets.Balance("wallet-zoro"))
+		})
+	}
+}
+
+// TestWalletHandler_SendConcurrently tests concurrent transfers cannot exceed the daily limit together.
+func TestWalletHandler_SendConcurrently(t *testing.T) {
+	s, session, wallets, transfers := NewWalletServer(t, handlers.TransferLimits{Daily: coin.Coin.Mul(5)})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Do(NewRequest(http.MethodPost, "/wallet/send", send("zoro", 1, true)), session)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, coin.Coin.Mul(5), wallets.Balance("wallet-zoro"))
+	assert.Len(t, transfers.Tracked(), 5)
+}
+
+// TestWalletHandler_SendUntracked tests the transfers that could not be tracked still count in the daily limit,
+// and are tracked with the next transfer.
+func TestWalletHandler_SendUntracked(t *testing.T) {
+	s, session, wallets, transfers := NewWalletServer(t, handlers.TransferLimits{Daily: coin.Coin.Mul(5)})
+
+	transfers.SetErr(coin.Error("database is down"))
+	w := s.Do(NewRequest(http.MethodPost, "/wallet/send", send("zoro", 4, true)), session)
+	assert.Contains(t, w.Body.String(), "4 Coins are on their way to zoro.")
+	assert.Empty(t, transfers.Tracked())
+
+	w = s.Do(NewRequest(http.MethodPost, "/wallet/send", send("zoro", 2, true)), session)
+	assert.Contains(t, w.Body.String(), "You can send at most 5 Coins a day, and have 1 Coins left for today.")
+
+	transfers.SetErr(nil)
+	w = s.Do(NewRequest(http.MethodPost, "/wallet/send", send("zoro", 1, true)), session)
+	assert.Contains(t, w.Body.String(), "1 Coins are on their way to zoro.")
+	assert.Len(t, transfers.Tracked(), 2)
+	assert.Equal(t, coin.Coin.Mul(5), wallets.Balance("wallet-zoro"))
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxWebhookSize bounds the body of the webhooks read.
+const maxWebhookSize = 1 << 20
+
+// WebhookHandler handles the webhooks sent by the wallet provider.
+type WebhookHandler struct {
+	// custom logger object.
+	logger *log.Entry
+
+	// handler path
+	path string
+
+	// router group.
+	group *gin.RouterGroup
+
+	// external services.
+	webhooks  WebhookParser
+	transfers TransferTracker
+}
+
+// NewWebhookHandler returns a new instance of WebhookHandler.
+func NewWebhookHandler(webhooks WebhookParser, transfers TransferTracker) *WebhookHandler {
+	h := &WebhookHandler{
+		logger:    log.WithFields(log.Fields{"package": "http", "module": "webhook-handler"}),
+		path:      "/webhooks",
+		webhooks:  webhooks,
+		transfers: transfers,
+	}
+
+	return h
+}
+
+// Bootstrap registers the handler routes in the server.
+func (h *WebhookHandler) Bootstrap(router *gin.Engine) {
+	h.logger.Info("Bootstrapping webhook handler")
+
+	// webhook routes.
+	h.group = router.Group(h.path)
+	h.group.POST(WalletWebhookRoute, h.performWalletWebhook)
+}
+
+// performWalletWebhook settles the transfer reported by the wallet provider.
+func (h *WebhookHandler) performWalletWebhook(c *gin.Context) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookSize))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "the webhook is too large"})
+		return
+	}
+
+	// verify the webhook.
+	update, err := h.webhooks.ParseWebhook(c.Request.Header, body)
+	if errors.Is(err, coin.ErrInvalidWebhook) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		util.Logger(c, h.logger).WithFields(log.Fields{"error": err}).Warn("failed to decode webhook")
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "the webhook is malformed"})
+		return
+	}
+
+	// events other than transfers are acknowledged and ignored.
+	if update.ID != "" {
+		if err := h.transfers.Update(update); err != nil {
+			// the provider retries the webhooks not acknowledged.
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "the transfer could not be updated"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
+
+// WebhookParser defines the interface to verify and decode the webhooks of the wallet provider.
+type WebhookParser interface {
+	ParseWebhook(header http.Header, body []byte) (coin.Transfer, error)
+}
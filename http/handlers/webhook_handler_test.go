@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/handlers"
+	"github.com/pmdcosta/treasure-coin/ost/osttest"
+	"github.com/pmdcosta/treasure-coin/tracker"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWebhookHandler tests settling the payment of a game with the webhooks of the wallet provider.
+func TestWebhookHandler(t *testing.T) {
+	tests := map[string]struct {
+		topic  string
+		status string
+		tamper bool
+
+		code    int
+		payment coin.TransferStatus
+	}{
+		"success":       {topic: "transactions/success", status: "SUCCESS", code: http.StatusOK, payment: coin.TransferComplete},
+		"failure":       {topic: "transactions/failure", status: "FAILED", code: http.StatusOK, payment: coin.TransferFailed},
+		"other event":   {topic: "users/activation_success", code: http.StatusOK, payment: coin.TransferPending},
+		"tampered body": {topic: "transactions/success", status: "SUCCESS", tamper: true, code: http.StatusUnauthorized, payment: coin.TransferPending},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := osttest.NewServer()
+			defer o.Close()
+
+			s := NewServer(t)
+			tr := tracker.NewTracker(s.DB.TransferService(), s.DB.GameService(), o.Client())
+			s.Bootstrap(handlers.NewWebhookHandler(o.Client(), tr))
+
+			// a game waiting for its payment.
+			id, err := s.DB.GameService().Add(coin.Game{Title: "Grand Line", Creator: "luffy@treasure.coin", Transaction: osttest.Transaction, Payment: coin.TransferPending})
+			assert.Nil(t, err)
+			assert.Nil(t, tr.Track(coin.Transfer{ID: osttest.Transaction, Event: coin.EventGameCreated, Game: id}))
+
+			transaction := osttest.Transaction
+			if tc.status == "" {
+				transaction = ""
+			}
+			h, body := osttest.Webhook(tc.topic, transaction, tc.status)
+			if tc.tamper {
+				body = append(body, ' ')
+			}
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/wallet", bytes.NewReader(body))
+			req.Header = h
+			w := s.Do(req, "")
+			assert.Equal(t, tc.code, w.Code)
+
+			g, err := s.DB.GameService().Find(id)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.payment, g.Payment)
+		})
+	}
+}
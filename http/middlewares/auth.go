@@ -1,6 +1,12 @@
 package middlewares
 
 import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/pmdcosta/treasure-coin"
 	"github.com/pmdcosta/treasure-coin/http/util"
@@ -17,31 +23,45 @@ type AuthMiddleware struct {
 	// external services.
 	users    UserManager
 	sessions SessionManager
+	tokens   TokenManager
 }
 
 // NewAuthMiddleware returns a new instance of the auth middleware handler.
-func NewAuthMiddleware(users UserManager, sessions SessionManager) *AuthMiddleware {
+func NewAuthMiddleware(users UserManager, sessions SessionManager, tokens TokenManager) *AuthMiddleware {
 	m := &AuthMiddleware{
 		logger:   log.WithFields(log.Fields{"package": "http", "module": "auth-middleware"}),
 		users:    users,
 		sessions: sessions,
+		tokens:   tokens,
 	}
 	return m
 }
 
 // SetUserStatus sets whether the user is logged in or not.
+// Requests carrying an API token are authenticated by the token instead of the session cookie.
 func (m AuthMiddleware) SetUserStatus() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if secret, ok := bearerToken(c); ok {
+			if user, token, ok := m.tokenUser(secret); ok {
+				c.Set(util.LogInCookie, true)
+				c.Set(util.UserCookie, user)
+				c.Set(util.APITokenKey, token)
+				return
+			}
+			c.Set(util.LogInCookie, false)
+			return
+		}
+
 		if token, err := c.Cookie(TokenCookie); err == nil && token != "" {
 			// get the user id from the session.
 			s, _ := m.sessions.Find(token)
 
 			// get user data from the db.
 			user, err := m.users.Find(s)
-			if (err == nil && user != coin.User{}) {
+			if err == nil && user.Email != "" && !user.Disabled {
 				c.Set(util.LogInCookie, true)
 				c.Set(util.UserCookie, user)
-				m.logger.WithFields(log.Fields{"token": token, "session": s, "user": user.Email}).Debug("current session")
+				util.Logger(c, m.logger).WithFields(log.Fields{"session": s, "user": user.Email}).Debug("current session")
 				return
 			}
 		}
@@ -49,30 +69,120 @@ func (m AuthMiddleware) SetUserStatus() gin.HandlerFunc {
 	}
 }
 
+// RequireAuth aborts requests from anonymous users.
+// Page requests are redirected to the sign in page and sent back once the user signs in.
+func (m AuthMiddleware) RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get(util.UserCookie); !exists {
+			m.abortAnonymous(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole aborts requests from users without at least the supplied role.
+func (m AuthMiddleware) RequireRole(role coin.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get(util.UserCookie)
+		if !exists {
+			m.abortAnonymous(c)
+			return
+		}
+
+		if !user.(coin.User).HasRole(role) {
+			util.Logger(c, m.logger).WithFields(log.Fields{"user": user.(coin.User).Email, "role": role}).Warn("access denied")
+			util.Abort(c, util.RequestError{
+				Code:    http.StatusForbidden,
+				Title:   "Failed!",
+				Message: "You do not have permission to access this page.",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// abortAnonymous stops a request that requires a logged in user.
+func (m AuthMiddleware) abortAnonymous(c *gin.Context) {
+	if c.Request.Method == http.MethodGet && !util.WantsJSON(c) {
+		c.Redirect(http.StatusFound, util.SignInURL+"?next="+url.QueryEscape(c.Request.URL.RequestURI()))
+		c.Abort()
+		return
+	}
+	util.Abort(c, util.RequestError{
+		Code:    http.StatusUnauthorized,
+		Page:    util.SignInPage,
+		Title:   "Failed!",
+		Message: "Requires a logged in user.",
+	})
+}
+
 // AddSession adds a new active session.
 func (m *AuthMiddleware) AddSession(c *gin.Context, user string) {
 	t := CreateSessionToken()
-	m.logger.WithFields(log.Fields{"token": t, "user": user}).Debug("creating sessions")
-	c.SetCookie(TokenCookie, t, 3600, "", "", false, true)
+	util.Logger(c, m.logger).WithFields(log.Fields{"user": user}).Debug("creating sessions")
+	util.SetCookie(c, TokenCookie, t, 3600, "")
 	c.Set(util.LogInCookie, true)
 	m.sessions.Add(t, user)
-	m.logger.WithFields(log.Fields{"user": user, "token": t}).Info("user signing in")
+	util.Logger(c, m.logger).WithFields(log.Fields{"user": user}).Info("user signing in")
 }
 
 // RemoveSession removes a new active session.
 func (m *AuthMiddleware) RemoveSession(c *gin.Context) {
-	c.SetCookie(TokenCookie, "", -1, "", "", false, true)
+	util.SetCookie(c, TokenCookie, "", -1, "")
 	c.Set(util.LogInCookie, false)
 	if token, err := c.Cookie(TokenCookie); err == nil || token != "" {
 		m.sessions.Remove(token)
-		m.logger.WithFields(log.Fields{"token": token}).Debug("removing sessions")
+		util.Logger(c, m.logger).Debug("removing sessions")
 	}
 }
 
+// UserSessions returns the active session tokens of a user.
+func (m *AuthMiddleware) UserSessions(user string) []string {
+	return m.sessions.FindByUser(user)
+}
+
+// RemoveUserSessions revokes every active session of a user.
+func (m *AuthMiddleware) RemoveUserSessions(user string) error {
+	m.logger.WithFields(log.Fields{"user": user}).Info("revoking user sessions")
+	return m.sessions.RemoveByUser(user)
+}
+
+// Reauthenticated returns whether the user signed in to the session of the request within the duration.
+// It confirms the user is still the one who signed in when there is no password to ask for.
+func (m *AuthMiddleware) Reauthenticated(c *gin.Context, within time.Duration) bool {
+	if _, exists := c.Get(util.APITokenKey); exists {
+		return false
+	}
+	if _, exists := c.Get(util.UserCookie); !exists {
+		return false
+	}
+	token, err := c.Cookie(TokenCookie)
+	if err != nil {
+		return false
+	}
+	return time.Since(sessionDate(token)) <= within
+}
+
 // CreateSessionToken generate a new session token to store in the cookie.
+// Tokens start with the time they were created, so the age of a session is known from its token.
 func CreateSessionToken() string {
 	token, _ := uuid.NewV4()
-	return token.String()
+	return strconv.FormatInt(time.Now().Unix(), 10) + "." + token.String()
+}
+
+// sessionDate returns when a session token was created, or the zero time for tokens created before they carried it.
+func sessionDate(token string) time.Time {
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return time.Time{}
+	}
+	sec, err := strconv.ParseInt(token[:i], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
 }
 
 // UserManager defines the interface to interact with the user persistence layer.
@@ -85,4 +195,6 @@ type SessionManager interface {
 	Add(token, session string) error
 	Find(token string) (string, error)
 	Remove(token string) error
+	FindByUser(session string) []string
+	RemoveByUser(session string) error
 }
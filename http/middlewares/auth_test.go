@@ -1,20 +1,29 @@
 package middlewares_test
 
 import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/database"
 	"github.com/pmdcosta/treasure-coin/http/middlewares"
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 )
 
 //go:generate moq -out user_service_mock.go . UserManager
 //go:generate moq -out session_service_mock.go . SessionManager
+//go:generate moq -out token_service_mock.go . TokenManager
 
 // AuthMiddleware is a test wrapper.
 type AuthMiddleware struct {
 	AuthMiddleware *middlewares.AuthMiddleware
 	Users          *middlewares.UserManagerMock
 	Sessions       *middlewares.SessionManagerMock
+	Tokens         *middlewares.TokenManagerMock
 }
 
 // NewAuthMiddleware returns a new instance of AuthMiddleware.
@@ -22,12 +31,68 @@ func NewAuthMiddleware() *AuthMiddleware {
 	log.SetLevel(log.DebugLevel)
 	u := &middlewares.UserManagerMock{}
 	s := &middlewares.SessionManagerMock{}
+	tk := &middlewares.TokenManagerMock{}
 	m := &AuthMiddleware{
-		AuthMiddleware: middlewares.NewAuthMiddleware(u, s),
+		AuthMiddleware: middlewares.NewAuthMiddleware(u, s, tk),
+		Users:          u,
+		Sessions:       s,
+		Tokens:         tk,
 	}
 	return m
 }
 
+// SignIn makes the session cookie "session" belong to the user.
+func (m *AuthMiddleware) SignIn(user coin.User) {
+	m.Sessions.FindFunc = func(token string) (string, error) {
+		if token == "session" {
+			return user.Email, nil
+		}
+		return "", database.ErrRecordNotFound
+	}
+	m.Users.FindFunc = func(email string) (coin.User, error) {
+		if email == user.Email {
+			return user, nil
+		}
+		return coin.User{}, database.ErrRecordNotFound
+	}
+}
+
+// NewRouter returns a router rendering the request errors, with stub pages printing the error messages.
+func NewRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.SetHTMLTemplate(template.Must(template.New("").Parse(
+		`{{define "index.html"}}index: {{.ErrorMessage}}{{end}}{{define "signin.html"}}signin: {{.ErrorMessage}}{{end}}`,
+	)))
+	router.Use(middlewares.NewErrorMiddleware().HandleErrors())
+	return router
+}
+
+// NewRequest returns a request with the session cookie, if set.
+func NewRequest(method, target, session string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	if session != "" {
+		req.AddCookie(&http.Cookie{Name: middlewares.TokenCookie, Value: session})
+	}
+	return req
+}
+
+// Do sends a request to the router.
+func Do(router *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// Serve sends a request to the router, with the session cookie and the Accept header if set.
+func Serve(router *gin.Engine, method, target, session, accept string) *httptest.ResponseRecorder {
+	req := NewRequest(method, target, session)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return Do(router, req)
+}
+
 // TestAuthMiddleware_Create tests creating a new auth middleware.
 func TestAuthMiddleware_Create(t *testing.T) {
 	m := NewAuthMiddleware()
@@ -36,4 +101,45 @@ func TestAuthMiddleware_Create(t *testing.T) {
 	}
 }
 
-// TODO add more tests.
+// TestAuthMiddleware_RequireAuth tests anonymous page requests are sent to the sign in page and back,
+// and other anonymous requests are refused.
+func TestAuthMiddleware_RequireAuth(t *testing.T) {
+	m := NewAuthMiddleware()
+	m.SignIn(coin.User{Email: "luffy@treasure.coin"})
+
+	router := NewRouter()
+	router.Use(m.AuthMiddleware.SetUserStatus())
+	handler := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	router.GET("/games/describe/:game", m.AuthMiddleware.RequireAuth(), handler)
+	router.POST("/games/create", m.AuthMiddleware.RequireAuth(), handler)
+
+	w := Serve(router, http.MethodGet, "/games/describe/1?tab=treasures", "", "")
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/signin?next=%2Fgames%2Fdescribe%2F1%3Ftab%3Dtreasures", w.Header().Get("Location"))
+
+	w = Serve(router, http.MethodGet, "/games/describe/1", "unknown", "application/json")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error": {"title": "Failed!", "message": "Requires a logged in user."}}`, w.Body.String())
+
+	w = Serve(router, http.MethodPost, "/games/create", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "signin: Requires a logged in user.", w.Body.String())
+
+	w = Serve(router, http.MethodGet, "/games/describe/1", "session", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = Serve(router, http.MethodPost, "/games/create", "session", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestAuthMiddleware_RequireAuthDisabled tests the sessions of disabled users are anonymous.
+func TestAuthMiddleware_RequireAuthDisabled(t *testing.T) {
+	m := NewAuthMiddleware()
+	m.SignIn(coin.User{Email: "luffy@treasure.coin", Disabled: true})
+
+	router := NewRouter()
+	router.Use(m.AuthMiddleware.SetUserStatus())
+	router.POST("/games/create", m.AuthMiddleware.RequireAuth(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := Serve(router, http.MethodPost, "/games/create", "session", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
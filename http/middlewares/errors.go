@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrorMiddleware represents a HTTP middleware handler that renders request errors.
+type ErrorMiddleware struct {
+	logger *log.Entry
+}
+
+// NewErrorMiddleware returns a new instance of the error middleware handler.
+func NewErrorMiddleware() *ErrorMiddleware {
+	m := &ErrorMiddleware{
+		logger: log.WithFields(log.Fields{"package": "http", "module": "error-middleware"}),
+	}
+	return m
+}
+
+// HandleErrors renders the last error attached to the request as an HTML page or a JSON document.
+func (m ErrorMiddleware) HandleErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		// nothing to do if the handlers already responded.
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		e, ok := c.Errors.Last().Err.(util.RequestError)
+		if !ok {
+			util.Logger(c, m.logger).WithFields(log.Fields{"route": c.FullPath()}).Error(c.Errors.Last().Err)
+			e = util.RequestError{
+				Code:    http.StatusInternalServerError,
+				Title:   "Failed!",
+				Message: "It seems we messed up somehow, please try again.",
+			}
+		}
+		if e.Code == 0 {
+			e.Code = http.StatusInternalServerError
+		}
+		if e.Page == "" {
+			e.Page = util.IndexPage
+		}
+
+		if util.WantsJSON(c) {
+			c.JSON(e.Code, gin.H{
+				"error": gin.H{
+					"title":   e.Title,
+					"message": e.Message,
+				},
+			})
+			return
+		}
+		util.RenderStatus(c, e.Code, e.Render(), e.Page)
+	}
+}
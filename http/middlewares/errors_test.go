@@ -0,0 +1,49 @@
+package middlewares_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestErrorMiddleware_HandleErrors tests the request errors are rendered as pages or JSON documents.
+func TestErrorMiddleware_HandleErrors(t *testing.T) {
+	router := NewRouter()
+	router.GET("/forbidden", func(c *gin.Context) {
+		util.Abort(c, util.RequestError{Code: http.StatusForbidden, Title: "Failed!", Message: "Not yours."})
+	})
+	router.GET("/signin", func(c *gin.Context) {
+		util.Abort(c, util.RequestError{Code: http.StatusUnauthorized, Page: util.SignInPage, Title: "Failed!", Message: "Sign in."})
+	})
+	router.GET("/internal", func(c *gin.Context) {
+		c.Error(errors.New("database is gone"))
+		c.Abort()
+	})
+	router.GET("/written", func(c *gin.Context) {
+		c.Error(errors.New("logged only"))
+		c.String(http.StatusOK, "done")
+	})
+
+	tests := []struct {
+		target string
+		accept string
+		code   int
+		body   string
+	}{
+		{"/forbidden", "", http.StatusForbidden, "index: Not yours."},
+		{"/forbidden", "application/json", http.StatusForbidden, `{"error":{"message":"Not yours.","title":"Failed!"}}`},
+		{"/signin", "", http.StatusUnauthorized, "signin: Sign in."},
+		{"/internal", "", http.StatusInternalServerError, "index: It seems we messed up somehow, please try again."},
+		{"/internal", "application/json", http.StatusInternalServerError, `{"error":{"message":"It seems we messed up somehow, please try again.","title":"Failed!"}}`},
+		{"/written", "", http.StatusOK, "done"},
+	}
+	for _, tt := range tests {
+		w := Serve(router, http.MethodGet, tt.target, "", tt.accept)
+		assert.Equal(t, tt.code, w.Code, tt.target)
+		assert.Equal(t, tt.body, w.Body.String(), tt.target)
+	}
+}
@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// GameMiddleware represents a HTTP middleware handler for loading and protecting games.
+type GameMiddleware struct {
+	logger *log.Entry
+
+	// external services.
+	games GameFinder
+}
+
+// NewGameMiddleware returns a new instance of the game middleware handler.
+func NewGameMiddleware(games GameFinder) *GameMiddleware {
+	m := &GameMiddleware{
+		logger: log.WithFields(log.Fields{"package": "http", "module": "game-middleware"}),
+		games:  games,
+	}
+	return m
+}
+
+// LoadGame loads the game in the 'game' route parameter into the request context.
+func (m GameMiddleware) LoadGame() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("game")
+
+		game, err := m.games.Find(id)
+		if err != nil || !game.VisibleTo(requestUser(c)) {
+			util.Abort(c, util.RequestError{
+				Code:    http.StatusNotFound,
+				Title:   "Failed!",
+				Message: "Game not found.",
+			})
+			return
+		}
+		game.ID = id
+
+		c.Set(util.GameKey, game)
+		c.Next()
+	}
+}
+
+// LoadTreasure loads the treasure in the 'treasure' route parameter into the request context.
+// It must run after LoadGame.
+func (m GameMiddleware) LoadTreasure() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		game := c.MustGet(util.GameKey).(coin.Game)
+
+		treasure, ok := game.Treasures[c.Param("treasure")]
+		if !ok {
+			util.Abort(c, util.RequestError{
+				Code:    http.StatusNotFound,
+				Title:   "Failed!",
+				Message: "Treasure not found.",
+			})
+			return
+		}
+
+		c.Set(util.TreasureKey, treasure)
+		c.Next()
+	}
+}
+
+// requestUser returns the logged in user of the request.
+func requestUser(c *gin.Context) coin.User {
+	if user, exists := c.Get(util.UserCookie); exists {
+		return user.(coin.User)
+	}
+	return coin.User{}
+}
+
+// GameFinder defines the interface to retrieve games from the persistence layer.
+type GameFinder interface {
+	Find(id string) (coin.Game, error)
+}
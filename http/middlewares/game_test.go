@@ -0,0 +1,67 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// games is an in-memory game store.
+type games map[string]coin.Game
+
+func (s games) Find(id string) (coin.Game, error) {
+	g, ok := s[id]
+	if !ok {
+		return coin.Game{}, database.ErrRecordNotFound
+	}
+	return g, nil
+}
+
+// TestGameMiddleware_Load tests loading the games and treasures of the routes, and hiding the missing and invisible ones.
+func TestGameMiddleware_Load(t *testing.T) {
+	m := NewAuthMiddleware()
+	m.SignIn(coin.User{Email: "nami@treasure.coin"})
+	gm := middlewares.NewGameMiddleware(games{
+		"1": {Title: "Public", Treasures: map[string]coin.Treasure{"gold": {ID: "gold", Name: "Gold"}}},
+		"2": {Title: "Hidden", Creator: "nami@treasure.coin", Hidden: true},
+		"3": {Title: "Pending", Creator: "nami@treasure.coin", Payment: coin.TransferPending},
+	})
+
+	router := NewRouter()
+	router.Use(m.AuthMiddleware.SetUserStatus())
+	router.GET("/games/:game", gm.LoadGame(), func(c *gin.Context) {
+		game := c.MustGet(util.GameKey).(coin.Game)
+		c.String(http.StatusOK, game.ID+" "+game.Title)
+	})
+	router.GET("/games/:game/:treasure", gm.LoadGame(), gm.LoadTreasure(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.MustGet(util.TreasureKey).(coin.Treasure).Name)
+	})
+
+	tests := []struct {
+		target  string
+		session string
+		code    int
+		body    string
+	}{
+		{"/games/1", "", http.StatusOK, "1 Public"},
+		{"/games/1/gold", "", http.StatusOK, "Gold"},
+		{"/games/1/silver", "", http.StatusNotFound, "index: Treasure not found."},
+		{"/games/4", "", http.StatusNotFound, "index: Game not found."},
+		{"/games/4/gold", "", http.StatusNotFound, "index: Game not found."},
+		{"/games/2", "", http.StatusNotFound, "index: Game not found."},
+		{"/games/3", "", http.StatusNotFound, "index: Game not found."},
+		{"/games/2", "session", http.StatusOK, "2 Hidden"},
+		{"/games/3", "session", http.StatusOK, "3 Pending"},
+	}
+	for _, tt := range tests {
+		w := Serve(router, http.MethodGet, tt.target, tt.session, "")
+		assert.Equal(t, tt.code, w.Code, tt.target)
+		assert.Equal(t, tt.body, w.Body.String(), tt.target)
+	}
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// unmatchedRoute labels the requests matching no route, so unknown paths do not create new series.
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware represents a HTTP middleware handler measuring the requests.
+type MetricsMiddleware struct {
+	logger *log.Entry
+
+	// external services.
+	observer RequestObserver
+}
+
+// NewMetricsMiddleware returns a new instance of the metrics middleware handler.
+func NewMetricsMiddleware(observer RequestObserver) *MetricsMiddleware {
+	m := &MetricsMiddleware{
+		logger:   log.WithFields(log.Fields{"package": "http", "module": "metrics-middleware"}),
+		observer: observer,
+	}
+	return m
+}
+
+// ObserveRequests records how long every request took, by route.
+func (m MetricsMiddleware) ObserveRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.observer.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// RequestObserver defines the interface to record the requests handled.
+type RequestObserver interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
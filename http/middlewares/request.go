@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	"github.com/pmdcosta/treasure-coin/logging"
+	"github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries the ID of a request, either set by a proxy in front of the server or generated.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID matches the request IDs accepted from the clients, so they cannot forge log lines.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestMiddleware represents a HTTP middleware handler identifying and logging the requests.
+type RequestMiddleware struct {
+	logger *log.Entry
+}
+
+// NewRequestMiddleware returns a new instance of the request middleware handler.
+func NewRequestMiddleware() *RequestMiddleware {
+	m := &RequestMiddleware{
+		logger: log.WithFields(log.Fields{"package": "http", "module": "request-middleware"}),
+	}
+	return m
+}
+
+// SetRequestID sets the ID of the request in its context and in the response,
+// keeping the one received from a proxy if valid.
+func (m RequestMiddleware) SetRequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			u, _ := uuid.NewV4()
+			id = u.String()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Set(util.RequestIDKey, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// LogRequests logs every request handled.
+// Requests are logged by route rather than by path, as paths carry secrets like the treasure keys.
+func (m RequestMiddleware) LogRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		logger := util.Logger(c, m.logger).WithFields(log.Fields{
+			"method":  c.Request.Method,
+			"route":   route,
+			"status":  c.Writer.Status(),
+			"latency": time.Since(start),
+			"ip":      c.ClientIP(),
+		})
+
+		switch {
+		case c.Writer.Status() >= 500:
+			logger.Error("request failed")
+		case c.Writer.Status() >= 400:
+			logger.Warn("request rejected")
+		default:
+			logger.Info("request handled")
+		}
+	}
+}
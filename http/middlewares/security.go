@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// SecurityMiddleware represents a HTTP middleware handler setting the security headers of the responses.
+type SecurityMiddleware struct {
+	logger *log.Entry
+
+	// how long browsers only reach the server over HTTPS, and what the pages can load.
+	hsts time.Duration
+	csp  string
+}
+
+// NewSecurityMiddleware returns a new instance of the security middleware handler.
+func NewSecurityMiddleware(hsts time.Duration, csp string) *SecurityMiddleware {
+	m := &SecurityMiddleware{
+		logger: log.WithFields(log.Fields{"package": "http", "module": "security-middleware"}),
+		hsts:   hsts,
+		csp:    csp,
+	}
+	return m
+}
+
+// SetHeaders forbids framing the pages and sniffing the content types, and sets the content security policy.
+// HSTS is only set on HTTPS requests, as browsers ignore it over plain HTTP.
+func (m SecurityMiddleware) SetHeaders() gin.HandlerFunc {
+	hsts := "max-age=" + strconv.Itoa(int(m.hsts.Seconds()))
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		if m.csp != "" {
+			h.Set("Content-Security-Policy", m.csp)
+		}
+		if m.hsts > 0 && c.Request.TLS != nil {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
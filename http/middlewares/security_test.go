@@ -0,0 +1,49 @@
+package middlewares_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSecurityMiddleware_SetHeaders tests the security headers, HSTS being only sent over HTTPS.
+func TestSecurityMiddleware_SetHeaders(t *testing.T) {
+	tests := map[string]struct {
+		hsts time.Duration
+		csp  string
+		tls  bool
+
+		sts string
+	}{
+		"https":      {hsts: time.Hour, csp: "default-src 'self'", tls: true, sts: "max-age=3600"},
+		"plain http": {hsts: time.Hour, csp: "default-src 'self'"},
+		"hsts off":   {tls: true},
+		"no policy":  {hsts: 24 * time.Hour, tls: true, sts: "max-age=86400"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := NewRouter()
+			router.Use(middlewares.NewSecurityMiddleware(tc.hsts, tc.csp).SetHeaders())
+			router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			assert.Equal(t, "strict-origin-when-cross-origin", w.Header().Get("Referrer-Policy"))
+			assert.Equal(t, tc.csp, w.Header().Get("Content-Security-Policy"))
+			assert.Equal(t, tc.sts, w.Header().Get("Strict-Transport-Security"))
+		})
+	}
+}
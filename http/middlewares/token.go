@@ -0,0 +1,189 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	"github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// APITokenPrefix prefixes every API token so leaked tokens are easy to recognize.
+const APITokenPrefix = "tc_"
+
+// tokenUsageInterval is how often the last usage date of a token is persisted.
+const tokenUsageInterval = time.Minute
+
+// middleware errors.
+const (
+	ErrInvalidScopes = coin.Error("invalid token scopes")
+	ErrTokenNotFound = coin.Error("token does not exist")
+)
+
+// RequireScope aborts requests authenticated by an API token that was not granted the scope.
+// Requests authenticated by a session cookie are always allowed through.
+func (m AuthMiddleware) RequireScope(scope coin.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, exists := c.Get(util.APITokenKey); exists && !token.(coin.APIToken).HasScope(scope) {
+			util.Abort(c, util.RequestError{
+				Code:    http.StatusForbidden,
+				Title:   "Failed!",
+				Message: "The API token is missing the " + string(scope) + " scope.",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession aborts requests authenticated by an API token.
+// It protects the pages that manage the account itself.
+func (m AuthMiddleware) RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get(util.APITokenKey); exists {
+			util.Abort(c, util.RequestError{
+				Code:    http.StatusForbidden,
+				Title:   "Failed!",
+				Message: "API tokens cannot be used to manage the account.",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CreateToken creates a new API token for the user, returning its secret value.
+// The secret is only stored hashed, so it cannot be shown again.
+func (m *AuthMiddleware) CreateToken(user, name string, scopes []coin.Scope, ttl time.Duration) (string, coin.APIToken, error) {
+	if len(scopes) == 0 {
+		return "", coin.APIToken{}, ErrInvalidScopes
+	}
+	for _, s := range scopes {
+		if !s.Valid() {
+			return "", coin.APIToken{}, ErrInvalidScopes
+		}
+	}
+
+	// generate the secret.
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", coin.APIToken{}, err
+	}
+	secret := APITokenPrefix + hex.EncodeToString(b)
+
+	id, _ := uuid.NewV4()
+	now := time.Now().Truncate(time.Second)
+	token := coin.APIToken{
+		ID:          id.String(),
+		Name:        name,
+		User:        user,
+		Hash:        HashToken(secret),
+		Scopes:      scopes,
+		CreatedDate: now,
+	}
+	if ttl > 0 {
+		token.ExpiryDate = now.Add(ttl)
+	}
+
+	if err := m.tokens.Add(token); err != nil {
+		return "", coin.APIToken{}, err
+	}
+
+	m.logger.WithFields(log.Fields{"user": user, "token_id": token.ID, "scopes": scopes}).Info("api token created")
+	return secret, token, nil
+}
+
+// UserTokens returns the API tokens of a user.
+func (m *AuthMiddleware) UserTokens(user string) []coin.APIToken {
+	return m.tokens.FindByUser(user)
+}
+
+// RevokeToken deletes an API token of the user.
+func (m *AuthMiddleware) RevokeToken(user, id string) error {
+	for _, t := range m.tokens.FindByUser(user) {
+		if t.ID == id {
+			m.logger.WithFields(log.Fields{"user": user, "token_id": id}).Info("api token revoked")
+			return m.tokens.Remove(t)
+		}
+	}
+	return ErrTokenNotFound
+}
+
+// RemoveUserTokens revokes every API token of a user.
+func (m *AuthMiddleware) RemoveUserTokens(user string) error {
+	m.logger.WithFields(log.Fields{"user": user}).Info("revoking user api tokens")
+	return m.tokens.RemoveByUser(user)
+}
+
+// MoveUserTokens assigns the API tokens of a user to a new email.
+func (m *AuthMiddleware) MoveUserTokens(old, user string) error {
+	for _, t := range m.tokens.FindByUser(old) {
+		t.User = user
+		if err := m.tokens.Save(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tokenUser retrieves the user authenticated by an API token.
+func (m AuthMiddleware) tokenUser(secret string) (coin.User, coin.APIToken, bool) {
+	token, err := m.tokens.Find(HashToken(secret))
+	if err != nil {
+		return coin.User{}, coin.APIToken{}, false
+	}
+
+	now := time.Now()
+	if token.Expired(now) {
+		m.logger.WithFields(log.Fields{"token_id": token.ID, "user": token.User}).Debug("expired api token")
+		return coin.User{}, coin.APIToken{}, false
+	}
+
+	user, err := m.users.Find(token.User)
+	if err != nil || user.Email == "" || user.Disabled {
+		return coin.User{}, coin.APIToken{}, false
+	}
+
+	// record the token usage.
+	if now.Sub(token.LastUsedDate) > tokenUsageInterval {
+		token.LastUsedDate = now.Truncate(time.Second)
+		if err := m.tokens.Save(token); err != nil {
+			m.logger.WithFields(log.Fields{"token_id": token.ID}).Error(err)
+		}
+	}
+
+	m.logger.WithFields(log.Fields{"token_id": token.ID, "user": user.Email}).Debug("current api token")
+	return user, token, true
+}
+
+// bearerToken returns the API token sent in the Authorization header.
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")), true
+}
+
+// HashToken returns the hash an API token is stored by.
+func HashToken(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// TokenManager defines the interface to interact with the API token persistence layer.
+type TokenManager interface {
+	Add(token coin.APIToken) error
+	Find(hash string) (coin.APIToken, error)
+	Save(token coin.APIToken) error
+	Remove(token coin.APIToken) error
+	FindByUser(user string) []coin.APIToken
+	RemoveByUser(user string) error
+}
@@ -0,0 +1,186 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// StoreTokens keeps the tokens of the middleware in memory, by hash.
+func (m *AuthMiddleware) StoreTokens() map[string]coin.APIToken {
+	tokens := make(map[string]coin.APIToken)
+	m.Tokens.AddFunc = func(token coin.APIToken) error {
+		tokens[token.Hash] = token
+		return nil
+	}
+	m.Tokens.SaveFunc = m.Tokens.AddFunc
+	m.Tokens.FindFunc = func(hash string) (coin.APIToken, error) {
+		token, ok := tokens[hash]
+		if !ok {
+			return coin.APIToken{}, database.ErrRecordNotFound
+		}
+		return token, nil
+	}
+	return tokens
+}
+
+// NewTokenRouter returns a router answering with the email of the user and how they were authenticated.
+func NewTokenRouter(m *AuthMiddleware) *gin.Engine {
+	router := NewRouter()
+	router.Use(m.AuthMiddleware.SetUserStatus())
+	handler := func(c *gin.Context) {
+		user, _ := c.Get(util.UserCookie)
+		_, token := c.Get(util.APITokenKey)
+		if user == nil {
+			c.String(http.StatusOK, "anonymous")
+			return
+		}
+		if token {
+			c.String(http.StatusOK, user.(coin.User).Email+" by token")
+			return
+		}
+		c.String(http.StatusOK, user.(coin.User).Email+" by session")
+	}
+	router.GET("/status", handler)
+	router.GET("/games", m.AuthMiddleware.RequireScope(coin.ScopeGamesRead), handler)
+	router.POST("/send", m.AuthMiddleware.RequireAuth(), m.AuthMiddleware.RequireScope(coin.ScopeWalletWrite), handler)
+	router.POST("/account", m.AuthMiddleware.RequireAuth(), m.AuthMiddleware.RequireSession(), handler)
+	return router
+}
+
+// ServeBearer sends a request to the router authenticated by the API token, and by the session cookie if set.
+func ServeBearer(router *gin.Engine, method, target, token, session string) (int, string) {
+	req := NewRequest(method, target, session)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := Do(router, req)
+	return w.Code, w.Body.String()
+}
+
+// TestAuthMiddleware_Bearer tests requests are authenticated by valid API tokens of enabled users.
+func TestAuthMiddleware_Bearer(t *testing.T) {
+	m := NewAuthMiddleware()
+	m.SignIn(coin.User{Email: "luffy@treasure.coin"})
+	tokens := m.StoreTokens()
+	router := NewTokenRouter(m)
+
+	secret, token, err := m.AuthMiddleware.CreateToken("luffy@treasure.coin", "script", []coin.Scope{coin.ScopeGamesRead}, time.Hour)
+	assert.Nil(t, err)
+	assert.Contains(t, tokens, middlewares.HashToken(secret))
+	assert.NotContains(t, tokens, secret)
+
+	code, body := ServeBearer(router, http.MethodGet, "/status", secret, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "luffy@treasure.coin by token", body)
+
+	// an invalid token is not replaced by the session cookie.
+	code, body = ServeBearer(router, http.MethodGet, "/status", secret+"x", "session")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "anonymous", body)
+	code, _ = ServeBearer(router, http.MethodPost, "/send", "", "session")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// expired tokens are refused.
+	token.ExpiryDate = time.Now().Add(-time.Second)
+	tokens[token.Hash] = token
+	_, body = ServeBearer(router, http.MethodGet, "/status", secret, "")
+	assert.Equal(t, "anonymous", body)
+
+	// as are the tokens of disabled and removed users.
+	token.ExpiryDate = time.Time{}
+	tokens[token.Hash] = token
+	m.SignIn(coin.User{Email: "luffy@treasure.coin", Disabled: true})
+	_, body = ServeBearer(router, http.MethodGet, "/status", secret, "")
+	assert.Equal(t, "anonymous", body)
+	m.SignIn(coin.User{Email: "zoro@treasure.coin"})
+	_, body = ServeBearer(router, http.MethodGet, "/status", secret, "")
+	assert.Equal(t, "anonymous", body)
+}
+
+// TestAuthMiddleware_RequireScope tests API tokens only reach the routes of their scopes, while sessions reach them all.
+func TestAuthMiddleware_RequireScope(t *testing.T) {
+	m := NewAuthMiddleware()
+	m.SignIn(coin.User{Email: "luffy@treasure.coin"})
+	m.StoreTokens()
+	router := NewTokenRouter(m)
+
+	read, _, _ := m.AuthMiddleware.CreateToken("luffy@treasure.coin", "read", []coin.Scope{coin.ScopeGamesRead}, 0)
+	write, _, _ := m.AuthMiddleware.CreateToken("luffy@treasure.coin", "write", []coin.Scope{coin.ScopeWalletWrite}, 0)
+
+	tests := []struct {
+		method string
+		target string
+		token  string
+		code   int
+	}{
+		{http.MethodGet, "/games", read, http.StatusOK},
+		{http.MethodGet, "/games", write, http.StatusForbidden},
+		{http.MethodPost, "/send", read, http.StatusForbidden},
+		{http.MethodPost, "/send", write, http.StatusOK},
+	}
+	for _, tt := range tests {
+		code, _ := ServeBearer(router, tt.method, tt.target, tt.token, "")
+		assert.Equal(t, tt.code, code, tt.target)
+	}
+
+	for _, target := range []string{"/games", "/send"} {
+		method := http.MethodGet
+		if target == "/send" {
+			method = http.MethodPost
+		}
+		w := Do(router, NewRequest(method, target, "session"))
+		assert.Equal(t, http.StatusOK, w.Code, target)
+		assert.Equal(t, "luffy@treasure.coin by session", w.Body.String())
+	}
+
+	_, _, err := m.AuthMiddleware.CreateToken("luffy@treasure.coin", "none", nil, 0)
+	assert.Equal(t, middlewares.ErrInvalidScopes, err)
+	_, _, err = m.AuthMiddleware.CreateToken("luffy@treasure.coin", "admin", []coin.Scope{"admin"}, 0)
+	assert.Equal(t, middlewares.ErrInvalidScopes, err)
+}
+
+// TestAuthMiddleware_RequireSession tests API tokens cannot manage the account, whatever their scopes.
+func TestAuthMiddleware_RequireSession(t *testing.T) {
+	m := NewAuthMiddleware()
+	m.SignIn(coin.User{Email: "luffy@treasure.coin"})
+	m.StoreTokens()
+	router := NewTokenRouter(m)
+
+	secret, _, _ := m.AuthMiddleware.CreateToken("luffy@treasure.coin", "all", coin.Scopes, 0)
+	code, body := ServeBearer(router, http.MethodPost, "/account", secret, "")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "index: API tokens cannot be used to manage the account.", body)
+
+	w := Do(router, NewRequest(http.MethodPost, "/account", "session"))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestAuthMiddleware_TokenUsage tests the last usage of the tokens is only saved once a minute.
+func TestAuthMiddleware_TokenUsage(t *testing.T) {
+	m := NewAuthMiddleware()
+	m.SignIn(coin.User{Email: "luffy@treasure.coin"})
+	tokens := m.StoreTokens()
+	router := NewTokenRouter(m)
+
+	secret, token, _ := m.AuthMiddleware.CreateToken("luffy@treasure.coin", "script", coin.Scopes, 0)
+	assert.True(t, token.LastUsedDate.IsZero())
+
+	ServeBearer(router, http.MethodGet, "/status", secret, "")
+	assert.Len(t, m.Tokens.SaveCalls(), 1)
+	assert.WithinDuration(t, time.Now(), tokens[token.Hash].LastUsedDate, 2*time.Second)
+
+	ServeBearer(router, http.MethodGet, "/status", secret, "")
+	assert.Len(t, m.Tokens.SaveCalls(), 1)
+
+	token = tokens[token.Hash]
+	token.LastUsedDate = time.Now().Add(-2 * time.Minute)
+	tokens[token.Hash] = token
+	ServeBearer(router, http.MethodGet, "/status", secret, "")
+	assert.Len(t, m.Tokens.SaveCalls(), 2)
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin/http/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// WalletMiddleware represents a HTTP middleware handler reporting the status of the wallet provider to the pages.
+type WalletMiddleware struct {
+	logger *log.Entry
+
+	// external services.
+	status WalletStatus
+}
+
+// NewWalletMiddleware returns a new instance of the wallet middleware handler.
+func NewWalletMiddleware(status WalletStatus) *WalletMiddleware {
+	m := &WalletMiddleware{
+		logger: log.WithFields(log.Fields{"package": "http", "module": "wallet-middleware"}),
+		status: status,
+	}
+	return m
+}
+
+// SetWalletStatus sets whether the wallet provider is degraded in the request context.
+func (m WalletMiddleware) SetWalletStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(util.WalletDegradedKey, m.status.Degraded())
+		c.Next()
+	}
+}
+
+// WalletStatus defines the interface to check the availability of the wallet provider.
+type WalletStatus interface {
+	Degraded() bool
+}
@@ -1,16 +1,58 @@
 package http
 
 import (
+	"context"
+	"crypto/tls"
+	"html/template"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
+	"github.com/pmdcosta/treasure-coin/http/middlewares"
 	log "github.com/sirupsen/logrus"
 )
 
-// templateDir describes the template asset directory.
-const templateDir = "templates/*"
+// Config are the server settings.
+type Config struct {
+	// Port is the address the server listens on, like ":8080".
+	Port     string
+	Timeouts Timeouts
+	TLS      TLS
+
+	// ContentSecurityPolicy restricts what the pages can load, none when empty.
+	ContentSecurityPolicy string
+
+	Files Files
+}
+
+// Timeouts are the limits of the server connections, zero for no limit.
+type Timeouts struct {
+	// Read limits reading a whole request, Write limits writing its response.
+	Read  time.Duration
+	Write time.Duration
+	// Idle limits how long a keep-alive connection waits for the next request.
+	Idle time.Duration
+}
+
+// TLS are the HTTPS settings, plain HTTP being served unless enabled.
+type TLS struct {
+	Enabled bool
+
+	// certificate and key files, loaded again when they change.
+	Cert string
+	Key  string
+
+	// RedirectPort is the address of a plain HTTP listener redirecting to Host, none when empty.
+	RedirectPort string
+	// Host is the public url the redirects point to, like "https://treasurecoin.powertrip.pt".
+	Host string
 
-// staticDir describes the static asset directory.
-const staticDir = "public/"
+	// HSTS is how long browsers only reach the server over HTTPS, none when zero.
+	HSTS time.Duration
+}
 
 // Handler represents an http handler.
 type Handler interface {
@@ -22,43 +64,97 @@ type Server struct {
 	// custom logger object.
 	logger *log.Entry
 
-	// http port to serve from.
-	port   string
-	cert   string
-	secret string
-	ssl    bool
+	// server settings.
+	config Config
+
+	// router instance, and the servers listening for its requests and redirecting to them.
+	router   *gin.Engine
+	server   *http.Server
+	redirect *http.Server
 
-	// router instance.
-	router *gin.Engine
+	// certificate served, watched until the server is closed.
+	certificate *certificate
+	watching    context.Context
+	stopWatch   context.CancelFunc
 
-	// http handlers.
-	handlers []Handler
+	// http handlers, and the middleware run before them.
+	handlers    []Handler
+	middlewares []gin.HandlerFunc
 }
 
 // NewServer returns a new instance of Server.
-func NewServer(port, cert, secret string, ssl bool, h ...Handler) *Server {
+func NewServer(config Config, h ...Handler) *Server {
 	// set the server to production mode.
 	gin.SetMode(gin.ReleaseMode)
 
+	// identifies and logs every request, recovering from the panics of the handlers.
+	router := gin.New()
+	rm := middlewares.NewRequestMiddleware()
+	logger := log.WithFields(log.Fields{"package": "http", "module": "server"})
+	router.Use(rm.SetRequestID(), rm.LogRequests(), gin.RecoveryWithWriter(logger.WriterLevel(log.ErrorLevel)))
+
+	// sets the security headers of every response, assets included.
+	sm := middlewares.NewSecurityMiddleware(config.TLS.HSTS, config.ContentSecurityPolicy)
+	router.Use(sm.SetHeaders())
+
 	s := &Server{
-		router:   gin.Default(),
-		logger:   log.WithFields(log.Fields{"package": "http", "module": "server"}),
+		router: router,
+		server: &http.Server{
+			Addr:         config.Port,
+			Handler:      router,
+			ReadTimeout:  config.Timeouts.Read,
+			WriteTimeout: config.Timeouts.Write,
+			IdleTimeout:  config.Timeouts.Idle,
+		},
+		logger:   logger,
+		config:   config,
 		handlers: h,
-		port:     port,
-		cert:     cert,
-		secret:   secret,
-		ssl:      ssl,
+	}
+	s.watching, s.stopWatch = context.WithCancel(context.Background())
+
+	if config.TLS.Enabled {
+		s.certificate = newCertificate(config.TLS.Cert, config.TLS.Key)
+		s.server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: s.certificate.GetCertificate,
+		}
+		if config.TLS.RedirectPort != "" {
+			s.redirect = &http.Server{
+				Addr:         config.TLS.RedirectPort,
+				Handler:      s.redirectHandler(),
+				ReadTimeout:  config.Timeouts.Read,
+				WriteTimeout: config.Timeouts.Write,
+				IdleTimeout:  config.Timeouts.Idle,
+			}
+		}
 	}
 	return s
 }
 
-// Open starts the server.
+// Use adds middleware run before every handler.
+func (c *Server) Use(middleware ...gin.HandlerFunc) {
+	c.middlewares = append(c.middlewares, middleware...)
+}
+
+// Open starts the server, blocking until it fails or is closed.
 func (c *Server) Open() error {
-	// loads the templates from the disk.
-	c.router.LoadHTMLGlob(templateDir)
+	// loads the templates, linking the assets by their fingerprinted urls.
+	files := c.config.Files
+	assets := newAssets(files.Assets, files.Reload)
+	templates, err := newTemplates(files.Templates, template.FuncMap{"asset": assets.URL}, files.Reload)
+	if err != nil {
+		return err
+	}
+	c.router.HTMLRender = templates
 
-	// serves the static assets.
-	c.router.Use(static.Serve("/assets", static.LocalFile(staticDir, false)))
+	// renders the errors left by the handlers.
+	c.router.Use(middlewares.NewErrorMiddleware().HandleErrors())
+
+	// serves the QR codes of the treasures and the static assets.
+	c.router.Use(static.Serve(AssetsPath+"codes", static.LocalFile(files.Codes, false)), assets.Serve())
+
+	// registers the middleware shared by every handler.
+	c.router.Use(c.middlewares...)
 
 	// loads the http handlers of the project.
 	for _, h := range c.handlers {
@@ -66,11 +162,76 @@ func (c *Server) Open() error {
 	}
 
 	// starts the http server.
-	c.logger.Info("Starting server at port: " + c.port)
+	c.logger.Info("Starting server at port: " + c.config.Port)
 
-	if c.ssl {
-		return c.router.RunTLS(c.port, c.cert, c.secret)
+	if c.config.TLS.Enabled {
+		err = c.serveTLS()
 	} else {
-		return c.router.Run(c.port)
+		err = c.server.ListenAndServe()
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// serveTLS serves HTTPS, along with the plain HTTP redirects if enabled.
+func (c *Server) serveTLS() error {
+	if err := c.certificate.Load(); err != nil {
+		return err
+	}
+	go func() {
+		if err := c.certificate.Watch(c.watching); err != nil {
+			c.logger.WithFields(log.Fields{"error": err}).Warn("certificate files are not watched, reload them with SIGHUP")
+		}
+	}()
+
+	if c.redirect != nil {
+		// listens before serving, so a port in use stops the server.
+		l, err := net.Listen("tcp", c.redirect.Addr)
+		if err != nil {
+			return err
+		}
+		c.logger.Info("Redirecting to HTTPS from port: " + c.redirect.Addr)
+		go func() {
+			if err := c.redirect.Serve(l); err != nil && err != http.ErrServerClosed {
+				c.logger.WithFields(log.Fields{"error": err}).Error("redirect server failed")
+			}
+		}()
+	}
+
+	// the certificate is supplied by the tls settings.
+	return c.server.ListenAndServeTLS("", "")
+}
+
+// redirectHandler sends the plain HTTP requests to the same address on the public HTTPS host.
+func (c *Server) redirectHandler() http.Handler {
+	host := strings.TrimSuffix(c.config.TLS.Host, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// other methods keep their body only with a permanent redirect.
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, host+r.URL.RequestURI(), code)
+	})
+}
+
+// ReloadCertificate loads the certificate files again, keeping the current certificate when they cannot be read.
+func (c *Server) ReloadCertificate() error {
+	if c.certificate == nil {
+		return nil
+	}
+	return c.certificate.Load()
+}
+
+// Close stops accepting connections and waits for the requests in flight to be handled,
+// until the context is done.
+func (c *Server) Close(ctx context.Context) error {
+	c.logger.Info("Stopping server at port: " + c.config.Port)
+	c.stopWatch()
+	if c.redirect != nil {
+		c.redirect.Shutdown(ctx)
 	}
+	return c.server.Shutdown(ctx)
 }
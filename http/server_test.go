@@ -0,0 +1,178 @@
+package http_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	server "github.com/pmdcosta/treasure-coin/http"
+	"github.com/pmdcosta/treasure-coin/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ping is a handler answering the pings.
+type Ping struct{}
+
+func (Ping) Bootstrap(router *gin.Engine) {
+	router.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+}
+
+// Server is a test wrapper serving HTTPS with a self-signed certificate, and redirecting plain HTTP to it.
+type Server struct {
+	*server.Server
+	Cert, Key string
+	Addr      string
+	Redirect  string
+}
+
+// NewServer returns a new instance of Server, serving a certificate for the name until the test ends.
+func NewServer(t *testing.T, name string) *Server {
+	dir := t.TempDir()
+	s := &Server{
+		Cert:     filepath.Join(dir, "certificate.pem"),
+		Key:      filepath.Join(dir, "secret.pem"),
+		Addr:     freeAddr(t),
+		Redirect: freeAddr(t),
+	}
+	s.WriteCertificate(t, name)
+
+	s.Server = server.NewServer(server.Config{
+		Port: s.Addr,
+		TLS: server.TLS{
+			Enabled:      true,
+			Cert:         s.Cert,
+			Key:          s.Key,
+			RedirectPort: s.Redirect,
+			Host:         "https://treasure.coin/",
+			HSTS:         time.Hour,
+		},
+		Files: server.Files{
+			Templates: web.Templates(""),
+			Assets:    web.Assets(""),
+			Codes:     t.TempDir(),
+		},
+	}, Ping{})
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Open()
+	}()
+	t.Cleanup(func() {
+		s.Close(context.Background())
+		assert.Nil(t, <-served)
+	})
+
+	require.Eventually(t, func() bool { return s.ServedName() == name }, 5*time.Second, 10*time.Millisecond)
+	return s
+}
+
+// WriteCertificate writes a new self-signed certificate for the name.
+func (s *Server) WriteCertificate(t *testing.T, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	require.Nil(t, ioutil.WriteFile(s.Key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	require.Nil(t, ioutil.WriteFile(s.Cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+}
+
+// ServedName returns the name of the certificate served, empty if the server cannot be reached.
+func (s *Server) ServedName() string {
+	conn, err := tls.Dial("tcp", s.Addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+// freeAddr returns a local address nothing listens on.
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+	return "127.0.0.1:" + strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
+
+// TestServer_Certificate tests the certificate is loaded again when its files change, and kept when they are invalid.
+func TestServer_Certificate(t *testing.T) {
+	s := NewServer(t, "luffy")
+
+	// the files changed.
+	s.WriteCertificate(t, "zoro")
+	assert.Eventually(t, func() bool { return s.ServedName() == "zoro" }, 5*time.Second, 10*time.Millisecond)
+
+	// the files are broken.
+	require.Nil(t, ioutil.WriteFile(s.Cert, []byte("not a certificate"), 0644))
+	assert.NotNil(t, s.ReloadCertificate())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "zoro", s.ServedName())
+
+	// reloaded on hangup.
+	s.WriteCertificate(t, "nami")
+	assert.Nil(t, s.ReloadCertificate())
+	assert.Equal(t, "nami", s.ServedName())
+}
+
+// TestServer_Redirect tests the plain HTTP requests are redirected to the same address on the public host.
+func TestServer_Redirect(t *testing.T) {
+	s := NewServer(t, "luffy")
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	tests := []struct {
+		method string
+		code   int
+	}{
+		{http.MethodGet, http.StatusMovedPermanently},
+		{http.MethodHead, http.StatusMovedPermanently},
+		{http.MethodPost, http.StatusPermanentRedirect},
+	}
+	for _, tc := range tests {
+		req, err := http.NewRequest(tc.method, "http://"+s.Redirect+"/games/list?page=2", nil)
+		require.Nil(t, err)
+		resp, err := client.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		assert.Equal(t, tc.code, resp.StatusCode, tc.method)
+		assert.Equal(t, "https://treasure.coin/games/list?page=2", resp.Header.Get("Location"), tc.method)
+		assert.Empty(t, resp.Header.Get("Strict-Transport-Security"), tc.method)
+	}
+}
+
+// TestServer_SecurityHeaders tests the security headers of the HTTPS responses.
+func TestServer_SecurityHeaders(t *testing.T) {
+	s := NewServer(t, "luffy")
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+
+	resp, err := client.Get("https://" + s.Addr + "/ping")
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "max-age=3600", resp.Header.Get("Strict-Transport-Security"))
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+}
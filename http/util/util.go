@@ -2,16 +2,45 @@ package util
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
 )
 
 const LogInCookie = "is_logged_in"
 const UserCookie = "user"
 
+// context keys set by the middlewares.
+const (
+	GameKey      = "game"
+	TreasureKey  = "treasure"
+	APITokenKey  = "api_token"
+	RequestIDKey = "request_id"
+
+	// IdentityProviderKey holds the name of the external identity provider, if any.
+	IdentityProviderKey = "identity_provider"
+	// WalletDegradedKey holds whether the wallet provider is unavailable and wallet operations are delayed.
+	WalletDegradedKey = "wallet_degraded"
+)
+
+// Pages rendered outside of the handlers.
+const (
+	IndexPage  = "index.html"
+	SignInPage = "signin.html"
+)
+
+// SignInURL is the address of the sign in page.
+const SignInURL = "/signin"
+
 // render returns either HTML or JSON based on the 'Accept' header of the request (defaults to HTML)
 func Render(c *gin.Context, data gin.H, template string) {
+	RenderStatus(c, http.StatusOK, data, template)
+}
+
+// RenderStatus renders the response like Render using the supplied status code.
+func RenderStatus(c *gin.Context, code int, data gin.H, template string) {
 	// check whether the user is logged in.
 	if loggedIn, exists := c.Get(LogInCookie); exists {
 		data[LogInCookie] = loggedIn.(bool)
@@ -19,21 +48,70 @@ func Render(c *gin.Context, data gin.H, template string) {
 	if user, exists := c.Get(UserCookie); exists {
 		data[UserCookie] = user.(coin.User)
 	}
+	if provider, exists := c.Get(IdentityProviderKey); exists {
+		data[IdentityProviderKey] = provider.(string)
+	}
+	if degraded, exists := c.Get(WalletDegradedKey); exists {
+		data[WalletDegradedKey] = degraded.(bool)
+	}
 
-	switch c.Request.Header.Get("Accept") {
-	case "application/json":
-		c.JSON(http.StatusOK, data["payload"])
-	default:
-		c.HTML(http.StatusOK, template, data)
+	if WantsJSON(c) {
+		// pages rendered with an error become JSON errors.
+		if title, ok := data["ErrorTitle"]; ok && data["payload"] == nil {
+			if code == http.StatusOK {
+				code = http.StatusBadRequest
+			}
+			c.JSON(code, gin.H{
+				"error": gin.H{
+					"title":   title,
+					"message": data["ErrorMessage"],
+				},
+			})
+			return
+		}
+		c.JSON(code, data["payload"])
+	} else {
+		c.HTML(code, template, data)
 	}
 }
 
+// WantsJSON returns whether the client asked for a JSON response.
+func WantsJSON(c *gin.Context) bool {
+	return strings.HasPrefix(c.Request.Header.Get("Accept"), "application/json")
+}
+
+// Logger returns the logger tagging its entries with the ID of the request.
+func Logger(c *gin.Context, logger *log.Entry) *log.Entry {
+	return logger.WithContext(c.Request.Context())
+}
+
+// SetCookie sets a cookie hidden from the scripts of the pages, and only sent back over HTTPS when set on a TLS connection.
+// The cookie is not sent with the requests other sites make, like forms posted to the server, so they cannot act
+// on behalf of the signed in users.
+func SetCookie(c *gin.Context, name, value string, maxAge int, path string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, maxAge, path, "", c.Request.TLS != nil, true)
+}
+
+// Abort stops the request and leaves the error to be rendered by the error middleware.
+func Abort(c *gin.Context, err RequestError) {
+	c.Error(err)
+	c.Abort()
+}
+
 // RequestError represents a request error.
 type RequestError struct {
+	// http status code and page used when the error aborts the request.
+	Code int
+	Page string
+
 	Title   string
 	Message string
 }
 
+// Error returns the error message.
+func (r RequestError) Error() string { return r.Title + " " + r.Message }
+
 func (r RequestError) Render() map[string]interface{} {
 	return gin.H{
 		"ErrorTitle":   r.Title,
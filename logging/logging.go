@@ -0,0 +1,76 @@
+// Package logging configures the application logs, tagging the entries logged while handling a request
+// with its ID and masking the secrets they carry.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// RequestIDField is the field holding the request ID in the log entries.
+const RequestIDField = "request_id"
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of the context holding the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID held by the context, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Configure sets the format and level of the logger, and adds the hooks tagging and redacting its entries.
+func Configure(logger *log.Logger, output io.Writer, format, level string) error {
+	l, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatText:
+		logger.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+	case FormatJSON:
+		logger.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	logger.SetOutput(output)
+	logger.SetLevel(l)
+	logger.AddHook(RequestHook{})
+	logger.AddHook(RedactHook{})
+	return nil
+}
+
+// RequestHook tags the entries logged with a request context with its ID.
+type RequestHook struct{}
+
+// Levels returns the levels the hook fires at, all of them.
+func (RequestHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire adds the request ID to the entry.
+func (RequestHook) Fire(entry *log.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id := RequestID(entry.Context); id != "" {
+		entry.Data[RequestIDField] = id
+	}
+	return nil
+}
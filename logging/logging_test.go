@@ -0,0 +1,79 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin/logging"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewLogger returns a logger writing JSON entries to the buffer.
+func NewLogger(t *testing.T) (*log.Logger, *bytes.Buffer) {
+	var b bytes.Buffer
+	logger := log.New()
+	require.Nil(t, logging.Configure(logger, &b, logging.FormatJSON, "debug"))
+	return logger, &b
+}
+
+// Entry decodes the last entry written to the buffer.
+func Entry(t *testing.T, b *bytes.Buffer) map[string]interface{} {
+	var e map[string]interface{}
+	require.Nil(t, json.Unmarshal(b.Bytes(), &e))
+	b.Reset()
+	return e
+}
+
+// TestConfigure tests rejecting unknown formats and levels.
+func TestConfigure(t *testing.T) {
+	assert.NotNil(t, logging.Configure(log.New(), &bytes.Buffer{}, "xml", "info"))
+	assert.NotNil(t, logging.Configure(log.New(), &bytes.Buffer{}, logging.FormatText, "loud"))
+}
+
+// TestRequestHook tests tagging the entries logged with a request context.
+func TestRequestHook(t *testing.T) {
+	logger, b := NewLogger(t)
+	ctx := logging.WithRequestID(context.Background(), "abc-123")
+	assert.Equal(t, "abc-123", logging.RequestID(ctx))
+
+	logger.WithContext(ctx).Info("handled")
+	assert.Equal(t, "abc-123", Entry(t, b)[logging.RequestIDField])
+
+	logger.WithContext(context.Background()).Info("handled")
+	assert.NotContains(t, Entry(t, b), logging.RequestIDField)
+
+	logger.Info("handled")
+	assert.NotContains(t, Entry(t, b), logging.RequestIDField)
+}
+
+// TestRedactHook tests masking the secrets of the fields and the messages.
+func TestRedactHook(t *testing.T) {
+	logger, b := NewLogger(t)
+
+	base := logger.WithFields(log.Fields{"package": "test", "password": "hunter2"})
+	base.WithFields(log.Fields{
+		"client_secret": "s3cr3t",
+		"token_id":      "4a1f",
+		"error":         errors.New(`Get "https://sandboxapi.ost.com/v1.1/users?api_key=k3y&signature=51gn": timeout`),
+		"record":        `{"email":"luffy@onepiece.com","password":"$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"}`,
+	}).Info("authorization: Bearer abc.def")
+
+	e := Entry(t, b)
+	assert.Equal(t, logging.Redacted, e["password"])
+	assert.Equal(t, logging.Redacted, e["client_secret"])
+	assert.Equal(t, "4a1f", e["token_id"])
+	assert.Equal(t, `Get "https://sandboxapi.ost.com/v1.1/users?api_key=********&signature=********": timeout`, e["error"])
+	assert.Equal(t, `{"email":"luffy@onepiece.com","password":"********"}`, e["record"])
+	assert.Equal(t, "authorization: Bearer ********", e["msg"])
+
+	// the fields of the logger itself are left untouched.
+	assert.Equal(t, "hunter2", base.Data["password"])
+
+	assert.Equal(t, "hash "+logging.Redacted, logging.RedactText("hash $2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"))
+	assert.Equal(t, "/found/1?token="+logging.Redacted, logging.RedactText("/found/1?token=8c2a"))
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Redacted replaces the secrets in the log entries.
+const Redacted = "********"
+
+// secretField matches the names of the fields holding secrets, like "password" or "client_secret".
+var secretField = regexp.MustCompile(`^([a-z0-9_]*_)?(password|passphrase|secret|token|signature|api_key|apikey|authorization|cookie)$`)
+
+// secretPatterns match the secrets embedded in the logged text, and how they are replaced.
+var secretPatterns = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	// query and form parameters, like the signed urls of the OST API.
+	{regexp.MustCompile(`(?i)\b([a-z_]*(?:key|signature|secret|token|password|passphrase)|code)=[^&\s"']+`), "${1}=" + Redacted},
+	// JSON attributes.
+	{regexp.MustCompile(`(?i)"([a-z_]*(?:key|signature|secret|token|password|passphrase))"\s*:\s*"[^"]*"`), `"${1}":"` + Redacted + `"`},
+	// bcrypt password hashes.
+	{regexp.MustCompile(`\$2[abxy]?\$\d\d\$[./A-Za-z0-9]{53}`), Redacted},
+	// authorization headers.
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[^\s"']+`), "${1} " + Redacted},
+}
+
+// RedactHook masks the passwords, tokens, signatures and API keys logged,
+// either as fields named after them or embedded in the message and the text of the fields.
+type RedactHook struct{}
+
+// Levels returns the levels the hook fires at, all of them.
+func (RedactHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire masks the secrets of the entry.
+func (RedactHook) Fire(entry *log.Entry) error {
+	entry.Message = RedactText(entry.Message)
+	for k, v := range entry.Data {
+		if secretField.MatchString(strings.Replace(strings.ToLower(k), "-", "_", -1)) {
+			entry.Data[k] = Redacted
+			continue
+		}
+
+		switch v := v.(type) {
+		case string:
+			entry.Data[k] = RedactText(v)
+		case error:
+			// errors are kept as they are unless they carry a secret.
+			if s := RedactText(v.Error()); s != v.Error() {
+				entry.Data[k] = s
+			}
+		}
+	}
+	return nil
+}
+
+// RedactText masks the secrets embedded in the text.
+func RedactText(s string) string {
+	for _, p := range secretPatterns {
+		s = p.pattern.ReplaceAllString(s, p.replace)
+	}
+	return s
+}
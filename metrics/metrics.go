@@ -0,0 +1,197 @@
+// Package metrics collects the application metrics and exposes them in the Prometheus format.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes the name of every metric.
+const Namespace = "treasurecoin"
+
+// Metrics represents the application metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// http requests.
+	requests *prometheus.HistogramVec
+
+	// wallet provider requests.
+	walletRequests *prometheus.CounterVec
+	walletDuration *prometheus.HistogramVec
+
+	// game events.
+	signUps          prometheus.Counter
+	gamesCreated     prometheus.Counter
+	treasuresClaimed prometheus.Counter
+}
+
+// NewMetrics returns new application metrics, reading the storage statistics and the open sessions on every scrape.
+func NewMetrics(store Store, sessions SessionCounter) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of the HTTP requests, by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		walletRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "wallet_requests_total",
+			Help:      "Requests made to the wallet provider, by endpoint and result.",
+		}, []string{"endpoint", "result"}),
+		walletDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "wallet_request_duration_seconds",
+			Help:      "Duration of the requests made to the wallet provider, by endpoint.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+		signUps: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "sign_ups_total",
+			Help:      "Users signed up.",
+		}),
+		gamesCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "games_created_total",
+			Help:      "Games created.",
+		}),
+		treasuresClaimed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "treasures_claimed_total",
+			Help:      "Treasures found by the players.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.requests, m.walletRequests, m.walletDuration,
+		m.signUps, m.gamesCreated, m.treasuresClaimed,
+		newStoreCollector(store, sessions),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler returns the handler exposing the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records an HTTP request handled by a route.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveWallet records a request made to the wallet provider.
+func (m *Metrics) ObserveWallet(endpoint string, duration time.Duration, err error) {
+	m.walletRequests.WithLabelValues(endpoint, result(err)).Inc()
+	m.walletDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
+
+// SignedUp records a user signing up.
+func (m *Metrics) SignedUp() {
+	m.signUps.Inc()
+}
+
+// GameCreated records a game being created.
+func (m *Metrics) GameCreated() {
+	m.gamesCreated.Inc()
+}
+
+// TreasureClaimed records a treasure being found.
+func (m *Metrics) TreasureClaimed() {
+	m.treasuresClaimed.Inc()
+}
+
+// result labels the outcome of a wallet provider request.
+func result(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	case errors.Is(err, coin.ErrWalletUnavailable):
+		return "unavailable"
+	case errors.Is(err, coin.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, coin.ErrWalletAuth):
+		return "unauthorized"
+	case errors.Is(err, coin.ErrInsufficientBalance):
+		return "insufficient_balance"
+	case errors.Is(err, coin.ErrInvalidWallet):
+		return "invalid_wallet"
+	}
+	return "error"
+}
+
+// storeCollector collects the open sessions and the storage statistics on every scrape.
+type storeCollector struct {
+	store    Store
+	sessions SessionCounter
+
+	active       *prometheus.Desc
+	size         *prometheus.Desc
+	freePages    *prometheus.Desc
+	pendingPages *prometheus.Desc
+	openReads    *prometheus.Desc
+	writes       *prometheus.Desc
+	writeTime    *prometheus.Desc
+}
+
+// newStoreCollector returns a new collector of the store statistics.
+func newStoreCollector(store Store, sessions SessionCounter) *storeCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", name), help, nil, nil)
+	}
+	return &storeCollector{
+		store:        store,
+		sessions:     sessions,
+		active:       desc("active_sessions", "Sessions currently open."),
+		size:         desc("db_size_bytes", "Size of the database data."),
+		freePages:    desc("db_free_pages", "Database pages free for reuse."),
+		pendingPages: desc("db_pending_pages", "Database pages pending to be freed."),
+		openReads:    desc("db_open_read_transactions", "Database read transactions in progress."),
+		writes:       desc("db_writes_total", "Database writes to disk."),
+		writeTime:    desc("db_write_seconds_total", "Time spent writing the database to disk."),
+	}
+}
+
+// Describe sends the descriptors of the store metrics.
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.active, c.size, c.freePages, c.pendingPages, c.openReads, c.writes, c.writeTime} {
+		ch <- d
+	}
+}
+
+// Collect reads the store statistics and sends them as metrics.
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.store.Stats()
+	ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(c.sessions.Count()))
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(s.Size))
+	ch <- prometheus.MustNewConstMetric(c.freePages, prometheus.GaugeValue, float64(s.FreePages))
+	ch <- prometheus.MustNewConstMetric(c.pendingPages, prometheus.GaugeValue, float64(s.PendingPages))
+	ch <- prometheus.MustNewConstMetric(c.openReads, prometheus.GaugeValue, float64(s.OpenReads))
+	ch <- prometheus.MustNewConstMetric(c.writes, prometheus.CounterValue, float64(s.Writes))
+	ch <- prometheus.MustNewConstMetric(c.writeTime, prometheus.CounterValue, s.WriteTime.Seconds())
+}
+
+// Store defines the interface to read the statistics of the persistence layer.
+type Store interface {
+	Stats() database.Stats
+}
+
+// SessionCounter defines the interface to count the open sessions.
+type SessionCounter interface {
+	Count() int
+}
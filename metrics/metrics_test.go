@@ -0,0 +1,66 @@
+package metrics_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/database"
+	"github.com/pmdcosta/treasure-coin/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// Store is a test store with fixed statistics.
+type Store struct {
+	sessions int
+}
+
+// Stats returns fixed storage statistics.
+func (s Store) Stats() database.Stats {
+	return database.Stats{Size: 32768, Writes: 4, WriteTime: 2 * time.Second}
+}
+
+// Count returns the open sessions.
+func (s Store) Count() int {
+	return s.sessions
+}
+
+// Scrape returns the metrics exposed.
+func Scrape(m *metrics.Metrics) string {
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(w.Body)
+	return string(body)
+}
+
+// TestMetrics tests exposing the recorded metrics.
+func TestMetrics(t *testing.T) {
+	s := Store{sessions: 3}
+	m := metrics.NewMetrics(s, s)
+
+	m.ObserveRequest("GET", "/games/:game", 200, 20*time.Millisecond)
+	m.ObserveWallet("GET /users/{id}/", time.Second, nil)
+	m.ObserveWallet("GET /users/{id}/", time.Second, errors.New("boom"))
+	m.ObserveWallet("POST /transactions/", time.Second, coin.ErrInsufficientBalance)
+	m.SignedUp()
+	m.GameCreated()
+	m.GameCreated()
+	m.TreasureClaimed()
+
+	body := Scrape(m)
+	assert.Contains(t, body, `treasurecoin_http_request_duration_seconds_count{method="GET",route="/games/:game",status="200"} 1`)
+	assert.Contains(t, body, `treasurecoin_wallet_requests_total{endpoint="GET /users/{id}/",result="ok"} 1`)
+	assert.Contains(t, body, `treasurecoin_wallet_requests_total{endpoint="GET /users/{id}/",result="error"} 1`)
+	assert.Contains(t, body, `treasurecoin_wallet_requests_total{endpoint="POST /transactions/",result="insufficient_balance"} 1`)
+	assert.Contains(t, body, `treasurecoin_wallet_request_duration_seconds_count{endpoint="GET /users/{id}/"} 2`)
+	assert.Contains(t, body, "treasurecoin_sign_ups_total 1")
+	assert.Contains(t, body, "treasurecoin_games_created_total 2")
+	assert.Contains(t, body, "treasurecoin_treasures_claimed_total 1")
+	assert.Contains(t, body, "treasurecoin_active_sessions 3")
+	assert.Contains(t, body, "treasurecoin_db_size_bytes 32768")
+	assert.Contains(t, body, "treasurecoin_db_write_seconds_total 2")
+	assert.Contains(t, body, "go_goroutines")
+}
@@ -0,0 +1,131 @@
+package openid
+
+import (
+	"context"
+	"strings"
+
+	"github.com/coreos/go-oidc"
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+// openid errors.
+const (
+	ErrMissingIDToken = coin.Error("the provider did not return an id token")
+	ErrInvalidNonce   = coin.Error("the id token nonce does not match")
+	ErrMissingEmail   = coin.Error("the id token does not contain an email")
+)
+
+// Client represents a client to sign users in with an OpenID Connect provider.
+type Client struct {
+	logger *log.Entry
+
+	name     string
+	provider *oidc.Provider
+	verifier *oidc.IDTokenVerifier
+	oauth    oauth2.Config
+}
+
+// Config are the OpenID Connect provider settings.
+type Config struct {
+	// Name is shown to the users in the sign in page.
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// NewClient returns a new OpenID Connect client, loading the provider discovery document.
+func NewClient(ctx context.Context, config Config) (*Client, error) {
+	provider, err := oidc.NewProvider(ctx, config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		logger:   log.WithFields(log.Fields{"package": "openid"}),
+		name:     config.Name,
+		provider: provider,
+		verifier: provider.Verifier(&oidc.Config{ClientID: config.ClientID}),
+		oauth: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		},
+	}
+	if c.name == "" {
+		c.name = config.Issuer
+	}
+
+	c.logger.WithFields(log.Fields{"issuer": config.Issuer}).Info("loaded OpenID Connect provider")
+	return c, nil
+}
+
+// Name returns the display name of the provider.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// AuthCodeURL returns the provider address the user is sent to in order to sign in.
+func (c *Client) AuthCodeURL(state, nonce string) string {
+	return c.oauth.AuthCodeURL(state, oidc.Nonce(nonce))
+}
+
+// Exchange trades the authorization code for the verified identity of the user.
+func (c *Client) Exchange(ctx context.Context, code, nonce string) (coin.Identity, error) {
+	token, err := c.oauth.Exchange(ctx, code)
+	if err != nil {
+		return coin.Identity{}, err
+	}
+
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return coin.Identity{}, ErrMissingIDToken
+	}
+
+	// verify the signature, issuer, audience and expiry of the id token.
+	idToken, err := c.verifier.Verify(ctx, raw)
+	if err != nil {
+		return coin.Identity{}, err
+	}
+	if idToken.Nonce != nonce {
+		return coin.Identity{}, ErrInvalidNonce
+	}
+
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return coin.Identity{}, err
+	}
+	if claims.Email == "" {
+		return coin.Identity{}, ErrMissingEmail
+	}
+
+	// pick the best available display name.
+	username := claims.PreferredUsername
+	if username == "" {
+		username = claims.Name
+	}
+	if username == "" {
+		username = strings.Split(claims.Email, "@")[0]
+	}
+
+	identity := coin.Identity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Username:      username,
+	}
+
+	c.logger.WithFields(log.Fields{"issuer": identity.Issuer, "subject": identity.Subject}).Info("user identity verified")
+	return identity, nil
+}
@@ -0,0 +1,86 @@
+package openid_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin/openid"
+	"github.com/pmdcosta/treasure-coin/openid/openidtest"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// NewClient returns a new client connected to the stub provider.
+func NewClient(t *testing.T, p *openidtest.Provider) *openid.Client {
+	log.SetLevel(log.DebugLevel)
+	c, err := p.Client("http://localhost:8080/auth/oidc/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// TestClient_AuthCodeURL tests building the provider sign in address.
+func TestClient_AuthCodeURL(t *testing.T) {
+	p := openidtest.NewProvider()
+	defer p.Close()
+	c := NewClient(t, p)
+
+	u, err := url.Parse(c.AuthCodeURL("state", "nonce"))
+	assert.Nil(t, err)
+	assert.Equal(t, p.URL+"/auth", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "state", u.Query().Get("state"))
+	assert.Equal(t, "nonce", u.Query().Get("nonce"))
+	assert.Equal(t, openidtest.ClientID, u.Query().Get("client_id"))
+	assert.Equal(t, "Stub", c.Name())
+}
+
+// TestClient_Exchange tests trading an authorization code for a verified identity.
+func TestClient_Exchange(t *testing.T) {
+	p := openidtest.NewProvider()
+	defer p.Close()
+	p.Claims["nonce"] = "nonce"
+	c := NewClient(t, p)
+
+	identity, err := c.Exchange(context.Background(), openidtest.Code, "nonce")
+	assert.Nil(t, err)
+	assert.Equal(t, p.URL, identity.Issuer)
+	assert.Equal(t, "luffy", identity.Subject)
+	assert.Equal(t, "luffy@treasure.coin", identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "Monkey D. Luffy", identity.Username)
+}
+
+// TestClient_Exchange_InvalidNonce tests rejecting an id token issued for another sign in.
+func TestClient_Exchange_InvalidNonce(t *testing.T) {
+	p := openidtest.NewProvider()
+	defer p.Close()
+	p.Claims["nonce"] = "other"
+	c := NewClient(t, p)
+
+	_, err := c.Exchange(context.Background(), openidtest.Code, "nonce")
+	assert.Equal(t, openid.ErrInvalidNonce, err)
+}
+
+// TestClient_Exchange_InvalidAudience tests rejecting an id token issued to another client.
+func TestClient_Exchange_InvalidAudience(t *testing.T) {
+	p := openidtest.NewProvider()
+	defer p.Close()
+	p.Claims["nonce"] = "nonce"
+	p.Claims["aud"] = "other"
+	c := NewClient(t, p)
+
+	_, err := c.Exchange(context.Background(), openidtest.Code, "nonce")
+	assert.NotNil(t, err)
+}
+
+// TestClient_Exchange_InvalidCode tests failing to trade an unknown authorization code.
+func TestClient_Exchange_InvalidCode(t *testing.T) {
+	p := openidtest.NewProvider()
+	defer p.Close()
+	c := NewClient(t, p)
+
+	_, err := c.Exchange(context.Background(), "other", "nonce")
+	assert.NotNil(t, err)
+}
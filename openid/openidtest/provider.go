@@ -0,0 +1,152 @@
+// Package openidtest provides a stub OpenID Connect identity provider for tests.
+package openidtest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin/openid"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// client registered with the provider, and the authorization code it issues.
+const (
+	ClientID = "treasure-coin"
+	Code     = "code"
+)
+
+// Provider is a stub OpenID Connect identity provider, signing every user in at once with its claims.
+type Provider struct {
+	*httptest.Server
+
+	key *rsa.PrivateKey
+
+	// Claims are the claims of the id tokens issued. The nonce of the last sign in is added unless set.
+	Claims map[string]interface{}
+
+	mu    sync.Mutex
+	nonce string
+}
+
+// NewProvider returns a new running stub identity provider.
+func NewProvider() *Provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	p := &Provider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/keys", p.keys)
+	mux.HandleFunc("/auth", p.auth)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+
+	p.Claims = map[string]interface{}{
+		"iss":            p.URL,
+		"sub":            "luffy",
+		"aud":            ClientID,
+		"email":          "luffy@treasure.coin",
+		"email_verified": true,
+		"name":           "Monkey D. Luffy",
+	}
+	return p
+}
+
+// Config returns the client config of the provider, sending the users back to the redirect url.
+func (p *Provider) Config(redirect string) openid.Config {
+	return openid.Config{
+		Name:         "Stub",
+		Issuer:       p.URL,
+		ClientID:     ClientID,
+		ClientSecret: "secret",
+		RedirectURL:  redirect,
+	}
+}
+
+// Client returns a new client connected to the provider.
+func (p *Provider) Client(redirect string) (*openid.Client, error) {
+	return openid.NewClient(context.Background(), p.Config(redirect))
+}
+
+// discovery serves the provider discovery document.
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                p.URL,
+		"authorization_endpoint":                p.URL + "/auth",
+		"token_endpoint":                        p.URL + "/token",
+		"jwks_uri":                              p.URL + "/keys",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+// keys serves the provider signing keys.
+func (p *Provider) keys(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{{Key: &p.key.PublicKey, KeyID: "1", Algorithm: "RS256", Use: "sig"}},
+	})
+}
+
+// auth signs the user in, sending them back to the client with the authorization code.
+func (p *Provider) auth(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("client_id") != ClientID {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	p.nonce = q.Get("nonce")
+	p.mu.Unlock()
+
+	redirect.RawQuery = url.Values{"code": {Code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token trades the authorization code for a signed id token.
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if r.PostForm.Get("code") != Code {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: p.key, KeyID: "1"}}, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	p.mu.Lock()
+	claims := map[string]interface{}{
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": p.nonce,
+	}
+	p.mu.Unlock()
+	for k, v := range p.Claims {
+		claims[k] = v
+	}
+	raw, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		panic(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     raw,
+	})
+}
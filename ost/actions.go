@@ -0,0 +1,249 @@
+package ost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// OST action kinds.
+const (
+	KindCompanyToUser = "company_to_user"
+	KindUserToCompany = "user_to_company"
+	KindUserToUser    = "user_to_user"
+)
+
+// CurrencyBT is the currency of actions priced in branded tokens.
+const CurrencyBT = "BT"
+
+// Action represents an OST action.
+type Action struct {
+	ID        int         `json:"id"`
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind"`
+	Currency  string      `json:"currency"`
+	Arbitrary bool        `json:"arbitrary_amount"`
+	Amount    coin.Amount `json:"amount"`
+}
+
+// Actions are the ids of the OST actions used by the platform.
+type Actions struct {
+	// company-to-user reward for finding a treasure.
+	Reward int
+	// user-to-company payment for creating a game.
+	Payment int
+	// user-to-company return of the remaining balance.
+	Decrease int
+	// user-to-user transfer between players.
+	Transfer int
+	// user-to-user tip to the creator of a game.
+	Tip int
+}
+
+// id returns the action id used for a role.
+func (a *Actions) id(role string) *int {
+	switch role {
+	case "reward":
+		return &a.Reward
+	case "payment":
+		return &a.Payment
+	case "decrease":
+		return &a.Decrease
+	case "transfer":
+		return &a.Transfer
+	case "tip":
+		return &a.Tip
+	}
+	return nil
+}
+
+// event returns the event of a transaction with the supplied action, sent from the wallet.
+func (a Actions) event(action, from, company string) string {
+	switch action {
+	case strconv.Itoa(a.Reward):
+		return coin.EventTreasureFound
+	case strconv.Itoa(a.Payment):
+		return coin.EventGameCreated
+	case strconv.Itoa(a.Decrease):
+		return coin.EventTokensReturned
+	case strconv.Itoa(a.Transfer):
+		return coin.EventTransfer
+	case strconv.Itoa(a.Tip):
+		return coin.EventTip
+	}
+
+	// airdrops are the only other company transfers.
+	if from == company {
+		return coin.EventAirdrop
+	}
+	return coin.EventTransfer
+}
+
+// actionSpec describes an OST action required by the platform.
+type actionSpec struct {
+	role string
+	Action
+}
+
+// requiredActions are the OST actions the platform needs, created with these settings when missing.
+var requiredActions = []actionSpec{
+	{role: "reward", Action: Action{Name: "Treasure Reward", Kind: KindCompanyToUser, Currency: CurrencyBT, Amount: coin.TreasurePrice}},
+	{role: "payment", Action: Action{Name: "Game Payment", Kind: KindUserToCompany, Currency: CurrencyBT, Arbitrary: true}},
+	{role: "decrease", Action: Action{Name: "Balance Return", Kind: KindUserToCompany, Currency: CurrencyBT, Arbitrary: true}},
+	{role: "transfer", Action: Action{Name: "Player Transfer", Kind: KindUserToUser, Currency: CurrencyBT, Arbitrary: true}},
+	{role: "tip", Action: Action{Name: "Creator Tip", Kind: KindUserToUser, Currency: CurrencyBT, Arbitrary: true}},
+}
+
+// check returns why the action cannot be used for the spec, or an empty string.
+func (s actionSpec) check(a Action) string {
+	switch {
+	case a.Kind != s.Kind:
+		return fmt.Sprintf("%s action %d (%s) is %s, expected %s", s.role, a.ID, a.Name, a.Kind, s.Kind)
+	case a.Currency != s.Currency:
+		return fmt.Sprintf("%s action %d (%s) is priced in %s, expected %s", s.role, a.ID, a.Name, a.Currency, s.Currency)
+	case a.Arbitrary != s.Arbitrary:
+		return fmt.Sprintf("%s action %d (%s) arbitrary amount is %t, expected %t", s.role, a.ID, a.Name, a.Arbitrary, s.Arbitrary)
+	case !a.Arbitrary && a.Amount.Sign() <= 0:
+		return fmt.Sprintf("%s action %d (%s) has no amount", s.role, a.ID, a.Name)
+	case !a.Arbitrary && a.Amount != s.Amount:
+		// creators pay the reward of each treasure upfront, so the rewards must match the price.
+		return fmt.Sprintf("%s action %d (%s) amount is %s, expected %s", s.role, a.ID, a.Name, a.Amount, s.Amount)
+	}
+	return ""
+}
+
+// SetupActions resolves the OST actions used by the platform, validating the configured ones.
+// Actions without a configured id are looked up by name, and created if enabled in the config.
+// The reward action must pay coin.TreasurePrice, the price creators pay for each treasure.
+// It returns an *ActionsError listing every problem found in the company.
+func (c *Client) SetupActions(ctx context.Context) error {
+	actions, err := c.ListActions(ctx)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]Action)
+	byName := make(map[string]Action)
+	for _, a := range actions {
+		byID[a.ID] = a
+		byName[a.Name] = a
+	}
+
+	resolved := Actions{}
+	var reward coin.Amount
+	var problems []string
+	for _, s := range requiredActions {
+		var a Action
+		var ok bool
+		if id := *c.configured.id(s.role); id != 0 {
+			// validate the configured action.
+			if a, ok = byID[id]; !ok {
+				problems = append(problems, fmt.Sprintf("%s action %d does not exist", s.role, id))
+				continue
+			}
+		} else if a, ok = byName[s.Name]; !ok {
+			// create the missing action.
+			if !c.createActions {
+				problems = append(problems, fmt.Sprintf("%s action %q does not exist, configure its id or enable action creation", s.role, s.Name))
+				continue
+			}
+			if a, err = c.CreateAction(ctx, s.Action); err != nil {
+				problems = append(problems, fmt.Sprintf("%s action %q could not be created: %s", s.role, s.Name, err))
+				continue
+			}
+		}
+
+		if p := s.check(a); p != "" {
+			problems = append(problems, p)
+			continue
+		}
+		*resolved.id(s.role) = a.ID
+		if s.role == "reward" {
+			reward = a.Amount
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ActionsError{Problems: problems}
+	}
+
+	c.actions = resolved
+	c.rewardAmount = reward
+	c.logger.WithContext(ctx).WithFields(log.Fields{"reward": resolved.Reward, "payment": resolved.Payment, "decrease": resolved.Decrease, "transfer": resolved.Transfer, "tip": resolved.Tip}).Info("OST actions ready")
+	return nil
+}
+
+// RewardAmount returns the amount rewarded for finding a treasure, known after SetupActions.
+func (c *Client) RewardAmount() coin.Amount {
+	return c.rewardAmount
+}
+
+// ListActions retrieves every action of the company from OST.
+func (c *Client) ListActions(ctx context.Context) ([]Action, error) {
+	actions := make([]Action, 0)
+	for page := 1; page > 0; {
+		var data struct {
+			Actions []Action `json:"actions"`
+			Meta    struct {
+				NextPagePayload struct {
+					PageNo int `json:"page_no"`
+				} `json:"next_page_payload"`
+			} `json:"meta"`
+		}
+		err := c.do(ctx, request{
+			method:   http.MethodGet,
+			resource: "/actions/",
+			query: map[string]string{
+				"page_no": strconv.Itoa(page),
+				"limit":   "100",
+			},
+			idempotent: true,
+		}, &data)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, data.Actions...)
+
+		next := data.Meta.NextPagePayload.PageNo
+		if next <= page || len(data.Actions) == 0 {
+			next = 0
+		}
+		page = next
+	}
+	return actions, nil
+}
+
+// CreateAction creates a new action in the company.
+func (c *Client) CreateAction(ctx context.Context, action Action) (Action, error) {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"name": action.Name, "kind": action.Kind}).Info("creating action in OST API")
+
+	query := map[string]string{
+		"name":                 action.Name,
+		"kind":                 action.Kind,
+		"currency":             action.Currency,
+		"arbitrary_amount":     strconv.FormatBool(action.Arbitrary),
+		"arbitrary_commission": "false",
+	}
+	if !action.Arbitrary {
+		query["amount"] = action.Amount.String()
+	}
+
+	var data struct {
+		Action Action `json:"action"`
+	}
+	err := c.do(ctx, request{
+		method:   http.MethodPost,
+		resource: "/actions/",
+		query:    query,
+	}, &data)
+	if err != nil {
+		return Action{}, err
+	}
+
+	c.logger.WithContext(ctx).WithFields(log.Fields{"name": action.Name, "id": data.Action.ID}).Info("action created in OST API")
+	return data.Action, nil
+}
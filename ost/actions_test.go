@@ -0,0 +1,88 @@
+package ost_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/ost"
+	"github.com/stretchr/testify/assert"
+)
+
+// ActionsStub is a stub of the OST actions API.
+type ActionsStub struct {
+	actions []ost.Action
+	created []string
+}
+
+// ServeHTTP lists and creates the stub actions.
+func (s *ActionsStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		r.ParseForm()
+		a := ost.Action{
+			ID:        len(s.actions) + 1,
+			Name:      r.PostForm.Get("name"),
+			Kind:      r.PostForm.Get("kind"),
+			Currency:  r.PostForm.Get("currency"),
+			Arbitrary: r.PostForm.Get("arbitrary_amount") == "true",
+		}
+		a.Amount.UnmarshalText([]byte(r.PostForm.Get("amount")))
+		s.actions = append(s.actions, a)
+		s.created = append(s.created, a.Name)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": map[string]interface{}{"action": a}})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": map[string]interface{}{"actions": s.actions}})
+}
+
+// TestClient_SetupActions_Create tests creating the missing actions.
+func TestClient_SetupActions_Create(t *testing.T) {
+	stub := &ActionsStub{actions: []ost.Action{{ID: 7, Name: "Game Payment", Kind: ost.KindUserToCompany, Currency: ost.CurrencyBT, Arbitrary: true}}}
+	s := httptest.NewServer(stub)
+	defer s.Close()
+
+	c := ost.NewClient(ost.Config{Url: s.URL, Company: "company", CreateActions: true})
+	assert.Nil(t, c.SetupActions(context.Background()))
+	assert.Equal(t, []string{"Treasure Reward", "Balance Return", "Player Transfer", "Creator Tip"}, stub.created)
+	assert.Equal(t, "0.1", c.RewardAmount().String())
+}
+
+// TestClient_SetupActions_Report tests reporting every misconfigured action.
+func TestClient_SetupActions_Report(t *testing.T) {
+	stub := &ActionsStub{actions: []ost.Action{{ID: 7, Name: "Game Payment", Kind: ost.KindCompanyToUser, Currency: ost.CurrencyBT, Arbitrary: true}}}
+	s := httptest.NewServer(stub)
+	defer s.Close()
+
+	c := ost.NewClient(ost.Config{Url: s.URL, Company: "company", Actions: ost.Actions{Reward: 9}})
+	err := c.SetupActions(context.Background())
+	e, ok := err.(*ost.ActionsError)
+	assert.True(t, ok)
+	assert.Len(t, e.Problems, 5)
+	assert.Empty(t, stub.created)
+
+	// nothing can be paid without the actions.
+	_, err = c.MakePayment(context.Background(), "luffy", 1)
+	assert.Equal(t, ost.ErrActionNotConfigured, err)
+}
+
+// TestClient_SetupActions_RewardAmount tests refusing a reward action not paying the treasure price.
+func TestClient_SetupActions_RewardAmount(t *testing.T) {
+	stub := &ActionsStub{}
+	s := httptest.NewServer(stub)
+	defer s.Close()
+
+	c := ost.NewClient(ost.Config{Url: s.URL, Company: "company", CreateActions: true})
+	assert.Nil(t, c.SetupActions(context.Background()))
+	assert.Equal(t, coin.TreasurePrice, c.RewardAmount())
+
+	// the reward was changed in the company.
+	stub.actions[0].Amount = coin.TreasurePrice.Mul(2)
+	c = ost.NewClient(ost.Config{Url: s.URL, Company: "company"})
+	err := c.SetupActions(context.Background())
+	e, ok := err.(*ost.ActionsError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"reward action 1 (Treasure Reward) amount is 0.2, expected 0.1"}, e.Problems)
+}
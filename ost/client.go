@@ -1,20 +1,18 @@
 package ost
 
 import (
-	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/pmdcosta/treasure-coin"
 	log "github.com/sirupsen/logrus"
-	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -26,14 +24,31 @@ type Client struct {
 	apiKey    string
 	apiSecret string
 	companyID string
+
+	// secret signing the webhooks, the api secret by default.
+	webhookSecret string
+
+	// configured and resolved OST actions.
+	configured    Actions
+	actions       Actions
+	rewardAmount  coin.Amount
+	createActions bool
+
+	// request executor settings.
+	observer   Observer
+	http       *http.Client
+	maxRetries int
+	backoff    time.Duration
 }
 
 // Transaction represents an OST transaction between two wallets.
 type Transaction struct {
-	FromUserID string `json:"from_user_id"`
-	ToUserID   string `json:"to_user_id"`
-	TimeStamp  int    `json:"timestamp"`
-	Amount     string `json:"amount"`
+	ID         string      `json:"id"`
+	FromUserID string      `json:"from_user_id"`
+	ToUserID   string      `json:"to_user_id"`
+	ActionID   json.Number `json:"action_id"`
+	TimeStamp  int         `json:"timestamp"`
+	Amount     coin.Amount `json:"amount"`
 }
 
 // NewClient returns a new configuration client.
@@ -44,6 +59,35 @@ func NewClient(config Config) *Client {
 		apiKey:    config.Key,
 		apiSecret: config.Secret,
 		companyID: config.Company,
+
+		webhookSecret: config.WebhookSecret,
+
+		configured:    config.Actions,
+		actions:       config.Actions,
+		createActions: config.CreateActions,
+
+		observer:   config.Observer,
+		http:       config.HTTPClient,
+		maxRetries: config.MaxRetries,
+		backoff:    DefaultBackoff,
+	}
+
+	if c.webhookSecret == "" {
+		c.webhookSecret = c.apiSecret
+	}
+
+	// apply the executor defaults.
+	if c.http == nil {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		c.http = &http.Client{Timeout: timeout}
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = DefaultMaxRetries
+	} else if c.maxRetries < 0 {
+		c.maxRetries = 0
 	}
 	return c
 }
@@ -58,372 +102,313 @@ func (c *Client) CreateSignature(resource, q string) string {
 }
 
 // GetUserBalance retrieves the user balance from OST.
-func (c *Client) GetUserBalance(user string) (string, error) {
-	// build the request.
-	t := fmt.Sprintf("%d", time.Now().Unix())
-	r := fmt.Sprintf("/users/%s/", user)
-	query := map[string]string{
-		"request_timestamp": t,
-		"api_key":           c.apiKey,
-		"id":                user,
-	}
-	u, err := c.BuildRequest(c.url, r, query)
+func (c *Client) GetUserBalance(ctx context.Context, user string) (coin.Amount, error) {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"id": user}).Info("getting user balance from OST API")
+
+	var data struct {
+		User struct {
+			Balance coin.Amount `json:"token_balance"`
+		} `json:"user"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		resource:   fmt.Sprintf("/users/%s/", user),
+		query:      map[string]string{"id": user},
+		idempotent: true,
+	}, &data)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
 
-	c.logger.WithFields(log.Fields{"id": user}).Info("getting user balance from OST API")
+	balance := data.User.Balance
 
-	// make the request.
-	response, err := http.Get(u.String())
-	if err != nil {
-		return "", err
-	}
-
-	// parse the response.
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return "", err
-	}
-
-	if response.StatusCode != 200 {
-		return "", errors.New("Invalid status code received: " + response.Status + " | " + string(contents))
-	}
-
-	// unmarhal the response.
-	type GetUserResponse struct {
-		Success bool `json:"success"`
-		Data    struct {
-			User struct {
-				Balance string `json:"token_balance"`
-			} `json:"user"`
-		} `json:"data"`
-	}
-	var resp GetUserResponse
-	json.Unmarshal(contents, &resp)
-
-	balance := resp.Data.User.Balance
-
-	c.logger.WithFields(log.Fields{"id": user, "balance": balance}).Info("user balance retrieved from OST API")
+	c.logger.WithContext(ctx).WithFields(log.Fields{"id": user, "balance": balance}).Info("user balance retrieved from OST API")
 
 	return balance, nil
 }
 
 // CreateUser creates a new user account in the OST platform.
-func (c *Client) CreateUser(user string) (string, error) {
-	// build the request.
-	t := fmt.Sprintf("%d", time.Now().Unix())
-	r := "/users/"
-	query := map[string]string{
-		"request_timestamp": t,
-		"api_key":           c.apiKey,
-		"name":              user,
-	}
-	u, err := c.BuildRequest(c.url, r, query)
+func (c *Client) CreateUser(ctx context.Context, user string) (string, error) {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"name": user}).Info("creating user account in OST API")
+
+	var data struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	err := c.do(ctx, request{
+		method:   http.MethodPost,
+		resource: "/users/",
+		query:    map[string]string{"name": user},
+	}, &data)
 	if err != nil {
 		return "", err
 	}
 
-	c.logger.WithFields(log.Fields{"name": user}).Info("creating user account in OST API")
-
-	// make the request.
-	response, err := http.Post(u.String(), "application/x-www-form-urlencoded", bytes.NewBuffer([]byte(u.RawQuery)))
-	if err != nil {
-		return "", err
-	}
+	id := data.User.ID
 
-	// parse the response.
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return "", err
-	}
-
-	if response.StatusCode != 200 {
-		return "", errors.New("Invalid status code received: " + response.Status + " | " + string(contents))
-	}
-
-	// response struct.
-	type CreateUserResponse struct {
-		Success bool `json:"success"`
-		Data    struct {
-			User struct {
-				ID string `json:"id"`
-			} `json:"user"`
-		} `json:"data"`
-	}
-
-	// unmarhal the response.
-	var resp CreateUserResponse
-	json.Unmarshal(contents, &resp)
-
-	id := resp.Data.User.ID
-
-	c.logger.WithFields(log.Fields{"name": user, "id": id}).Info("user account created in OST API")
+	c.logger.WithContext(ctx).WithFields(log.Fields{"name": user, "id": id}).Info("user account created in OST API")
 
 	return id, nil
 }
 
-// GetUserTransactions retrieves the last 10 transactions from OST.
-func (c *Client) GetUserTransactions(user string) ([]coin.Transaction, error) {
-	// build the request.
-	t := fmt.Sprintf("%d", time.Now().Unix())
-	r := fmt.Sprintf("/ledger/%s/", user)
-	query := map[string]string{
-		"request_timestamp": t,
-		"api_key":           c.apiKey,
-		"page_no":           "1",
-	}
-	u, err := c.BuildRequest(c.url, r, query)
+// LedgerPageSize is how many transactions are requested per ledger page.
+const LedgerPageSize = 25
+
+// GetUserTransactions retrieves the most recent page of transactions from OST.
+func (c *Client) GetUserTransactions(ctx context.Context, user string) ([]coin.Transaction, error) {
+	transactions, _, err := c.ledgerPage(ctx, user, 1)
 	if err != nil {
 		return []coin.Transaction{}, err
 	}
+	return transactions, nil
+}
 
-	c.logger.WithFields(log.Fields{"id": user}).Info("getting user transactions from the OST API")
-
-	// make the request.
-	response, err := http.Get(u.String())
-	if err != nil {
-		return nil, err
+// EachUserTransaction calls fn for every transaction in the user ledger, newest first.
+// It fetches the ledger pages as needed and stops at the first error, returned as is.
+func (c *Client) EachUserTransaction(ctx context.Context, user string, fn func(coin.Transaction) error) error {
+	for page := 1; page > 0; {
+		transactions, next, err := c.ledgerPage(ctx, user, page)
+		if err != nil {
+			return err
+		}
+		for _, t := range transactions {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+		page = next
 	}
+	return nil
+}
 
-	// parse the response.
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
+// ledgerPage retrieves a page of the user ledger from OST, returning the number of the next page or zero.
+func (c *Client) ledgerPage(ctx context.Context, user string, page int) ([]coin.Transaction, int, error) {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"id": user, "page": page}).Info("getting user transactions from the OST API")
+
+	var data struct {
+		Transactions []Transaction `json:"transactions"`
+		Meta         struct {
+			NextPagePayload struct {
+				PageNo int `json:"page_no"`
+			} `json:"next_page_payload"`
+		} `json:"meta"`
+	}
+	err := c.do(ctx, request{
+		method:   http.MethodGet,
+		resource: fmt.Sprintf("/ledger/%s/", user),
+		query: map[string]string{
+			"page_no": strconv.Itoa(page),
+			"limit":   strconv.Itoa(LedgerPageSize),
+		},
+		idempotent: true,
+	}, &data)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	if response.StatusCode != 200 {
-		return []coin.Transaction{}, errors.New("Invalid status code received: " + response.Status + " | " + string(contents))
-	}
-
-	// response struct.
-	type GetUserResponse struct {
-		Success bool `json:"success"`
-		Data    struct {
-			Transactions []Transaction `json:"transactions"`
-		} `json:"data"`
-	}
-
-	// unmarhal the response.
-	var resp GetUserResponse
-	json.Unmarshal(contents, &resp)
-
-	c.logger.WithFields(log.Fields{"transactions": fmt.Sprintf("%+v", resp.Data.Transactions)}).Info("transactions retrieved from the OST API")
+	c.logger.WithContext(ctx).WithFields(log.Fields{"page": page, "transactions": len(data.Transactions)}).Debug("transactions retrieved from the OST API")
 
 	// format transaction data.
-	transactions := make([]coin.Transaction, 0)
-	for _, t := range resp.Data.Transactions {
+	transactions := make([]coin.Transaction, 0, len(data.Transactions))
+	for _, t := range data.Transactions {
 		tr := coin.Transaction{
+			ID:         t.ID,
 			FromWallet: t.FromUserID,
 			ToWallet:   t.ToUserID,
+			Event:      c.actions.event(t.ActionID.String(), t.FromUserID, c.companyID),
 			Date:       time.Unix(int64(t.TimeStamp/1000), 0),
 			Amount:     t.Amount,
 		}
-		if t.FromUserID == c.companyID {
-			tr.Event = "Treasure Found"
-			tr.Amount = "+" + tr.Amount
-		} else {
-			tr.Event = "Game Created"
-			tr.Amount = "-" + tr.Amount
+		if t.ToUserID != user {
+			tr.Amount = tr.Amount.Neg()
 		}
 		transactions = append(transactions, tr)
 	}
 
-	return transactions, nil
+	// guard against a provider repeating the same page.
+	next := data.Meta.NextPagePayload.PageNo
+	if next <= page || len(data.Transactions) == 0 {
+		next = 0
+	}
+	return transactions, next, nil
 }
 
 // Airdrop adds TreasureCoins to a user's balance from OST.
-func (c *Client) Airdrop(user string, amount float64) error {
-	t := fmt.Sprintf("%d", time.Now().Unix())
-	a := fmt.Sprintf("%f", amount)
-	r := "/airdrops/"
-	query := map[string]string{
-		"request_timestamp": t,
-		"api_key":           c.apiKey,
-		"amount":            a,
-		"user_ids":          user,
-	}
-	u, err := c.BuildRequest(c.url, r, query)
+func (c *Client) Airdrop(ctx context.Context, user string, amount coin.Amount) error {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"amount": amount, "user": user}).Info("airdropping tokens using the OST API")
+
+	err := c.do(ctx, request{
+		method:   http.MethodPost,
+		resource: "/airdrops/",
+		query: map[string]string{
+			"amount":   amount.String(),
+			"user_ids": user,
+		},
+	}, nil)
 	if err != nil {
 		return err
 	}
 
-	c.logger.WithFields(log.Fields{"amount": amount, "user": user}).Info("airdropping tokens using the OST API")
+	c.logger.WithContext(ctx).WithFields(log.Fields{"amount": amount, "user": user}).Info("tokens airdropped using the OST API")
+	return nil
+}
 
-	// make the request.
-	response, err := http.Post(u.String(), "application/x-www-form-urlencoded", bytes.NewBuffer([]byte(u.RawQuery)))
-	if err != nil {
-		return err
-	}
+// GetRewarded makes a company-to-user transaction request to OST, returning the transaction id.
+func (c *Client) GetRewarded(ctx context.Context, user string) (string, error) {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"from": c.companyID, "to": user}).Info("executing company-to-user token transfer using the OST API")
 
-	// parse the response.
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
+	id, err := c.executeTransaction(ctx, c.actions.Reward, map[string]string{
+		"from_user_id": c.companyID,
+		"to_user_id":   user,
+	})
 	if err != nil {
-		return err
-	}
-
-	type GetUserResponse struct {
-		Success bool `json:"success"`
-		Error   struct {
-			Msg string `json:"msg"`
-		} `json:"err"`
+		return "", err
 	}
 
-	var resp GetUserResponse
-	json.Unmarshal(contents, &resp)
+	c.logger.WithContext(ctx).WithFields(log.Fields{"from": c.companyID, "to": user, "transaction": id}).Info("tokens transferred using the OST API")
 
-	if !resp.Success {
-		return errors.New(resp.Error.Msg)
-	}
-
-	c.logger.WithFields(log.Fields{"amount": amount, "user": user}).Info("tokens airdropped using the OST API")
-	return nil
+	return id, nil
 }
 
-// GetRewarded makes a company-to-user transaction request to OST.
-func (c *Client) GetRewarded(user string) error {
-	// build the request.
-	t := fmt.Sprintf("%d", time.Now().Unix())
-	r := "/transactions/"
-	query := map[string]string{
-		"request_timestamp": t,
-		"api_key":           c.apiKey,
-		"action_id":         "39879",
-		"from_user_id":      c.companyID,
-		"to_user_id":        user,
-	}
-	u, err := c.BuildRequest(c.url, r, query)
-	if err != nil {
-		return err
-	}
-
-	c.logger.WithFields(log.Fields{"from": c.companyID, "to": user}).Info("executing company-to-user token transfer using the OST API")
+// MakePayment makes a user-to-company transaction request to OST, returning the transaction id.
+func (c *Client) MakePayment(ctx context.Context, user string, amount coin.Amount) (string, error) {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"from": user, "to": c.companyID}).Info("executing user-to-company token transfer using the OST API")
 
-	// make the request.
-	response, err := http.Post(u.String(), "application/x-www-form-urlencoded", bytes.NewBuffer([]byte(u.RawQuery)))
+	id, err := c.executeTransaction(ctx, c.actions.Payment, map[string]string{
+		"from_user_id": user,
+		"to_user_id":   c.companyID,
+		"amount":       amount.String(),
+		"currency":     "BT",
+	})
 	if err != nil {
-		return err
-	}
-
-	// parse the response.
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return err
-	}
-
-	if response.StatusCode != 200 {
-		return errors.New("Invalid status code received: " + response.Status + " | " + string(contents))
+		return "", err
 	}
 
-	c.logger.WithFields(log.Fields{"from": c.companyID, "to": user}).Info("tokens transferred using the OST API")
+	c.logger.WithContext(ctx).WithFields(log.Fields{"from": user, "to": c.companyID, "transaction": id}).Info("tokens transferred using the OST API")
 
-	return nil
+	return id, nil
 }
 
-// GetPayed makes a user-to-company transaction request to OST.
-func (c *Client) MakePayment(user string, amount int) error {
-	tokens := fmt.Sprintf("%f", float32(amount)*0.1)
-
-	// build the request.
-	t := fmt.Sprintf("%d", time.Now().Unix())
-	r := "/transactions/"
-	query := map[string]string{
-		"request_timestamp": t,
-		"api_key":           c.apiKey,
-		"from_user_id":      user,
-		"to_user_id":        c.companyID,
-		"action_id":         "39876",
-		"amount":            tokens,
-		"currency":          "BT",
-	}
-	u, err := c.BuildRequest(c.url, r, query)
-	if err != nil {
-		return err
-	}
+// Transfer makes a user-to-user transaction request to OST, returning the transaction id.
+func (c *Client) Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	return c.sendCoins(ctx, c.actions.Transfer, from, to, amount)
+}
 
-	c.logger.WithFields(log.Fields{"from": user, "to": c.companyID}).Info("executing user-to-company token transfer using the OST API")
+// Tip makes a user-to-user transaction request to OST tipping the creator of a game, returning the transaction id.
+func (c *Client) Tip(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	return c.sendCoins(ctx, c.actions.Tip, from, to, amount)
+}
 
-	// make the request.
-	response, err := http.Post(u.String(), "application/x-www-form-urlencoded", bytes.NewBuffer([]byte(u.RawQuery)))
-	if err != nil {
-		return err
-	}
+// sendCoins executes a user-to-user action, returning the transaction id.
+func (c *Client) sendCoins(ctx context.Context, action int, from, to string, amount coin.Amount) (string, error) {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"from": from, "to": to, "amount": amount}).Info("executing user-to-user token transfer using the OST API")
 
-	// parse the response.
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
+	id, err := c.executeTransaction(ctx, action, map[string]string{
+		"from_user_id": from,
+		"to_user_id":   to,
+		"amount":       amount.String(),
+		"currency":     "BT",
+	})
 	if err != nil {
-		return err
-	}
-
-	if response.StatusCode != 200 {
-		return errors.New("Invalid status code received: " + response.Status + " | " + string(contents))
+		return "", err
 	}
 
-	c.logger.WithFields(log.Fields{"from": user, "to": c.companyID}).Info("tokens transferred using the OST API")
+	c.logger.WithContext(ctx).WithFields(log.Fields{"from": from, "to": to, "transaction": id}).Info("tokens transferred using the OST API")
 
-	return nil
+	return id, nil
 }
 
 // DecreaseTokens removes tokens from clients and returns them to the pool.
-func (c *Client) DecreaseTokens(user string, amount float64) error {
-	tokens := fmt.Sprintf("%f", amount)
-
-	// build the request.
-	t := fmt.Sprintf("%d", time.Now().Unix())
-	r := "/transactions/"
-	query := map[string]string{
-		"request_timestamp": t,
-		"api_key":           c.apiKey,
-		"from_user_id":      user,
-		"to_user_id":        c.companyID,
-		"action_id":         "39928",
-		"amount":            tokens,
-		"currency":          "BT",
-	}
-	u, err := c.BuildRequest(c.url, r, query)
-	if err != nil {
-		return err
-	}
+func (c *Client) DecreaseTokens(ctx context.Context, user string, amount coin.Amount) error {
+	_, err := c.executeTransaction(ctx, c.actions.Decrease, map[string]string{
+		"from_user_id": user,
+		"to_user_id":   c.companyID,
+		"amount":       amount.String(),
+		"currency":     "BT",
+	})
+	return err
+}
 
-	// make the request.
-	response, err := http.Post(u.String(), "application/x-www-form-urlencoded", bytes.NewBuffer([]byte(u.RawQuery)))
+// RemoveTokens returns the full balance of a user to the pool.
+func (c *Client) RemoveTokens(ctx context.Context, user string) error {
+	amount, err := c.GetUserBalance(ctx, user)
 	if err != nil {
 		return err
 	}
 
-	// parse the response.
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return err
+	// nothing to return.
+	if amount.Sign() <= 0 {
+		return nil
 	}
 
-	if response.StatusCode != 200 {
-		return errors.New("Invalid status code received: " + response.Status + " | " + string(contents))
+	return c.DecreaseTokens(ctx, user, amount)
+}
+
+// executeTransaction executes an action between two wallets, returning the transaction id.
+func (c *Client) executeTransaction(ctx context.Context, action int, query map[string]string) (string, error) {
+	if action == 0 {
+		return "", ErrActionNotConfigured
 	}
+	query["action_id"] = strconv.Itoa(action)
 
-	return nil
+	var data struct {
+		Transaction struct {
+			ID string `json:"id"`
+		} `json:"transaction"`
+	}
+	err := c.do(ctx, request{
+		method:   http.MethodPost,
+		resource: "/transactions/",
+		query:    query,
+	}, &data)
+	return data.Transaction.ID, err
 }
 
-func (c *Client) RemoveTokens(user string) error {
-	s, err := c.GetUserBalance(user)
-	if err != nil {
-		return err
+// Ping checks the OST API can be reached with the credentials, without retrying.
+func (c *Client) Ping(ctx context.Context) error {
+	r := request{
+		method:   http.MethodGet,
+		resource: "/actions/",
+		query:    map[string]string{"limit": "1"},
 	}
+	start := time.Now()
+	_, err := c.attempt(ctx, r, nil)
+	c.observe(r, time.Since(start), err)
+	return err
+}
 
-	amount, err := strconv.ParseFloat(s, 64)
+// GetTransactionStatus retrieves the status of a transaction from OST.
+func (c *Client) GetTransactionStatus(ctx context.Context, id string) (coin.TransferStatus, error) {
+	c.logger.WithContext(ctx).WithFields(log.Fields{"transaction": id}).Debug("getting transaction status from the OST API")
+
+	var data struct {
+		Transaction struct {
+			Status string `json:"status"`
+		} `json:"transaction"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		resource:   fmt.Sprintf("/transactions/%s/", id),
+		query:      map[string]string{"id": id},
+		idempotent: true,
+	}, &data)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	err = c.DecreaseTokens(user, amount)
-	return err
+	return transferStatus(data.Transaction.Status), nil
+}
+
+// transferStatus maps an OST transaction status to a transfer status.
+func transferStatus(status string) coin.TransferStatus {
+	switch strings.ToLower(status) {
+	case "complete", "success":
+		return coin.TransferComplete
+	case "failed", "failure":
+		return coin.TransferFailed
+	}
+	return coin.TransferPending
 }
 
 // BuildRequest builds the OST request params.
@@ -456,31 +441,20 @@ type Config struct {
 	Secret  string
 	Url     string
 	Company string
-}
 
-func (c *Config) LoadCred(config, ostUrl, ostKey, ostSecret, ostCompany string) {
-	// if the OST credentials are not provided use the '.env file'.
-	file, err := os.Open(config)
-	if err != nil {
-		panic(err)
-	}
-
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&c)
-	if err != nil {
-		panic(err)
-	}
-
-	if ostUrl != "" {
-		c.Url = ostUrl
-	}
-	if ostKey != "" {
-		c.Key = ostKey
-	}
-	if ostSecret != "" {
-		c.Secret = ostSecret
-	}
-	if ostCompany != "" {
-		c.Company = ostCompany
-	}
+	// Timeout bounds every request, defaults to DefaultTimeout.
+	Timeout time.Duration
+	// MaxRetries is how many times idempotent requests are retried on transient failures,
+	// defaults to DefaultMaxRetries, negative disables retries.
+	MaxRetries int
+	// Actions are the ids of the OST actions, looked up by name when zero.
+	Actions Actions
+	// CreateActions creates the missing OST actions on setup.
+	CreateActions bool
+	// WebhookSecret verifies the webhook signatures, defaults to Secret.
+	WebhookSecret string
+	// HTTPClient overrides the client used to reach the API, ignoring Timeout.
+	HTTPClient *http.Client `json:"-"`
+	// Observer is told about every request made to the API, if set.
+	Observer Observer `json:"-"`
 }
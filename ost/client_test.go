@@ -1,90 +1,243 @@
 package ost_test
 
 import (
-	"fmt"
+	"context"
+	"github.com/pmdcosta/treasure-coin"
 	"github.com/pmdcosta/treasure-coin/ost"
+	"github.com/pmdcosta/treasure-coin/ost/osttest"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strconv"
 	"testing"
+	"time"
 )
 
 // Client is a test wrapper.
 type Client struct {
 	*ost.Client
+	Server *osttest.Server
 }
 
-// NewClient returns a new instance of Client.
+// NewClient returns a new instance of Client, connected to a fake OST API.
 func NewClient() *Client {
 	log.SetLevel(log.DebugLevel)
 
-	cred := ost.Config{}
-	cred.LoadCred("../.env", "", "", "", "")
-
+	s := osttest.NewServer()
 	c := &Client{
-		Client: ost.NewClient(cred),
+		Client: s.Client(),
+		Server: s,
 	}
 	return c
 }
 
+// Close stops the fake OST API.
+func (c *Client) Close() {
+	c.Server.Close()
+}
+
 // TestClient_CreateSignature tests creating a signature.
 func TestClient_CreateSignature(t *testing.T) {
-	c := NewClient()
+	c := ost.NewClient(ost.Config{Secret: "secret"})
 	sig := c.CreateSignature("/users/ID/", "api_key=KEY&id=ID&request_timestamp=TIME")
-	assert.Equal(t, "28e9035850612343fdd46a38d5c35f451e0035680509572e56cd4f984987ebc9", sig)
+	assert.Equal(t, "10975bf8aac4df68ced4f1e4ce94bcfe83a737d073e573fa6dd4c1d0cf1042c5", sig)
+}
+
+// TestClient_InvalidSignature tests the fake OST API rejecting requests signed with another secret.
+func TestClient_InvalidSignature(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	config := c.Server.Config()
+	config.Secret = "other"
+	_, err := ost.NewClient(config).GetUserBalance(context.Background(), osttest.UserWallet)
+	assert.Equal(t, ost.ErrUnauthorized, err.(*ost.Error).Kind)
 }
 
 // TestClient_GetUserBalance tests getting the user balance from OST.
 func TestClient_GetUserBalance(t *testing.T) {
 	c := NewClient()
-	b, err := c.GetUserBalance("5190fed7-dbfb-4687-b2c8-b5cd57002198")
+	defer c.Close()
+
+	b, err := c.GetUserBalance(context.Background(), osttest.UserWallet)
 	assert.Nil(t, err)
-	assert.Equal(t, "0", b)
+	assert.Equal(t, osttest.UserBalance, b.String())
+	assert.Equal(t, "/users/"+osttest.UserWallet+"/", c.Server.Requests()[0].Path)
 }
 
 // TestClient_CreateUser tests creating a new user using the API.
 func TestClient_CreateUser(t *testing.T) {
 	c := NewClient()
-	u, err := c.CreateUser("Luffy")
+	defer c.Close()
+
+	u, err := c.CreateUser(context.Background(), "Luffy")
 	assert.Nil(t, err)
-	fmt.Println(u)
+	assert.Equal(t, osttest.NewWallet, u)
+	assert.Equal(t, "Luffy", c.Server.Requests()[0].Params.Get("name"))
 }
 
 // TestClient_GetUserTransactions tests getting user transactions using the API.
 func TestClient_GetUserTransactions(t *testing.T) {
 	c := NewClient()
-	b, err := c.GetUserTransactions("87e9132d-0586-4beb-9600-ffa050966bc8")
+	defer c.Close()
+
+	b, err := c.GetUserTransactions(context.Background(), osttest.UserWallet)
 	assert.Nil(t, err)
-	assert.Equal(t, len(b), 1)
-	assert.Equal(t, b[0].Amount, "0.1")
-	assert.Equal(t, b[0].FromWallet, "9bc1ee0d-084b-4d75-b798-fda6a270adcc")
-	assert.Equal(t, b[0].ToWallet, "87e9132d-0586-4beb-9600-ffa050966bc8")
-	fmt.Println(b[0].Date)
+	assert.Equal(t, len(b), 3)
+	assert.Equal(t, osttest.Transaction, b[0].ID)
+	assert.Equal(t, coin.EventTreasureFound, b[0].Event)
+	assert.Equal(t, "+0.1", b[0].Amount.Signed())
+	assert.Equal(t, osttest.Company, b[0].FromWallet)
+	assert.Equal(t, osttest.UserWallet, b[0].ToWallet)
+	assert.Equal(t, time.Unix(1526392871, 0), b[0].Date)
+	assert.Equal(t, coin.EventGameCreated, b[1].Event)
+	assert.Equal(t, "-0.2", b[1].Amount.Signed())
+	assert.Equal(t, coin.EventAirdrop, b[2].Event)
 }
 
 // TestClient_Airdrop tests incrementing user's balance the API.
 func TestClient_Airdrop(t *testing.T) {
 	c := NewClient()
-	err := c.Airdrop("1bc46b40-2d76-4bfa-a806-b9ce1983ae8f", 0.1)
+	defer c.Close()
+
+	err := c.Airdrop(context.Background(), osttest.NewWallet, coin.Coin/10)
 	assert.Nil(t, err)
+
+	r := c.Server.Requests()[0]
+	assert.Equal(t, "0.1", r.Params.Get("amount"))
+	assert.Equal(t, osttest.NewWallet, r.Params.Get("user_ids"))
 }
 
 // TestClient_GetRewarded tests making a company to user transaction.
 func TestClient_GetRewarded(t *testing.T) {
 	c := NewClient()
-	err := c.GetRewarded("5190fed7-dbfb-4687-b2c8-b5cd57002198")
+	defer c.Close()
+
+	id, err := c.GetRewarded(context.Background(), osttest.UserWallet)
 	assert.Nil(t, err)
+	assert.Equal(t, osttest.Transaction, id)
+
+	r := c.Server.Requests()[0]
+	assert.Equal(t, "39879", r.Params.Get("action_id"))
+	assert.Equal(t, osttest.Company, r.Params.Get("from_user_id"))
+	assert.Equal(t, osttest.UserWallet, r.Params.Get("to_user_id"))
 }
 
 // TestClient_MakePayment tests making a user to company transaction.
 func TestClient_MakePayment(t *testing.T) {
 	c := NewClient()
-	err := c.MakePayment("5190fed7-dbfb-4687-b2c8-b5cd57002198", 2)
+	defer c.Close()
+
+	_, err := c.MakePayment(context.Background(), osttest.UserWallet, coin.TreasurePrice.Mul(2))
 	assert.Nil(t, err)
+
+	r := c.Server.Requests()[0]
+	assert.Equal(t, "39876", r.Params.Get("action_id"))
+	assert.Equal(t, "0.2", r.Params.Get("amount"))
+}
+
+// TestClient_MakePayment_InsufficientBalance tests failing to pay without enough coins.
+func TestClient_MakePayment_InsufficientBalance(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+	c.Server.Fail(osttest.EndpointTransaction, 1, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "Insufficient funds to make the transfer")
+
+	_, err := c.MakePayment(context.Background(), osttest.UserWallet, coin.Coin.Mul(100))
+	assert.Equal(t, ost.ErrInsufficientBalance, err.(*ost.Error).Kind)
 }
 
 // TestClient_RemoveTokens tests removing all tokens from a client transaction.
 func TestClient_RemoveTokens(t *testing.T) {
 	c := NewClient()
-	err := c.RemoveTokens("5190fed7-dbfb-4687-b2c8-b5cd57002198")
+	defer c.Close()
+
+	err := c.RemoveTokens(context.Background(), osttest.UserWallet)
+	assert.Nil(t, err)
+
+	r := c.Server.Requests()[1]
+	assert.Equal(t, "39928", r.Params.Get("action_id"))
+	assert.Equal(t, osttest.UserBalance, r.Params.Get("amount"))
+}
+
+// TestClient_Timeout tests aborting requests to a slow API.
+func TestClient_Timeout(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+	c.Server.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.GetUserBalance(ctx, osttest.UserWallet)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+// TestClient_SetupActions tests validating the recorded company actions.
+func TestClient_SetupActions(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	assert.Nil(t, c.SetupActions(context.Background()))
+	assert.Equal(t, osttest.RewardAmount, c.RewardAmount().String())
+	assert.Equal(t, 0, c.Server.Count(osttest.EndpointCreateAction))
+}
+
+// TestClient_GetTransactionStatus tests getting the status of a transaction.
+func TestClient_GetTransactionStatus(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	s, err := c.GetTransactionStatus(context.Background(), osttest.Transaction)
+	assert.Nil(t, err)
+	assert.Equal(t, coin.TransferComplete, s)
+	assert.Equal(t, "/transactions/"+osttest.Transaction+"/", c.Server.Requests()[0].Path)
+}
+
+// TestClient_ParseWebhook tests verifying and decoding a transaction webhook.
+func TestClient_ParseWebhook(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	h, body := osttest.Webhook("transactions/failure", osttest.Transaction, "FAILED")
+	tr, err := c.ParseWebhook(h, body)
+	assert.Nil(t, err)
+	assert.Equal(t, osttest.Transaction, tr.ID)
+	assert.Equal(t, coin.TransferFailed, tr.Status)
+
+	// other events carry no transfer.
+	h, body = osttest.Webhook("users/activation_success", "", "")
+	tr, err = c.ParseWebhook(h, body)
+	assert.Nil(t, err)
+	assert.Equal(t, "", tr.ID)
+
+	// tampered body.
+	h, body = osttest.Webhook("transactions/success", osttest.Transaction, "SUCCESS")
+	_, err = c.ParseWebhook(h, append(body, ' '))
+	assert.Equal(t, ost.ErrInvalidWebhook, err)
+
+	// expired signature.
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	h.Set(ost.WebhookTimestampHeader, old)
+	h.Set(ost.WebhookSignatureHeader, ost.SignWebhook(osttest.Secret, old, "v2", body))
+	_, err = c.ParseWebhook(h, body)
+	assert.Equal(t, ost.ErrInvalidWebhook, err)
+}
+
+// TestClient_Transfer tests making a user to user transaction.
+func TestClient_Transfer(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	id, err := c.Transfer(context.Background(), osttest.UserWallet, osttest.OtherWallet, coin.Coin/2)
+	assert.Nil(t, err)
+	assert.Equal(t, osttest.Transaction, id)
+
+	r := c.Server.Requests()[0]
+	assert.Equal(t, "39930", r.Params.Get("action_id"))
+	assert.Equal(t, osttest.UserWallet, r.Params.Get("from_user_id"))
+	assert.Equal(t, osttest.OtherWallet, r.Params.Get("to_user_id"))
+	assert.Equal(t, "0.5", r.Params.Get("amount"))
+
+	_, err = c.Tip(context.Background(), osttest.UserWallet, osttest.OtherWallet, coin.Coin)
 	assert.Nil(t, err)
+	assert.Equal(t, "39931", c.Server.Requests()[1].Params.Get("action_id"))
 }
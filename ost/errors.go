@@ -0,0 +1,84 @@
+package ost
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pmdcosta/treasure-coin"
+)
+
+// OST errors, matching the wallet errors shared by every provider.
+const (
+	ErrInsufficientBalance = coin.ErrInsufficientBalance
+	ErrInvalidUser         = coin.ErrInvalidWallet
+	ErrRateLimited         = coin.ErrRateLimited
+	ErrUnauthorized        = coin.ErrWalletAuth
+	ErrUnavailable         = coin.ErrWalletUnavailable
+	ErrInvalidWebhook      = coin.ErrInvalidWebhook
+
+	ErrActionNotConfigured = coin.Error("the action has not been set up")
+)
+
+// ActionsError reports the OST actions the company is missing or has misconfigured.
+type ActionsError struct {
+	Problems []string
+}
+
+// Error returns the report of every problem found.
+func (e *ActionsError) Error() string {
+	return "ost: the company actions are misconfigured:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Error represents a failed OST API request.
+type Error struct {
+	// http status code of the response, zero if no response was received.
+	StatusCode int
+
+	// error details from the OST response envelope.
+	Code    string
+	Message string
+
+	// Kind classifies the failure, nil if it matches none of the OST errors.
+	Kind error
+}
+
+// newError returns a new classified Error.
+func newError(status int, code, message string) *Error {
+	e := &Error{StatusCode: status, Code: code, Message: message}
+	e.Kind = e.classify()
+	return e
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("ost: request failed with status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("ost: request failed with status %d: %s (%s)", e.StatusCode, e.Message, e.Code)
+}
+
+// Unwrap returns the kind of the error, so it can be matched with errors.Is.
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// classify maps the response status and OST error details to one of the OST errors.
+func (e *Error) classify() error {
+	code := strings.ToLower(e.Code)
+	msg := strings.ToLower(e.Message)
+
+	switch {
+	case e.StatusCode == 0 || e.StatusCode >= http.StatusInternalServerError:
+		return ErrUnavailable
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden || strings.Contains(code, "unauthorized"):
+		return ErrUnauthorized
+	case strings.Contains(code, "insufficient") || strings.Contains(msg, "insufficient") || strings.Contains(msg, "not enough"):
+		return ErrInsufficientBalance
+	case e.StatusCode == http.StatusNotFound || strings.Contains(code, "not_found") || strings.Contains(msg, "invalid user") || strings.Contains(msg, "user not found"):
+		return ErrInvalidUser
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package osttest
+
+import "github.com/pmdcosta/treasure-coin/ost"
+
+// OST API endpoints served by the fake server.
+const (
+	EndpointCreateUser     = "POST /users/"
+	EndpointGetUser        = "GET /users/{id}/"
+	EndpointLedger         = "GET /ledger/{id}/"
+	EndpointAirdrop        = "POST /airdrops/"
+	EndpointTransaction    = "POST /transactions/"
+	EndpointGetTransaction = "GET /transactions/{id}/"
+	EndpointListActions    = "GET /actions/"
+	EndpointCreateAction   = "POST /actions/"
+)
+
+// wallets of the recorded responses.
+const (
+	UserWallet   = "5190fed7-dbfb-4687-b2c8-b5cd57002198"
+	NewWallet    = "1bc46b40-2d76-4bfa-a806-b9ce1983ae8f"
+	OtherWallet  = "87e9132d-0586-4beb-9600-ffa050966bc8"
+	Transaction  = "4f1a4c0f-6b4d-4bb5-9d8f-0c1d5b0f5b61"
+	UserBalance  = "1.3"
+	RewardAmount = "0.1"
+)
+
+// Actions are the ids of the recorded company actions.
+var Actions = ost.Actions{Reward: 39879, Payment: 39876, Decrease: 39928, Transfer: 39930, Tip: 39931}
+
+// Fixtures are the recorded responses of every endpoint.
+var Fixtures = map[string]string{
+	EndpointCreateUser: `{
+  "success": true,
+  "data": {
+    "result_type": "user",
+    "user": {
+      "id": "` + NewWallet + `",
+      "addresses": [["1409", "0x3c5d3bb2e6b8bc4a64a1e5e1e2d0c8c0b2b9e2ae"]],
+      "name": "Luffy",
+      "airdropped_tokens": "0",
+      "token_balance": "0"
+    }
+  }
+}`,
+
+	EndpointGetUser: `{
+  "success": true,
+  "data": {
+    "result_type": "user",
+    "user": {
+      "id": "` + UserWallet + `",
+      "addresses": [["1409", "0x7b4c8f07f6d1e3c8c8b9f5c0ea4c1e0d1f2a3b4c"]],
+      "name": "Luffy",
+      "airdropped_tokens": "1",
+      "token_balance": "` + UserBalance + `"
+    }
+  }
+}`,
+
+	EndpointLedger: `{
+  "success": true,
+  "data": {
+    "result_type": "transactions",
+    "transactions": [
+      {
+        "id": "` + Transaction + `",
+        "from_user_id": "` + Company + `",
+        "to_user_id": "` + UserWallet + `",
+        "transaction_hash": "0x9a3f1d0c1e4d5c8e7b2f0a6c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e",
+        "action_id": 39879,
+        "timestamp": 1526392871000,
+        "status": "complete",
+        "amount": "` + RewardAmount + `"
+      },
+      {
+        "id": "0b8b1e0e-3a62-4f6a-8f3e-1d2c3b4a5f60",
+        "from_user_id": "` + UserWallet + `",
+        "to_user_id": "` + Company + `",
+        "transaction_hash": "0x1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d",
+        "action_id": 39876,
+        "timestamp": 1526306471000,
+        "status": "complete",
+        "amount": "0.2"
+      },
+      {
+        "id": "7d6c5b4a-3f2e-4d1c-9b8a-7f6e5d4c3b2a",
+        "from_user_id": "` + Company + `",
+        "to_user_id": "` + UserWallet + `",
+        "transaction_hash": "0x2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e",
+        "timestamp": 1526220071000,
+        "status": "complete",
+        "amount": "1"
+      }
+    ],
+    "meta": {
+      "next_page_payload": {}
+    }
+  }
+}`,
+
+	EndpointAirdrop: `{
+  "success": true,
+  "data": {
+    "result_type": "airdrop",
+    "airdrop": {
+      "id": "d1c2b3a4-5e6f-4a7b-8c9d-0e1f2a3b4c5d",
+      "current_status": "pending",
+      "steps_complete": []
+    }
+  }
+}`,
+
+	EndpointTransaction: `{
+  "success": true,
+  "data": {
+    "result_type": "transaction",
+    "transaction": {
+      "id": "` + Transaction + `",
+      "from_user_id": "` + Company + `",
+      "to_user_id": "` + UserWallet + `",
+      "transaction_hash": null,
+      "action_id": 39879,
+      "timestamp": 1526392871000,
+      "status": "processing",
+      "amount": null
+    }
+  }
+}`,
+
+	EndpointGetTransaction: `{
+  "success": true,
+  "data": {
+    "result_type": "transaction",
+    "transaction": {
+      "id": "` + Transaction + `",
+      "from_user_id": "` + Company + `",
+      "to_user_id": "` + UserWallet + `",
+      "transaction_hash": "0x9a3f1d0c1e4d5c8e7b2f0a6c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e",
+      "action_id": 39879,
+      "timestamp": 1526392871000,
+      "status": "complete",
+      "amount": "` + RewardAmount + `"
+    }
+  }
+}`,
+
+	EndpointListActions: `{
+  "success": true,
+  "data": {
+    "result_type": "actions",
+    "actions": [
+      {"id": 39879, "name": "Treasure Reward", "kind": "company_to_user", "currency": "BT", "arbitrary_amount": false, "amount": "` + RewardAmount + `", "arbitrary_commission": false, "commission_percent": null},
+      {"id": 39876, "name": "Game Payment", "kind": "user_to_company", "currency": "BT", "arbitrary_amount": true, "amount": null, "arbitrary_commission": false, "commission_percent": null},
+      {"id": 39928, "name": "Balance Return", "kind": "user_to_company", "currency": "BT", "arbitrary_amount": true, "amount": null, "arbitrary_commission": false, "commission_percent": null},
+      {"id": 39930, "name": "Player Transfer", "kind": "user_to_user", "currency": "BT", "arbitrary_amount": true, "amount": null, "arbitrary_commission": false, "commission_percent": null},
+      {"id": 39931, "name": "Creator Tip", "kind": "user_to_user", "currency": "BT", "arbitrary_amount": true, "amount": null, "arbitrary_commission": false, "commission_percent": null}
+    ],
+    "meta": {
+      "next_page_payload": {}
+    }
+  }
+}`,
+
+	EndpointCreateAction: `{
+  "success": true,
+  "data": {
+    "result_type": "action",
+    "action": {"id": 40001, "name": "Treasure Reward", "kind": "company_to_user", "currency": "BT", "arbitrary_amount": false, "amount": "` + RewardAmount + `", "arbitrary_commission": false, "commission_percent": null}
+  }
+}`,
+}
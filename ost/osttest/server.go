@@ -0,0 +1,218 @@
+// Package osttest provides a fake OST API server for tests.
+package osttest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin/ost"
+)
+
+// credentials of the fake company.
+const (
+	Key     = "osttest-key"
+	Secret  = "osttest-secret"
+	Company = "c0mpany0-0000-4000-8000-000000000000"
+)
+
+// signatureWindow is how old a signed request can be before it is rejected.
+const signatureWindow = time.Minute
+
+// Server is a fake OST API serving recorded responses.
+// Every request must be signed with the fake company credentials.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]Response
+	failures  map[string][]Response
+	latency   time.Duration
+	requests  []Request
+}
+
+// Response represents a recorded OST API response.
+type Response struct {
+	Status int
+	Body   string
+}
+
+// Request represents a request received by the server.
+type Request struct {
+	Endpoint string
+	Path     string
+	Params   url.Values
+}
+
+// NewServer returns a new running fake OST API serving the recorded responses.
+func NewServer() *Server {
+	s := &Server{
+		responses: make(map[string]Response),
+		failures:  make(map[string][]Response),
+	}
+	for endpoint, body := range Fixtures {
+		s.responses[endpoint] = Response{Status: http.StatusOK, Body: body}
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Config returns the client config of the fake company.
+func (s *Server) Config() ost.Config {
+	return ost.Config{
+		Key:     Key,
+		Secret:  Secret,
+		Url:     s.URL,
+		Company: Company,
+		Timeout: 2 * time.Second,
+		Actions: Actions,
+	}
+}
+
+// Client returns a new client connected to the server.
+func (s *Server) Client() *ost.Client {
+	return ost.NewClient(s.Config())
+}
+
+// Respond replaces the recorded response of an endpoint.
+func (s *Server) Respond(endpoint string, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[endpoint] = Response{Status: status, Body: body}
+}
+
+// Fail makes the next n requests to an endpoint fail with the OST error.
+func (s *Server) Fail(endpoint string, n int, status int, code, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures[endpoint] = append(s.failures[endpoint], Response{Status: status, Body: ErrorBody(code, msg)})
+	}
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Count returns how many requests an endpoint received.
+func (s *Server) Count(endpoint string) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if r.Endpoint == endpoint {
+			n++
+		}
+	}
+	return n
+}
+
+// serve answers a request with the failure or recorded response of its endpoint.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err == nil {
+			params = r.PostForm
+		}
+	}
+	endpoint := Endpoint(r.Method, r.URL.Path)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Endpoint: endpoint, Path: r.URL.Path, Params: params})
+	latency := s.latency
+	resp, ok := s.responses[endpoint]
+	if f := s.failures[endpoint]; len(f) > 0 {
+		resp, ok = f[0], true
+		s.failures[endpoint] = f[1:]
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// authenticate the request.
+	if err := verify(r.URL.Path, params); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, ErrorBody("companyRestFulApi(401)", err.Error()))
+		return
+	}
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, ErrorBody("NOT_FOUND", "endpoint not found: "+endpoint))
+		return
+	}
+	w.WriteHeader(resp.Status)
+	fmt.Fprint(w, resp.Body)
+}
+
+// verify checks the api key, timestamp and signature of the request parameters.
+func verify(path string, params url.Values) error {
+	if params.Get("api_key") != Key {
+		return fmt.Errorf("invalid api key")
+	}
+	ts, err := strconv.ParseInt(params.Get("request_timestamp"), 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)) > signatureWindow {
+		return fmt.Errorf("invalid request timestamp")
+	}
+
+	q := url.Values{}
+	for k, v := range params {
+		if k != "signature" {
+			q[k] = v
+		}
+	}
+	signer := ost.NewClient(ost.Config{Secret: Secret})
+	if params.Get("signature") != signer.CreateSignature(path, q.Encode()) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// Endpoint returns the endpoint name of a request, with the ids in the path replaced by a placeholder.
+// For example, "GET /users/1234/" becomes "GET /users/{id}/".
+func Endpoint(method, path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) > 1 {
+		parts[1] = "{id}"
+	}
+	return method + " /" + strings.Join(parts, "/") + "/"
+}
+
+// Webhook returns the headers and body of a transaction webhook signed with the fake company secret.
+func Webhook(topic, transaction, status string) (http.Header, []byte) {
+	body := []byte(fmt.Sprintf(`{"id":"webhook-event","topic":%q,"created_at":%d,"version":"v2","data":{"result_type":"transaction","transaction":{"id":%q,"status":%q}}}`,
+		topic, time.Now().Unix(), transaction, status))
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	h := http.Header{}
+	h.Set(ost.WebhookTimestampHeader, timestamp)
+	h.Set(ost.WebhookVersionHeader, "v2")
+	h.Set(ost.WebhookSignatureHeader, ost.SignWebhook(Secret, timestamp, "v2", body))
+	return h, body
+}
+
+// ErrorBody returns an OST error envelope.
+func ErrorBody(code, msg string) string {
+	return fmt.Sprintf(`{"success":false,"err":{"code":%q,"msg":%q}}`, code, msg)
+}
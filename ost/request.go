@@ -0,0 +1,168 @@
+package ost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// request executor defaults.
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxRetries = 3
+	DefaultBackoff    = 200 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+)
+
+// envelope represents the body of every OST API response.
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Err     struct {
+		Code       string `json:"code"`
+		Msg        string `json:"msg"`
+		InternalID string `json:"internal_id"`
+	} `json:"err"`
+}
+
+// request represents a call to an OST API endpoint.
+type request struct {
+	method   string
+	resource string
+	query    map[string]string
+
+	// idempotent requests are retried on transient failures.
+	idempotent bool
+}
+
+// do executes the request and decodes the data of the response into out.
+func (c *Client) do(ctx context.Context, r request, out interface{}) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		start := time.Now()
+		retry, err = c.attempt(ctx, r, out)
+		c.observe(r, time.Since(start), err)
+		if err == nil || !retry || !r.idempotent || attempt >= c.maxRetries {
+			return err
+		}
+
+		wait := c.backoffDuration(attempt)
+		c.logger.WithContext(ctx).WithFields(log.Fields{"resource": r.resource, "attempt": attempt + 1, "wait": wait, "error": err}).Warn("retrying OST API request")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// observe reports the outcome of a request to the observer, if any.
+func (c *Client) observe(r request, d time.Duration, err error) {
+	if c.observer != nil {
+		c.observer.ObserveWallet(r.endpoint(), d, err)
+	}
+}
+
+// endpoint returns the method and resource of the request, with the ids replaced by a placeholder.
+func (r request) endpoint() string {
+	parts := strings.Split(r.resource, "/")
+	for i := range parts {
+		if i > 1 && parts[i] != "" {
+			parts[i] = "{id}"
+		}
+	}
+	return r.method + " " + strings.Join(parts, "/")
+}
+
+// attempt executes the request once, returning whether a failure is transient.
+func (c *Client) attempt(ctx context.Context, r request, out interface{}) (bool, error) {
+	// sign the request, with a fresh timestamp.
+	query := map[string]string{
+		"request_timestamp": fmt.Sprintf("%d", time.Now().Unix()),
+		"api_key":           c.apiKey,
+	}
+	for k, v := range r.query {
+		query[k] = v
+	}
+	u, err := c.BuildRequest(c.url, r.resource, query)
+	if err != nil {
+		return false, err
+	}
+
+	// build the http request.
+	var req *http.Request
+	if r.method == http.MethodPost {
+		req, err = http.NewRequest(http.MethodPost, u.String(), bytes.NewBufferString(u.RawQuery))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequest(r.method, u.String(), nil)
+	}
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+
+	// make the request.
+	response, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return true, newError(0, "", err.Error())
+	}
+
+	// parse the response.
+	defer response.Body.Close()
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return true, newError(response.StatusCode, "", err.Error())
+	}
+
+	var env envelope
+	if err := json.Unmarshal(contents, &env); err != nil {
+		return transientStatus(response.StatusCode), newError(response.StatusCode, "", "invalid response: "+string(contents))
+	}
+
+	if !env.Success || response.StatusCode != http.StatusOK {
+		return transientStatus(response.StatusCode), newError(response.StatusCode, env.Err.Code, env.Err.Msg)
+	}
+
+	// unmarshal the response data.
+	if out != nil {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return false, &Error{StatusCode: response.StatusCode, Message: "invalid response data: " + err.Error()}
+		}
+	}
+	return false, nil
+}
+
+// backoffDuration returns how long to wait before retrying, using exponential backoff with full jitter.
+func (c *Client) backoffDuration(attempt int) time.Duration {
+	d := c.backoff << uint(attempt)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// transientStatus returns whether a response status is worth retrying.
+func transientStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// Observer defines the interface to report the requests made to the OST API.
+type Observer interface {
+	ObserveWallet(endpoint string, duration time.Duration, err error)
+}
@@ -0,0 +1,218 @@
+package ost_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/ost"
+	"github.com/stretchr/testify/assert"
+)
+
+// NewStubClient returns a client connected to a stub OST API served by the handler.
+func NewStubClient(handler http.HandlerFunc) (*ost.Client, *httptest.Server) {
+	s := httptest.NewServer(handler)
+	c := ost.NewClient(ost.Config{
+		Key:     "key",
+		Secret:  "secret",
+		Url:     s.URL,
+		Company: "company",
+		Timeout: time.Second,
+		Actions: ost.Actions{Reward: 39879, Payment: 39876, Decrease: 39928, Transfer: 39930, Tip: 39931},
+	})
+	return c, s
+}
+
+// TestClient_RetryIdempotent tests retrying idempotent requests on transient failures.
+func TestClient_RetryIdempotent(t *testing.T) {
+	var calls int32
+	c, s := NewStubClient(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.NotEmpty(t, r.URL.Query().Get("signature"))
+		w.Write([]byte(`{"success":true,"data":{"user":{"token_balance":"1.5"}}}`))
+	})
+	defer s.Close()
+
+	b, err := c.GetUserBalance(context.Background(), "luffy")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.5", b.String())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// TestClient_NoRetryWrites tests never retrying requests that move tokens.
+func TestClient_NoRetryWrites(t *testing.T) {
+	var calls int32
+	c, s := NewStubClient(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer s.Close()
+
+	_, err := c.GetRewarded(context.Background(), "luffy")
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestClient_EnvelopeError tests decoding the OST error envelope.
+func TestClient_EnvelopeError(t *testing.T) {
+	c, s := NewStubClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "luffy", r.PostForm.Get("user_ids"))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"success":false,"err":{"code":"invalid_request","msg":"Insufficient funds"}}`))
+	})
+	defer s.Close()
+
+	err := c.Airdrop(context.Background(), "luffy", coin.Coin)
+	e, ok := err.(*ost.Error)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusUnprocessableEntity, e.StatusCode)
+	assert.Equal(t, "invalid_request", e.Code)
+	assert.Equal(t, "Insufficient funds", e.Message)
+	assert.True(t, errors.Is(err, ost.ErrInsufficientBalance))
+}
+
+// TestClient_ErrorKinds tests classifying OST failures.
+func TestClient_ErrorKinds(t *testing.T) {
+	for status, kind := range map[int]error{
+		http.StatusUnauthorized:       ost.ErrUnauthorized,
+		http.StatusNotFound:           ost.ErrInvalidUser,
+		http.StatusTooManyRequests:    ost.ErrRateLimited,
+		http.StatusServiceUnavailable: ost.ErrUnavailable,
+	} {
+		c, s := NewStubClient(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"success":false,"err":{"code":"","msg":"failed"}}`))
+		})
+		_, err := c.MakePayment(context.Background(), "luffy", coin.TreasurePrice)
+		assert.True(t, errors.Is(err, kind), "status %d", status)
+		s.Close()
+	}
+}
+
+// TestClient_InvalidData tests failing on undecodable response data.
+func TestClient_InvalidData(t *testing.T) {
+	c, s := NewStubClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"data":{"user":{"token_balance":1}}}`))
+	})
+	defer s.Close()
+
+	_, err := c.GetUserBalance(context.Background(), "luffy")
+	assert.NotNil(t, err)
+}
+
+// TestClient_Cancelled tests aborting requests when the context is cancelled.
+func TestClient_Cancelled(t *testing.T) {
+	c, s := NewStubClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.GetUserBalance(ctx, "luffy")
+	assert.Equal(t, context.Canceled, err)
+}
+
+// TestClient_EachUserTransaction tests reading every page of the user ledger.
+func TestClient_EachUserTransaction(t *testing.T) {
+	c, s := NewStubClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page_no") {
+		case "1":
+			w.Write([]byte(`{"success":true,"data":{"transactions":[{"from_user_id":"company","to_user_id":"luffy","timestamp":2000,"amount":"0.1"}],"meta":{"next_page_payload":{"page_no":2}}}}`))
+		case "2":
+			w.Write([]byte(`{"success":true,"data":{"transactions":[{"from_user_id":"luffy","to_user_id":"company","timestamp":1000,"amount":"0.3"}],"meta":{"next_page_payload":{}}}}`))
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("page_no"))
+		}
+	})
+	defer s.Close()
+
+	var amounts []string
+	err := c.EachUserTransaction(context.Background(), "luffy", func(tr coin.Transaction) error {
+		amounts = append(amounts, tr.Amount.Signed())
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"+0.1", "-0.3"}, amounts)
+
+	// stop at the first error.
+	stop := coin.Error("stop")
+	err = c.EachUserTransaction(context.Background(), "luffy", func(tr coin.Transaction) error {
+		return stop
+	})
+	assert.Equal(t, stop, err)
+}
+
+// TestClient_TransactionEvents tests classifying transactions by their action.
+func TestClient_TransactionEvents(t *testing.T) {
+	c, s := NewStubClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"data":{"transactions":[
+			{"id":"1","from_user_id":"company","to_user_id":"luffy","action_id":39879,"amount":"0.1"},
+			{"id":"2","from_user_id":"luffy","to_user_id":"company","action_id":39876,"amount":"0.2"},
+			{"id":"3","from_user_id":"luffy","to_user_id":"company","action_id":39928,"amount":"0.3"},
+			{"id":"4","from_user_id":"company","to_user_id":"luffy","amount":"1"},
+			{"id":"5","from_user_id":"zoro","to_user_id":"luffy","action_id":"40000","amount":"0.5"},
+			{"id":"6","from_user_id":"luffy","to_user_id":"zoro","action_id":39930,"amount":"0.6"},
+			{"id":"7","from_user_id":"nami","to_user_id":"luffy","action_id":39931,"amount":"0.7"}
+		]}}`))
+	})
+	defer s.Close()
+
+	transactions, err := c.GetUserTransactions(context.Background(), "luffy")
+	assert.Nil(t, err)
+	var events []string
+	for _, tr := range transactions {
+		events = append(events, tr.Event)
+	}
+	assert.Equal(t, []string{coin.EventTreasureFound, coin.EventGameCreated, coin.EventTokensReturned, coin.EventAirdrop, coin.EventTransfer, coin.EventTransfer, coin.EventTip}, events)
+	assert.Equal(t, "+0.5", transactions[4].Amount.Signed())
+	assert.Equal(t, "-0.3", transactions[2].Amount.Signed())
+	assert.Equal(t, "-0.6", transactions[5].Amount.Signed())
+	assert.Equal(t, "+0.7", transactions[6].Amount.Signed())
+}
+
+// Observer is a test observer recording the requests made.
+type Observer struct {
+	endpoints []string
+	errs      []error
+}
+
+// ObserveWallet records the request.
+func (o *Observer) ObserveWallet(endpoint string, duration time.Duration, err error) {
+	o.endpoints = append(o.endpoints, endpoint)
+	o.errs = append(o.errs, err)
+}
+
+// TestClient_Observer tests reporting every attempt of the requests, by endpoint.
+func TestClient_Observer(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"success":true,"data":{"user":{"token_balance":"1.5"}}}`))
+	}))
+	defer s.Close()
+
+	o := &Observer{}
+	c := ost.NewClient(ost.Config{Key: "key", Secret: "secret", Url: s.URL, Company: "company", Observer: o})
+
+	_, err := c.GetUserBalance(context.Background(), "luffy")
+	assert.Nil(t, err)
+	assert.Nil(t, c.Ping(context.Background()))
+
+	assert.Equal(t, []string{"GET /users/{id}/", "GET /users/{id}/", "GET /actions/"}, o.endpoints)
+	assert.True(t, errors.Is(o.errs[0], coin.ErrWalletUnavailable))
+	assert.Nil(t, o.errs[1])
+}
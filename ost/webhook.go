@@ -0,0 +1,95 @@
+package ost
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// webhook signature headers.
+const (
+	WebhookSignatureHeader = "Api-Signature"
+	WebhookTimestampHeader = "Api-Request-Timestamp"
+	WebhookVersionHeader   = "Version"
+)
+
+// WebhookWindow is how old a signed webhook can be before it is rejected.
+const WebhookWindow = 5 * time.Minute
+
+// webhook represents the body of an OST webhook.
+type webhook struct {
+	ID    string `json:"id"`
+	Topic string `json:"topic"`
+	Data  struct {
+		Transaction struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+		} `json:"transaction"`
+	} `json:"data"`
+}
+
+// SignWebhook returns the signature of a webhook body sent at the timestamp.
+func SignWebhook(secret, timestamp, version string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "." + version + "."))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ParseWebhook verifies the signature of an OST webhook and returns the transfer update it carries.
+// Only the ID, Status and UpdatedDate of the transfer are set, and the ID is empty for other events.
+func (c *Client) ParseWebhook(header http.Header, body []byte) (coin.Transfer, error) {
+	// check the webhook is recent.
+	timestamp := header.Get(WebhookTimestampHeader)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return coin.Transfer{}, ErrInvalidWebhook
+	}
+	sent := time.Unix(ts, 0)
+	if d := time.Since(sent); d > WebhookWindow || d < -WebhookWindow {
+		c.logger.WithFields(log.Fields{"sent": sent}).Warn("rejected an expired OST webhook")
+		return coin.Transfer{}, ErrInvalidWebhook
+	}
+
+	// check any of the signatures matches, as several are sent while the secret is rotated.
+	expected := SignWebhook(c.webhookSecret, timestamp, header.Get(WebhookVersionHeader), body)
+	valid := false
+	for _, sig := range strings.Split(header.Get(WebhookSignatureHeader), ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(sig)), []byte(expected)) {
+			valid = true
+		}
+	}
+	if !valid {
+		c.logger.Warn("rejected an OST webhook with an invalid signature")
+		return coin.Transfer{}, ErrInvalidWebhook
+	}
+
+	var w webhook
+	if err := json.Unmarshal(body, &w); err != nil {
+		return coin.Transfer{}, err
+	}
+	c.logger.WithFields(log.Fields{"webhook": w.ID, "topic": w.Topic, "transaction": w.Data.Transaction.ID}).Info("received an OST webhook")
+
+	if !strings.HasPrefix(w.Topic, "transactions/") {
+		return coin.Transfer{}, nil
+	}
+
+	// the topic names the final status of the transaction.
+	status := transferStatus(w.Data.Transaction.Status)
+	switch w.Topic {
+	case "transactions/success":
+		status = coin.TransferComplete
+	case "transactions/failure":
+		status = coin.TransferFailed
+	}
+	return coin.Transfer{ID: w.Data.Transaction.ID, Status: status, UpdatedDate: sent}, nil
+}
@@ -0,0 +1,300 @@
+// Package queue defers the wallet operations of sign-ups and treasure discoveries while the wallet provider is unavailable.
+//
+// Deferred operations are stored, so they survive restarts, and replayed in order with Replay.
+// A replay stops at the first operation the provider is still unavailable for, keeping the rest for later.
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultInterval is how often the deferred operations are replayed by default.
+const DefaultInterval = 30 * time.Second
+
+// MaxAttempts is how many times an operation is replayed before it is given up.
+const MaxAttempts = 10
+
+// ErrWalletPending is returned when replaying an operation of a user whose wallet has not been created yet.
+const ErrWalletPending = coin.Error("the wallet of the user has not been created yet")
+
+// Queue represents the service deferring and replaying wallet operations.
+type Queue struct {
+	logger *log.Entry
+
+	// serializes the replays.
+	mu sync.Mutex
+
+	// external services.
+	ops       OperationStore
+	users     UserStore
+	games     GameStore
+	wallets   WalletService
+	transfers TransferTracker
+}
+
+// NewQueue returns a new instance of Queue.
+func NewQueue(ops OperationStore, users UserStore, games GameStore, wallets WalletService, transfers TransferTracker) *Queue {
+	return &Queue{
+		logger:    log.WithFields(log.Fields{"package": "queue"}),
+		ops:       ops,
+		users:     users,
+		games:     games,
+		wallets:   wallets,
+		transfers: transfers,
+	}
+}
+
+// Defer stores an operation to be replayed once the wallet provider recovers.
+func (q *Queue) Defer(op coin.Operation) error {
+	op.CreatedDate = time.Now()
+
+	id, err := q.ops.Add(op)
+	if err != nil {
+		q.logger.WithFields(log.Fields{"kind": op.Kind, "user": op.User, "error": err}).Error("failed to defer operation")
+		return err
+	}
+	q.logger.WithFields(log.Fields{"operation": id, "kind": op.Kind, "user": op.User}).Info("operation deferred")
+	return nil
+}
+
+// MoveUser moves the deferred operations of the user to their new email, so they are performed once it changed.
+// It waits for the replay in progress, which would otherwise store the operations back under the old email.
+func (q *Queue) MoveUser(old, email string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, op := range q.ops.List() {
+		if op.User != old {
+			continue
+		}
+		op.User = email
+		if err := q.ops.Save(op); err != nil {
+			return err
+		}
+		q.logger.WithFields(log.Fields{"operation": op.ID, "kind": op.Kind, "user": email}).Info("operation moved")
+	}
+	return nil
+}
+
+// Replay performs the deferred operations in order, until the wallet provider is unavailable again.
+// Operations failing for any other reason are retried up to MaxAttempts times.
+func (q *Queue) Replay(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, op := range q.ops.List() {
+		err := q.perform(ctx, &op)
+		if err == nil {
+			q.logger.WithFields(log.Fields{"operation": op.ID, "kind": op.Kind, "user": op.User}).Info("deferred operation performed")
+			q.ops.Remove(op)
+			continue
+		}
+		if errors.Is(err, coin.ErrWalletUnavailable) || ctx.Err() != nil {
+			return
+		}
+
+		op.Attempts++
+		op.LastError = err.Error()
+		if op.Attempts >= MaxAttempts {
+			q.giveUp(op)
+			continue
+		}
+		q.logger.WithFields(log.Fields{"operation": op.ID, "kind": op.Kind, "attempts": op.Attempts, "error": err}).Warn("deferred operation failed")
+		q.ops.Save(op)
+	}
+}
+
+// Run replays the deferred operations every interval until the context is cancelled.
+func (q *Queue) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	q.logger.WithFields(log.Fields{"interval": interval}).Info("replaying deferred operations")
+	for {
+		q.Replay(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// perform performs a deferred operation.
+// Operations of users or games that no longer exist are done.
+func (q *Queue) perform(ctx context.Context, op *coin.Operation) error {
+	user, err := q.users.Find(op.User)
+	if err != nil {
+		q.logger.WithFields(log.Fields{"operation": op.ID, "kind": op.Kind, "user": op.User, "error": err}).Warn("dropping operation of a missing user")
+		return nil
+	}
+
+	switch op.Kind {
+	case coin.OperationSignUp:
+		return q.signUp(ctx, *op, user)
+	case coin.OperationReward:
+		return q.reward(ctx, op, user)
+	default:
+		q.logger.WithFields(log.Fields{"operation": op.ID, "kind": op.Kind}).Error("dropping operation of unknown kind")
+		return nil
+	}
+}
+
+// signUp creates the wallet of the user, if missing, and airdrops the signup coins.
+func (q *Queue) signUp(ctx context.Context, op coin.Operation, user coin.User) error {
+	if user.Wallet == "" {
+		w, err := q.wallets.CreateUser(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+
+		// reload the user in case it changed while the wallet was created.
+		user, err = q.users.Find(op.User)
+		if err != nil {
+			return nil
+		}
+		user.Wallet = w
+		if err := q.users.Save(user); err != nil {
+			return err
+		}
+	}
+
+	if op.Amount.Sign() <= 0 {
+		return nil
+	}
+	err := q.wallets.Airdrop(ctx, user.Wallet, op.Amount)
+	if unknownOutcome(err) {
+		// the coins may have been sent, so the airdrop is not retried.
+		q.logger.WithFields(log.Fields{"operation": op.ID, "wallet": user.Wallet, "error": err}).Error("airdrop outcome unknown, check it with the wallet provider")
+		return nil
+	}
+	return err
+}
+
+// reward rewards the user for the treasure and tracks the reward until it settles.
+// The reward transaction is stored in the operation as soon as it is sent, so a retry only records and tracks it.
+func (q *Queue) reward(ctx context.Context, op *coin.Operation, user coin.User) error {
+	if user.Wallet == "" {
+		return ErrWalletPending
+	}
+	if _, err := q.games.Find(op.Game); err != nil {
+		return nil
+	}
+
+	if op.Transaction == "" {
+		tx, err := q.wallets.GetRewarded(ctx, user.Wallet)
+		if unknownOutcome(err) {
+			// the reward may have been sent, so it is not retried but left for an admin to check.
+			q.logger.WithFields(log.Fields{"operation": op.ID, "wallet": user.Wallet, "game": op.Game, "treasure": op.Treasure, "error": err}).Error("reward outcome unknown, check it with the wallet provider")
+			return q.games.Update(op.Game, func(game *coin.Game) error {
+				treasure := game.Treasures[op.Treasure]
+				treasure.Reward = coin.TransferUnknown
+				game.Treasures[op.Treasure] = treasure
+				return nil
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		op.Transaction = tx
+		if err := q.ops.Save(*op); err != nil {
+			// the reward must not be sent again, so the operation is done whatever happens next.
+			q.logger.WithFields(log.Fields{"operation": op.ID, "transaction": tx, "error": err}).Error("failed to store reward transaction")
+			if err := q.record(*op, user); err != nil {
+				q.logger.WithFields(log.Fields{"operation": op.ID, "transaction": tx, "error": err}).Error("failed to record reward transaction")
+			}
+			return nil
+		}
+	}
+
+	return q.record(*op, user)
+}
+
+// record records the reward transaction in the treasure and tracks it until it settles.
+func (q *Queue) record(op coin.Operation, user coin.User) error {
+	err := q.games.Update(op.Game, func(game *coin.Game) error {
+		treasure := game.Treasures[op.Treasure]
+		treasure.Transaction = op.Transaction
+		treasure.Reward = coin.TransferPending
+		game.Treasures[op.Treasure] = treasure
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.transfers.Track(coin.Transfer{
+		ID:       op.Transaction,
+		Event:    coin.EventTreasureFound,
+		Wallet:   user.Wallet,
+		Game:     op.Game,
+		Treasure: op.Treasure,
+	})
+}
+
+// unknownOutcome reports whether the wallet provider failed after the call was sent, so it may have gone through.
+func unknownOutcome(err error) bool {
+	return errors.Is(err, coin.ErrWalletUnavailable) && !errors.Is(err, coin.ErrWalletNotSent)
+}
+
+// giveUp drops an operation that failed too many times, failing the reward it was for unless it was sent.
+func (q *Queue) giveUp(op coin.Operation) {
+	q.logger.WithFields(log.Fields{"operation": op.ID, "kind": op.Kind, "user": op.User, "attempts": op.Attempts, "error": op.LastError}).Error("giving up deferred operation")
+	q.ops.Remove(op)
+
+	if op.Kind != coin.OperationReward {
+		return
+	}
+	q.games.Update(op.Game, func(game *coin.Game) error {
+		treasure := game.Treasures[op.Treasure]
+		treasure.Reward = coin.TransferFailed
+		if op.Transaction != "" {
+			treasure.Transaction = op.Transaction
+			treasure.Reward = coin.TransferPending
+		}
+		game.Treasures[op.Treasure] = treasure
+		return nil
+	})
+}
+
+// OperationStore defines the interface to interact with the deferred operation persistence layer.
+type OperationStore interface {
+	Add(op coin.Operation) (string, error)
+	Save(op coin.Operation) error
+	Remove(op coin.Operation) error
+	List() []coin.Operation
+}
+
+// UserStore defines the interface to interact with the user persistence layer.
+type UserStore interface {
+	Find(email string) (coin.User, error)
+	Save(user coin.User) error
+}
+
+// GameStore defines the interface to interact with the game persistence layer.
+type GameStore interface {
+	Find(id string) (coin.Game, error)
+	Update(id string, fn func(game *coin.Game) error) error
+}
+
+// WalletService defines the interface of the wallet operations that can be deferred.
+type WalletService interface {
+	CreateUser(ctx context.Context, user string) (string, error)
+	Airdrop(ctx context.Context, user string, amount coin.Amount) error
+	GetRewarded(ctx context.Context, user string) (string, error)
+}
+
+// TransferTracker defines the interface to follow the transfers until they settle.
+type TransferTracker interface {
+	Track(transfer coin.Transfer) error
+}
@@ -0,0 +1,228 @@
+package queue_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+// operations is an in-memory operation store.
+type operations struct {
+	ops []coin.Operation
+	seq int
+}
+
+func (s *operations) Add(op coin.Operation) (string, error) {
+	s.seq++
+	op.ID = strconv.Itoa(s.seq)
+	s.ops = append(s.ops, op)
+	return op.ID, nil
+}
+func (s *operations) Save(op coin.Operation) error {
+	for i := range s.ops {
+		if s.ops[i].ID == op.ID {
+			s.ops[i] = op
+		}
+	}
+	return nil
+}
+func (s *operations) Remove(op coin.Operation) error {
+	for i := range s.ops {
+		if s.ops[i].ID == op.ID {
+			s.ops = append(s.ops[:i], s.ops[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+func (s *operations) List() []coin.Operation { return append([]coin.Operation(nil), s.ops...) }
+
+// users is an in-memory user store.
+type users map[string]coin.User
+
+func (s users) Save(u coin.User) error { s[u.Email] = u; return nil }
+func (s users) Find(email string) (coin.User, error) {
+	u, ok := s[email]
+	if !ok {
+		return u, coin.Error("not found")
+	}
+	return u, nil
+}
+
+// games is an in-memory game store.
+type games map[string]coin.Game
+
+func (s games) Update(id string, fn func(*coin.Game) error) error {
+	g, ok := s[id]
+	if !ok {
+		return coin.Error("not found")
+	}
+	if err := fn(&g); err != nil {
+		return err
+	}
+	s[id] = g
+	return nil
+}
+func (s games) Find(id string) (coin.Game, error) {
+	g, ok := s[id]
+	if !ok {
+		return g, coin.Error("not found")
+	}
+	return g, nil
+}
+
+// wallets is a fake wallet provider recording the operations performed.
+type wallets struct {
+	err error
+	// trackErr fails the tracking when set.
+	trackErr  error
+	airdrops  map[string]coin.Amount
+	rewarded  []string
+	transfers []coin.Transfer
+}
+
+func (w *wallets) CreateUser(ctx context.Context, user string) (string, error) {
+	return "wallet-" + user, w.err
+}
+func (w *wallets) Airdrop(ctx context.Context, user string, amount coin.Amount) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.airdrops[user] = w.airdrops[user].Add(amount)
+	return nil
+}
+func (w *wallets) GetRewarded(ctx context.Context, user string) (string, error) {
+	if w.err != nil {
+		return "", w.err
+	}
+	w.rewarded = append(w.rewarded, user)
+	return "tx-" + user, nil
+}
+func (w *wallets) Track(transfer coin.Transfer) error {
+	if w.trackErr != nil {
+		return w.trackErr
+	}
+	w.transfers = append(w.transfers, transfer)
+	return nil
+}
+
+// newQueue returns a queue with a user who signed up and found a treasure while the wallet provider was unavailable.
+func newQueue(t *testing.T) (*queue.Queue, *operations, users, games, *wallets) {
+	ops := &operations{}
+	us := users{"luffy@onepiece.com": {Email: "luffy@onepiece.com", Username: "luffy"}}
+	gs := games{"1": {ID: "1", Treasures: map[string]coin.Treasure{"t": {ID: "t", Found: true, Reward: coin.TransferPending}}}}
+	ws := &wallets{err: coin.ErrWalletNotSent, airdrops: map[string]coin.Amount{}}
+	q := queue.NewQueue(ops, us, gs, ws, ws)
+
+	assert.Nil(t, q.Defer(coin.Operation{Kind: coin.OperationSignUp, User: "luffy@onepiece.com", Amount: coin.Coin}))
+	assert.Nil(t, q.Defer(coin.Operation{Kind: coin.OperationReward, User: "luffy@onepiece.com", Game: "1", Treasure: "t"}))
+	return q, ops, us, gs, ws
+}
+
+// TestQueue_Replay tests replaying the deferred operations once the wallet provider recovers.
+func TestQueue_Replay(t *testing.T) {
+	q, ops, us, gs, ws := newQueue(t)
+
+	// nothing is replayed while the provider is unavailable.
+	q.Replay(context.Background())
+	assert.Len(t, ops.ops, 2)
+	assert.Equal(t, 0, ops.ops[0].Attempts)
+
+	ws.err = nil
+	q.Replay(context.Background())
+	assert.Empty(t, ops.ops)
+
+	// the wallet is created before the reward is sent to it.
+	assert.Equal(t, "wallet-luffy", us["luffy@onepiece.com"].Wallet)
+	assert.Equal(t, coin.Coin, ws.airdrops["wallet-luffy"])
+	assert.Equal(t, []string{"wallet-luffy"}, ws.rewarded)
+
+	// the reward is tracked until it settles.
+	assert.Equal(t, "tx-wallet-luffy", gs["1"].Treasures["t"].Transaction)
+	assert.Equal(t, coin.TransferPending, gs["1"].Treasures["t"].Reward)
+	assert.Len(t, ws.transfers, 1)
+	assert.Equal(t, coin.EventTreasureFound, ws.transfers[0].Event)
+}
+
+// TestQueue_GiveUp tests dropping the operations failing too many times.
+func TestQueue_GiveUp(t *testing.T) {
+	q, ops, _, gs, ws := newQueue(t)
+	ws.err = coin.ErrInvalidWallet
+
+	for i := 1; i < queue.MaxAttempts; i++ {
+		q.Replay(context.Background())
+		assert.Len(t, ops.ops, 2)
+		assert.Equal(t, i, ops.ops[0].Attempts)
+		assert.Equal(t, coin.ErrInvalidWallet.Error(), ops.ops[0].LastError)
+	}
+
+	q.Replay(context.Background())
+	assert.Empty(t, ops.ops)
+	assert.Equal(t, coin.TransferFailed, gs["1"].Treasures["t"].Reward)
+}
+
+// TestQueue_Gone tests dropping the operations of users who deleted their account.
+func TestQueue_Gone(t *testing.T) {
+	q, ops, us, _, ws := newQueue(t)
+	delete(us, "luffy@onepiece.com")
+
+	q.Replay(context.Background())
+	assert.Empty(t, ops.ops)
+	assert.Empty(t, ws.rewarded)
+}
+
+// TestQueue_MoveUser tests the operations of a user changing their email are performed for the new email.
+func TestQueue_MoveUser(t *testing.T) {
+	q, ops, us, _, ws := newQueue(t)
+	us["monkey@onepiece.com"] = coin.User{Email: "monkey@onepiece.com", Username: "luffy"}
+	delete(us, "luffy@onepiece.com")
+
+	assert.Nil(t, q.MoveUser("luffy@onepiece.com", "monkey@onepiece.com"))
+	assert.Equal(t, "monkey@onepiece.com", ops.ops[0].User)
+	assert.Equal(t, "monkey@onepiece.com", ops.ops[1].User)
+
+	ws.err = nil
+	q.Replay(context.Background())
+	assert.Empty(t, ops.ops)
+	assert.Equal(t, coin.Coin, ws.airdrops["wallet-luffy"])
+	assert.Equal(t, []string{"wallet-luffy"}, ws.rewarded)
+}
+
+// TestQueue_RewardOnce tests a reward failing after it was sent is retried without being sent again.
+func TestQueue_RewardOnce(t *testing.T) {
+	q, ops, _, gs, ws := newQueue(t)
+	ws.err = nil
+	ws.trackErr = coin.Error("database is down")
+
+	q.Replay(context.Background())
+	assert.Len(t, ops.ops, 1)
+	assert.Equal(t, "tx-wallet-luffy", ops.ops[0].Transaction)
+	assert.Equal(t, 1, ops.ops[0].Attempts)
+
+	ws.trackErr = nil
+	q.Replay(context.Background())
+	assert.Empty(t, ops.ops)
+	assert.Equal(t, []string{"wallet-luffy"}, ws.rewarded)
+	assert.Equal(t, "tx-wallet-luffy", gs["1"].Treasures["t"].Transaction)
+	assert.Len(t, ws.transfers, 1)
+}
+
+// TestQueue_RewardUnknown tests a reward failing once sent is dropped, as it may have gone through, and left for an
+// admin to check.
+func TestQueue_RewardUnknown(t *testing.T) {
+	q, ops, us, gs, ws := newQueue(t)
+	us["luffy@onepiece.com"] = coin.User{Email: "luffy@onepiece.com", Username: "luffy", Wallet: "wallet-luffy"}
+	ops.Remove(ops.ops[0])
+	ws.err = fmt.Errorf("%w: request timed out", coin.ErrWalletUnavailable)
+
+	q.Replay(context.Background())
+	assert.Empty(t, ops.ops)
+	assert.Empty(t, ws.rewarded)
+	assert.Equal(t, coin.TransferUnknown, gs["1"].Treasures["t"].Reward)
+	assert.Empty(t, gs["1"].Treasures["t"].Transaction)
+}
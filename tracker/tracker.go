@@ -0,0 +1,220 @@
+// Package tracker follows the transfers submitted to the wallet provider until they settle.
+//
+// The provider reports the final status of a transfer with a webhook, applied with Update.
+// Transfers whose webhook never arrives are settled by polling the provider with Poll.
+package tracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// errNotApplied cancels the update of a game the transfer no longer pays for.
+const errNotApplied = coin.Error("the transfer does not pay for the game")
+
+// DefaultInterval is how often the pending transfers are polled by default.
+const DefaultInterval = time.Minute
+
+// Tracker represents the service following the transfers until they settle,
+// updating the games and treasures they pay for.
+type Tracker struct {
+	logger *log.Entry
+
+	// serializes the updates of the transfers and the games.
+	mu sync.Mutex
+
+	// external services.
+	transfers TransferStore
+	games     GameStore
+	wallets   StatusChecker
+
+	// called with every transfer settled.
+	handlers []func(coin.Transfer)
+}
+
+// NewTracker returns a new instance of Tracker.
+func NewTracker(transfers TransferStore, games GameStore, wallets StatusChecker) *Tracker {
+	return &Tracker{
+		logger:    log.WithFields(log.Fields{"package": "tracker"}),
+		transfers: transfers,
+		games:     games,
+		wallets:   wallets,
+	}
+}
+
+// Handle registers a function called with every transfer settled, once the game it pays for is updated.
+// Handlers must be quick and must not use the tracker. They are registered before the tracker is used.
+func (t *Tracker) Handle(fn func(coin.Transfer)) {
+	t.handlers = append(t.handlers, fn)
+}
+
+// Track starts following a transfer submitted to the wallet provider.
+func (t *Tracker) Track(transfer coin.Transfer) error {
+	now := time.Now()
+	transfer.Status = coin.TransferPending
+	transfer.CreatedDate = now
+	transfer.UpdatedDate = now
+
+	if err := t.transfers.Add(transfer); err != nil {
+		t.logger.WithFields(log.Fields{"transaction": transfer.ID, "error": err}).Error("failed to track transfer")
+		return err
+	}
+	t.logger.WithFields(log.Fields{"transaction": transfer.ID, "event": transfer.Event}).Info("tracking transfer")
+	return nil
+}
+
+// Update applies the status reported for a transfer to the transfer and to the game or treasure it pays for.
+// Updates of unknown or already settled transfers are ignored.
+func (t *Tracker) Update(update coin.Transfer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transfer, err := t.transfers.Find(update.ID)
+	if err != nil {
+		t.logger.WithFields(log.Fields{"transaction": update.ID}).Debug("ignoring update of an untracked transfer")
+		return nil
+	}
+	if transfer.Status.Settled() || !update.Status.Settled() {
+		return nil
+	}
+
+	// settle the transfer.
+	transfer.Status = update.Status
+	transfer.Reason = update.Reason
+	transfer.UpdatedDate = update.UpdatedDate
+	if transfer.UpdatedDate.IsZero() {
+		transfer.UpdatedDate = time.Now()
+	}
+	if err := t.transfers.Save(transfer); err != nil {
+		t.logger.WithFields(log.Fields{"transaction": transfer.ID, "error": err}).Error("failed to save transfer")
+		return err
+	}
+	t.logger.WithFields(log.Fields{"transaction": transfer.ID, "status": transfer.Status}).Info("transfer settled")
+
+	err = t.apply(transfer)
+	for _, fn := range t.handlers {
+		fn(transfer)
+	}
+	return err
+}
+
+// apply records the status of the transfer in the game or treasure it pays for.
+// The game is updated in place so the changes made to it meanwhile, like treasures being found, are kept.
+func (t *Tracker) apply(transfer coin.Transfer) error {
+	if transfer.Game == "" {
+		return nil
+	}
+	if _, err := t.games.Find(transfer.Game); err != nil {
+		t.logger.WithFields(log.Fields{"transaction": transfer.ID, "game": transfer.Game}).Warn("the game of the transfer no longer exists")
+		return nil
+	}
+
+	err := t.games.Update(transfer.Game, func(game *coin.Game) error {
+		switch {
+		case transfer.Treasure == "" && game.Transaction == transfer.ID:
+			game.Payment = transfer.Status
+		case transfer.Treasure != "" && game.Treasures[transfer.Treasure].Transaction == transfer.ID:
+			treasure := game.Treasures[transfer.Treasure]
+			treasure.Reward = transfer.Status
+			game.Treasures[transfer.Treasure] = treasure
+		default:
+			return errNotApplied
+		}
+		return nil
+	})
+	if errors.Is(err, errNotApplied) {
+		return nil
+	}
+	return err
+}
+
+// reconcile tracks the payments and rewards recorded in the games that are not tracked,
+// because tracking them failed when they were submitted.
+func (t *Tracker) reconcile() {
+	for id, g := range t.games.List() {
+		if g.Transaction != "" && g.Payment == coin.TransferPending {
+			t.retrack(coin.Transfer{ID: g.Transaction, Event: coin.EventGameCreated, Game: id})
+		}
+		for tid, treasure := range g.Treasures {
+			if treasure.Transaction != "" && treasure.Reward == coin.TransferPending {
+				t.retrack(coin.Transfer{ID: treasure.Transaction, Event: coin.EventTreasureFound, Game: id, Treasure: tid})
+			}
+		}
+	}
+}
+
+// retrack tracks the transfer if it is not tracked yet.
+func (t *Tracker) retrack(transfer coin.Transfer) {
+	if _, err := t.transfers.Find(transfer.ID); err == nil {
+		return
+	}
+	if err := t.Track(transfer); err == nil {
+		t.logger.WithFields(log.Fields{"transaction": transfer.ID, "game": transfer.Game}).Warn("tracking untracked transfer")
+	}
+}
+
+// Poll asks the wallet provider for the status of every pending transfer,
+// tracking first the transfers of the games whose tracking failed.
+func (t *Tracker) Poll(ctx context.Context) {
+	t.reconcile()
+	for _, transfer := range t.transfers.Pending() {
+		status, err := t.wallets.GetTransactionStatus(ctx, transfer.ID)
+		if errors.Is(err, coin.ErrInvalidWallet) {
+			// the provider has no record of the transaction.
+			t.Update(coin.Transfer{ID: transfer.ID, Status: coin.TransferFailed, Reason: "the transaction does not exist"})
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			t.logger.WithFields(log.Fields{"transaction": transfer.ID, "error": err}).Warn("failed to get transfer status")
+			continue
+		}
+		t.Update(coin.Transfer{ID: transfer.ID, Status: status})
+	}
+}
+
+// Run polls the pending transfers every interval until the context is cancelled.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	t.logger.WithFields(log.Fields{"interval": interval}).Info("polling pending transfers")
+	for {
+		t.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// TransferStore defines the interface to interact with the transfer persistence layer.
+type TransferStore interface {
+	Add(transfer coin.Transfer) error
+	Find(id string) (coin.Transfer, error)
+	Save(transfer coin.Transfer) error
+	Pending() []coin.Transfer
+}
+
+// GameStore defines the interface to interact with the game persistence layer.
+type GameStore interface {
+	Find(id string) (coin.Game, error)
+	Update(id string, fn func(game *coin.Game) error) error
+	List() map[string]coin.Game
+}
+
+// StatusChecker defines the interface to get the status of the transactions from the wallet provider.
+type StatusChecker interface {
+	GetTransactionStatus(ctx context.Context, id string) (coin.TransferStatus, error)
+}
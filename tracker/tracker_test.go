@@ -0,0 +1,141 @@
+package tracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/ost"
+	"github.com/pmdcosta/treasure-coin/tracker"
+	"github.com/stretchr/testify/assert"
+)
+
+// transfers is an in-memory transfer store.
+type transfers map[string]coin.Transfer
+
+func (s transfers) Add(t coin.Transfer) error  { s[t.ID] = t; return nil }
+func (s transfers) Save(t coin.Transfer) error { s[t.ID] = t; return nil }
+func (s transfers) Find(id string) (coin.Transfer, error) {
+	t, ok := s[id]
+	if !ok {
+		return t, coin.Error("not found")
+	}
+	return t, nil
+}
+func (s transfers) Pending() []coin.Transfer {
+	var pending []coin.Transfer
+	for _, t := range s {
+		if !t.Status.Settled() {
+			pending = append(pending, t)
+		}
+	}
+	return pending
+}
+
+// games is an in-memory game store.
+type games map[string]coin.Game
+
+func (s games) List() map[string]coin.Game { return s }
+func (s games) Update(id string, fn func(*coin.Game) error) error {
+	g, ok := s[id]
+	if !ok {
+		return coin.Error("not found")
+	}
+	if err := fn(&g); err != nil {
+		return err
+	}
+	s[id] = g
+	return nil
+}
+func (s games) Find(id string) (coin.Game, error) {
+	g, ok := s[id]
+	if !ok {
+		return g, coin.Error("not found")
+	}
+	return g, nil
+}
+
+// statuses is a fake wallet provider reporting the transaction statuses.
+type statuses map[string]error
+
+func (s statuses) GetTransactionStatus(ctx context.Context, id string) (coin.TransferStatus, error) {
+	if err := s[id]; err != nil {
+		return "", err
+	}
+	return coin.TransferComplete, nil
+}
+
+// newGame returns a game paid with the "payment" transaction, with a treasure rewarded with the "reward" transaction.
+func newGame() games {
+	return games{"1": coin.Game{
+		ID:          "1",
+		Transaction: "payment",
+		Payment:     coin.TransferPending,
+		Treasures: map[string]coin.Treasure{
+			"chest": {ID: "chest", Found: true, Transaction: "reward", Reward: coin.TransferPending},
+		},
+	}}
+}
+
+// TestTracker_Update tests settling the transfers from the webhooks.
+func TestTracker_Update(t *testing.T) {
+	ts, gs := transfers{}, newGame()
+	tr := tracker.NewTracker(ts, gs, statuses{})
+
+	assert.Nil(t, tr.Track(coin.Transfer{ID: "payment", Event: coin.EventGameCreated, Game: "1"}))
+	assert.Nil(t, tr.Track(coin.Transfer{ID: "reward", Event: coin.EventTreasureFound, Game: "1", Treasure: "chest"}))
+	assert.Equal(t, coin.TransferPending, ts["payment"].Status)
+	assert.False(t, gs["1"].Active())
+
+	// the payment completes.
+	assert.Nil(t, tr.Update(coin.Transfer{ID: "payment", Status: coin.TransferComplete}))
+	assert.True(t, gs["1"].Active())
+
+	// the reward fails, and later updates are ignored.
+	assert.Nil(t, tr.Update(coin.Transfer{ID: "reward", Status: coin.TransferFailed, Reason: "out of gas"}))
+	assert.Nil(t, tr.Update(coin.Transfer{ID: "reward", Status: coin.TransferComplete}))
+	assert.Equal(t, coin.TransferFailed, gs["1"].Treasures["chest"].Reward)
+	assert.Equal(t, "out of gas", ts["reward"].Reason)
+
+	// unknown transfers are ignored.
+	assert.Nil(t, tr.Update(coin.Transfer{ID: "unknown", Status: coin.TransferComplete}))
+}
+
+// TestTracker_Poll tests settling the transfers whose webhook never arrived.
+func TestTracker_Poll(t *testing.T) {
+	ts, gs := transfers{}, newGame()
+	tr := tracker.NewTracker(ts, gs, statuses{
+		"reward": &ost.Error{StatusCode: 404, Kind: ost.ErrInvalidUser},
+		"other":  ost.ErrUnavailable,
+	})
+	tr.Track(coin.Transfer{ID: "payment", Game: "1"})
+	tr.Track(coin.Transfer{ID: "reward", Game: "1", Treasure: "chest"})
+	tr.Track(coin.Transfer{ID: "other"})
+
+	tr.Poll(context.Background())
+	assert.Equal(t, coin.TransferComplete, gs["1"].Payment)
+	assert.Equal(t, coin.TransferFailed, gs["1"].Treasures["chest"].Reward)
+	assert.Equal(t, coin.TransferPending, ts["other"].Status)
+}
+
+// TestTracker_Reconcile tests tracking the payments and rewards of the games whose tracking failed.
+func TestTracker_Reconcile(t *testing.T) {
+	ts, gs := transfers{}, newGame()
+	tr := tracker.NewTracker(ts, gs, statuses{"reward": ost.ErrUnavailable})
+
+	// only the reward is tracked.
+	tr.Track(coin.Transfer{ID: "reward", Event: coin.EventTreasureFound, Game: "1", Treasure: "chest"})
+
+	tr.Poll(context.Background())
+	assert.Equal(t, coin.Transfer{ID: "payment", Event: coin.EventGameCreated, Game: "1", Status: coin.TransferComplete}, withoutDates(ts["payment"]))
+	assert.True(t, gs["1"].Active())
+	assert.Equal(t, coin.TransferPending, ts["reward"].Status)
+	assert.Len(t, ts, 2)
+}
+
+// withoutDates returns the transfer without its creation and update dates.
+func withoutDates(t coin.Transfer) coin.Transfer {
+	t.CreatedDate, t.UpdatedDate = time.Time{}, time.Time{}
+	return t
+}
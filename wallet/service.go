@@ -0,0 +1,164 @@
+// Package wallet implements the wallet service of the application on top of a token provider,
+// a ledger of wallets able to create, transfer, mint and burn coins, like an ERC-20 token.
+package wallet
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pmdcosta/treasure-coin"
+	log "github.com/sirupsen/logrus"
+)
+
+// LedgerPageSize is how many transactions are returned as the most recent page of a ledger.
+const LedgerPageSize = 25
+
+// errStop stops iterating the history once a page is complete.
+const errStop = coin.Error("stop")
+
+// Config are the wallet service settings.
+type Config struct {
+	// Treasury is the wallet paying the rewards and collecting the payments.
+	Treasury string
+	// Reward is the amount of coins paid for finding a treasure.
+	Reward coin.Amount
+}
+
+// Service represents the wallet service of the application backed by a token provider.
+type Service struct {
+	logger *log.Entry
+
+	provider Provider
+	treasury string
+	reward   coin.Amount
+}
+
+// NewService returns a new wallet service.
+func NewService(provider Provider, config Config) *Service {
+	return &Service{
+		logger:   log.WithFields(log.Fields{"package": "wallet"}),
+		provider: provider,
+		treasury: config.Treasury,
+		reward:   config.Reward,
+	}
+}
+
+// CreateUser creates a wallet for the user, returning its address.
+func (s *Service) CreateUser(ctx context.Context, user string) (string, error) {
+	return s.provider.CreateWallet(ctx, user)
+}
+
+// GetUserBalance retrieves the balance of the wallet.
+func (s *Service) GetUserBalance(ctx context.Context, user string) (coin.Amount, error) {
+	return s.provider.Balance(ctx, user)
+}
+
+// Airdrop mints coins into the wallet.
+func (s *Service) Airdrop(ctx context.Context, user string, amount coin.Amount) error {
+	_, err := s.provider.Mint(ctx, user, amount)
+	return err
+}
+
+// GetRewarded pays the treasure reward from the treasury to the wallet, returning the transaction id.
+func (s *Service) GetRewarded(ctx context.Context, user string) (string, error) {
+	return s.provider.Transfer(ctx, s.treasury, user, s.reward)
+}
+
+// MakePayment pays coins from the wallet to the treasury, returning the transaction id.
+func (s *Service) MakePayment(ctx context.Context, user string, amount coin.Amount) (string, error) {
+	return s.provider.Transfer(ctx, user, s.treasury, amount)
+}
+
+// Transfer sends coins between wallets, returning the transaction id.
+func (s *Service) Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	return s.provider.Transfer(ctx, from, to, amount)
+}
+
+// Tip sends coins to the creator of a game, returning the transaction id.
+// Tips are plain transfers to the provider, so they show as transfers in the ledger.
+func (s *Service) Tip(ctx context.Context, from, to string, amount coin.Amount) (string, error) {
+	return s.provider.Transfer(ctx, from, to, amount)
+}
+
+// GetUserTransactions retrieves the most recent page of transactions of the wallet.
+func (s *Service) GetUserTransactions(ctx context.Context, user string) ([]coin.Transaction, error) {
+	transactions := make([]coin.Transaction, 0, LedgerPageSize)
+	err := s.EachUserTransaction(ctx, user, func(t coin.Transaction) error {
+		transactions = append(transactions, t)
+		if len(transactions) == LedgerPageSize {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return []coin.Transaction{}, err
+	}
+	return transactions, nil
+}
+
+// EachUserTransaction calls fn for every transaction of the wallet, newest first, and stops at the first error, returned as is.
+func (s *Service) EachUserTransaction(ctx context.Context, user string, fn func(coin.Transaction) error) error {
+	return s.provider.History(ctx, user, func(t coin.Transaction) error {
+		t.Event = s.event(t)
+		return fn(t)
+	})
+}
+
+// event labels a transaction from its parties.
+func (s *Service) event(t coin.Transaction) string {
+	switch {
+	case t.FromWallet == "":
+		return coin.EventAirdrop
+	case t.ToWallet == "":
+		return coin.EventTokensReturned
+	case strings.EqualFold(t.FromWallet, s.treasury):
+		return coin.EventTreasureFound
+	case strings.EqualFold(t.ToWallet, s.treasury):
+		return coin.EventGameCreated
+	}
+	return coin.EventTransfer
+}
+
+// DecreaseTokens burns coins of the wallet.
+func (s *Service) DecreaseTokens(ctx context.Context, user string, amount coin.Amount) error {
+	_, err := s.provider.Burn(ctx, user, amount)
+	return err
+}
+
+// RemoveTokens burns the full balance of the wallet.
+func (s *Service) RemoveTokens(ctx context.Context, user string) error {
+	amount, err := s.provider.Balance(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	// nothing to remove.
+	if amount.Sign() <= 0 {
+		return nil
+	}
+
+	s.logger.WithContext(ctx).WithFields(log.Fields{"wallet": user, "amount": amount}).Info("removing wallet coins")
+	return s.DecreaseTokens(ctx, user, amount)
+}
+
+// Ping checks the provider can be reached.
+func (s *Service) Ping(ctx context.Context) error {
+	return s.provider.Ping(ctx)
+}
+
+// GetTransactionStatus retrieves the status of a transaction from the provider.
+func (s *Service) GetTransactionStatus(ctx context.Context, id string) (coin.TransferStatus, error) {
+	return s.provider.TransactionStatus(ctx, id)
+}
+
+// Provider defines the interface to interact with a token ledger.
+type Provider interface {
+	CreateWallet(ctx context.Context, name string) (string, error)
+	Balance(ctx context.Context, wallet string) (coin.Amount, error)
+	Transfer(ctx context.Context, from, to string, amount coin.Amount) (string, error)
+	Mint(ctx context.Context, to string, amount coin.Amount) (string, error)
+	Burn(ctx context.Context, from string, amount coin.Amount) (string, error)
+	History(ctx context.Context, wallet string, fn func(coin.Transaction) error) error
+	TransactionStatus(ctx context.Context, id string) (coin.TransferStatus, error)
+	Ping(ctx context.Context) error
+}
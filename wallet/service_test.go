@@ -0,0 +1,133 @@
+package wallet_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/evm"
+	"github.com/pmdcosta/treasure-coin/evm/evmtest"
+	"github.com/pmdcosta/treasure-coin/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Service is a test wrapper.
+type Service struct {
+	*wallet.Service
+	Chain *evmtest.Chain
+}
+
+// NewService returns a new instance of Service, backed by a token on a simulated chain.
+func NewService(t *testing.T) *Service {
+	chain := evmtest.NewChain()
+	client := chain.Client()
+	require.Nil(t, client.Setup(context.Background()))
+
+	return &Service{
+		Service: wallet.NewService(client, wallet.Config{Treasury: chain.Treasury, Reward: 5 * coin.Coin}),
+		Chain:   chain,
+	}
+}
+
+// Close stops the simulated chain.
+func (s *Service) Close() {
+	s.Chain.Close()
+}
+
+// MustCreateUser creates a wallet holding the amount of coins.
+func (s *Service) MustCreateUser(t *testing.T, amount coin.Amount) string {
+	w, err := s.CreateUser(context.Background(), "Luffy")
+	require.Nil(t, err)
+	require.Nil(t, s.Airdrop(context.Background(), w, amount))
+	return w
+}
+
+// TestService_Ledger tests the application transactions are labelled in the ledger.
+func TestService_Ledger(t *testing.T) {
+	s := NewService(t)
+	defer s.Close()
+	ctx := context.Background()
+
+	// fund the treasury.
+	require.Nil(t, s.Airdrop(ctx, s.Chain.Treasury, 100*coin.Coin))
+
+	user := s.MustCreateUser(t, 20*coin.Coin)
+	other := s.MustCreateUser(t, 0)
+
+	_, err := s.MakePayment(ctx, user, 10*coin.Coin)
+	require.Nil(t, err)
+	_, err = s.GetRewarded(ctx, user)
+	require.Nil(t, err)
+	_, err = s.Tip(ctx, user, other, 2*coin.Coin)
+	require.Nil(t, err)
+	require.Nil(t, s.DecreaseTokens(ctx, user, coin.Coin))
+
+	b, err := s.GetUserBalance(ctx, user)
+	assert.Nil(t, err)
+	assert.Equal(t, 12*coin.Coin, b)
+
+	transactions, err := s.GetUserTransactions(ctx, user)
+	assert.Nil(t, err)
+	require.Len(t, transactions, 5)
+	assert.Equal(t, coin.EventTokensReturned, transactions[0].Event)
+	assert.Equal(t, coin.EventTransfer, transactions[1].Event)
+	assert.Equal(t, -2*coin.Coin, transactions[1].Amount)
+	assert.Equal(t, coin.EventTreasureFound, transactions[2].Event)
+	assert.Equal(t, 5*coin.Coin, transactions[2].Amount)
+	assert.Equal(t, coin.EventGameCreated, transactions[3].Event)
+	assert.Equal(t, -10*coin.Coin, transactions[3].Amount)
+	assert.Equal(t, coin.EventAirdrop, transactions[4].Event)
+}
+
+// TestService_GetUserTransactions tests only the most recent page of the ledger is returned.
+func TestService_GetUserTransactions(t *testing.T) {
+	s := NewService(t)
+	defer s.Close()
+
+	user := s.MustCreateUser(t, coin.Coin)
+	for i := 0; i < wallet.LedgerPageSize; i++ {
+		require.Nil(t, s.Airdrop(context.Background(), user, coin.Coin))
+	}
+
+	transactions, err := s.GetUserTransactions(context.Background(), user)
+	assert.Nil(t, err)
+	assert.Len(t, transactions, wallet.LedgerPageSize)
+}
+
+// TestService_RemoveTokens tests burning the full balance of a wallet.
+func TestService_RemoveTokens(t *testing.T) {
+	s := NewService(t)
+	defer s.Close()
+
+	user := s.MustCreateUser(t, 7*coin.Coin)
+	assert.Nil(t, s.RemoveTokens(context.Background(), user))
+	b, _ := s.GetUserBalance(context.Background(), user)
+	assert.Equal(t, coin.Amount(0), b)
+
+	// nothing left to remove.
+	assert.Nil(t, s.RemoveTokens(context.Background(), user))
+}
+
+// TestService_Errors tests the provider errors are returned as wallet errors.
+func TestService_Errors(t *testing.T) {
+	s := NewService(t)
+	defer s.Close()
+
+	// the treasury has no coins to pay the reward.
+	user := s.MustCreateUser(t, 0)
+	_, err := s.GetRewarded(context.Background(), user)
+	assert.True(t, errors.Is(err, coin.ErrInsufficientBalance))
+
+	id, err := s.MakePayment(context.Background(), user, 0)
+	require.Nil(t, err)
+	status, err := s.GetTransactionStatus(context.Background(), id)
+	assert.Nil(t, err)
+	assert.Equal(t, coin.TransferComplete, status)
+
+	s.Chain.Down(true)
+	_, err = s.GetUserBalance(context.Background(), user)
+	assert.True(t, errors.Is(err, coin.ErrWalletUnavailable))
+	assert.True(t, errors.Is(err, evm.ErrUnavailable))
+}
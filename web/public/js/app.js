@@ -0,0 +1,130 @@
+// app.js
+
+// addTreasures creates the inputs of as many treasures as the player asked for.
+function addTreasures(){
+    // Number of inputs to create
+    var number = document.getElementById("treasures").value;
+    // Container <div> where dynamic content will be placed
+    var container = document.getElementById("treasure-list");
+    // Clear previous contents of the container
+    while (container.hasChildNodes()) {
+        container.removeChild(container.lastChild);
+    }
+
+    // Append a line break
+    container.appendChild(document.createElement("br"));
+
+    // Append label
+    var newlabel = document.createElement("label");
+    newlabel.setAttribute("class", "col-sm-3 col-form-label");
+    newlabel.innerHTML = "Treasures";
+    container.appendChild(newlabel);
+
+    container.appendChild(document.createElement("hr"));
+
+    for (let i=0; i<number; i++){
+        // Append treasure name
+        let name = document.createElement("input");
+        name.setAttribute("type", "text");
+        name.setAttribute("class", "mt-1 form-control");
+        name.setAttribute("id", "treasure-name-" + i);
+        name.setAttribute("name", "treasure-name-" + i);
+        name.setAttribute("placeholder", "Treasure Name");
+        container.appendChild(name);
+
+        // Append treasure location
+        let location = document.createElement("input");
+        location.setAttribute("type", "text");
+        location.setAttribute("class", "mt-1 form-control");
+        location.setAttribute("id", "treasure-location-" + i);
+        location.setAttribute("name", "treasure-location-" + i);
+        location.setAttribute("placeholder", "Treasure Location");
+        container.appendChild(location);
+
+        // Append treasure hint
+        let hint = document.createElement("input");
+        hint.setAttribute("type", "text");
+        hint.setAttribute("class", "mt-1 form-control");
+        hint.setAttribute("id", "treasure-hint-" + i);
+        hint.setAttribute("name", "treasure-hint-" + i);
+        hint.setAttribute("placeholder", "Treasure Hint");
+        container.appendChild(hint);
+
+        container.appendChild(document.createElement("br"));
+    }
+    container.appendChild(document.createElement("hr"));
+    container.appendChild(document.createElement("br"));
+}
+
+// describeEvent returns the line of the activity feed telling what happened.
+function describeEvent(kind, e) {
+    switch (kind) {
+    case "game_created":
+        return "New game: " + e.game_title;
+    case "treasure_found":
+        return e.player + " found " + e.treasure_name + " in " + e.game_title;
+    case "game_finished":
+        return "Every treasure of " + e.game_title + " has been found!";
+    }
+    return "";
+}
+
+// followEvents updates the page with the game events streamed to the feed, the browser reconnecting when dropped.
+function followEvents(feed) {
+    let source = new EventSource(feed.dataset.events);
+    ["game_created", "treasure_found", "game_finished"].forEach(function(kind) {
+        source.addEventListener(kind, function(message) {
+            let e = JSON.parse(message.data);
+
+            // show the treasures found and the game finished.
+            if (kind === "treasure_found") {
+                let badge = document.querySelector("[data-treasure=\"" + CSS.escape(e.treasure) + "\"]");
+                if (badge) {
+                    badge.classList.remove("d-none");
+                }
+            }
+            if (kind === "game_finished") {
+                let badge = document.querySelector("[data-finished]");
+                if (badge) {
+                    badge.classList.remove("d-none");
+                }
+            }
+
+            // add the event on top of the feed.
+            let empty = feed.querySelector("[data-empty]");
+            if (empty) {
+                empty.remove();
+            }
+            let line = document.createElement("li");
+            line.setAttribute("class", "list-group-item");
+            line.textContent = new Date(e.date).toLocaleTimeString() + " - " + describeEvent(kind, e);
+            feed.prepend(line);
+            while (feed.children.length > 20) {
+                feed.lastElementChild.remove();
+            }
+        });
+    });
+}
+
+document.addEventListener("DOMContentLoaded", function() {
+    // creates the treasure inputs of a new game.
+    let add = document.getElementById("addtreasures");
+    if (add) {
+        add.addEventListener("click", function(event) {
+            event.preventDefault();
+            addTreasures();
+        });
+    }
+
+    // follows the game events live.
+    document.querySelectorAll("[data-events]").forEach(followEvents);
+
+    // asks before submitting the forms with a data-confirm message.
+    document.querySelectorAll("form[data-confirm]").forEach(function(form) {
+        form.addEventListener("submit", function(event) {
+            if (!confirm(form.dataset.confirm)) {
+                event.preventDefault();
+            }
+        });
+    });
+});
@@ -0,0 +1,37 @@
+// Package web bundles the page templates and the static assets of the application into the binary.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// template and asset directories, in the binary and in a checkout of this package.
+const (
+	templateDir = "templates"
+	assetDir    = "public"
+)
+
+//go:embed templates public
+var files embed.FS
+
+// Templates returns the page templates, read from the override directory when set, or else from the binary.
+func Templates(dir string) fs.FS {
+	return sub(dir, templateDir)
+}
+
+// Assets returns the static assets, read from the override directory when set, or else from the binary.
+func Assets(dir string) fs.FS {
+	return sub(dir, assetDir)
+}
+
+// sub returns a directory of the override directory or of the binary.
+func sub(dir, name string) fs.FS {
+	if dir != "" {
+		return os.DirFS(filepath.Join(dir, name))
+	}
+	f, _ := fs.Sub(files, name)
+	return f
+}
@@ -0,0 +1,36 @@
+package web_test
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pmdcosta/treasure-coin/web"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTemplates tests the templates are bundled into the binary.
+func TestTemplates(t *testing.T) {
+	_, err := fs.Stat(web.Templates(""), "index.html")
+	assert.Nil(t, err)
+	_, err = fs.Stat(web.Assets(""), "css/index.css")
+	assert.Nil(t, err)
+}
+
+// TestOverride tests reading the templates and assets from an override directory.
+func TestOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "templates"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "templates", "index.html"), []byte("changed"), 0644))
+
+	b, err := fs.ReadFile(web.Templates(dir), "index.html")
+	assert.Nil(t, err)
+	assert.Equal(t, "changed", string(b))
+
+	_, err = fs.Stat(web.Assets(dir), "css/index.css")
+	assert.True(t, os.IsNotExist(err))
+}
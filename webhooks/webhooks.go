@@ -0,0 +1,356 @@
+// Package webhooks notifies the webhooks registered by the creators and admins of the game events.
+//
+// Every event is stored as a delivery to each webhook following its game before anything is sent, so deliveries
+// survive restarts. Deliveries are posted as JSON signed with the secret of the webhook, and failed ones are retried
+// with an exponential backoff until MaxAttempts is reached.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/events"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaults of the dispatcher settings.
+const (
+	DefaultInterval    = 10 * time.Second
+	DefaultTimeout     = 10 * time.Second
+	DefaultMaxAttempts = 10
+	DefaultBackoff     = 30 * time.Second
+)
+
+// MaxBackoff bounds how long a failing delivery waits for its next attempt.
+const MaxBackoff = 6 * time.Hour
+
+// Retention is how long the deliveries done with are kept in the delivery log.
+const Retention = 7 * 24 * time.Hour
+
+// headers of the deliveries.
+const (
+	EventHeader     = "X-Treasure-Event"
+	DeliveryHeader  = "X-Treasure-Delivery"
+	TimestampHeader = "X-Treasure-Timestamp"
+	SignatureHeader = "X-Treasure-Signature"
+)
+
+// ErrPrivateAddress is returned when a webhook resolves to a loopback, private or link-local address.
+const ErrPrivateAddress = coin.Error("the webhook address is not public")
+
+// ErrNotFailed is returned when retrying a delivery that has not been given up.
+const ErrNotFailed = coin.Error("the delivery has not failed")
+
+// Config are the dispatcher settings, zero values taking the defaults.
+type Config struct {
+	// Timeout bounds every delivery attempt.
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is attempted before it is given up.
+	MaxAttempts int
+	// Backoff is how long the first failed attempt waits for the next, doubling on every failure.
+	Backoff time.Duration
+	// AllowPrivate lets the webhooks reach the loopback and private networks of the server.
+	AllowPrivate bool
+}
+
+// Dispatcher represents the service delivering the game events to the webhooks.
+type Dispatcher struct {
+	logger *log.Entry
+	config Config
+	client *http.Client
+
+	// serializes the deliveries, and wakes the worker up when events are queued.
+	mu   sync.Mutex
+	wake chan struct{}
+
+	// external services.
+	hooks      WebhookStore
+	deliveries DeliveryStore
+}
+
+// NewDispatcher returns a new instance of Dispatcher.
+func NewDispatcher(hooks WebhookStore, deliveries DeliveryStore, config Config) *Dispatcher {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = DefaultBackoff
+	}
+
+	// the addresses are checked once resolved, so a public name cannot point to the server network.
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivate {
+		dialer.Control = publicOnly
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: config.Timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     time.Minute,
+		},
+		// redirects are answers, the webhook must accept the delivery itself.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &Dispatcher{
+		logger:     log.WithFields(log.Fields{"package": "webhooks"}),
+		config:     config,
+		client:     client,
+		wake:       make(chan struct{}, 1),
+		hooks:      hooks,
+		deliveries: deliveries,
+	}
+}
+
+// Enqueue stores a delivery of the event to every webhook following its game, and wakes the worker up to send them.
+func (d *Dispatcher) Enqueue(e events.Event) {
+	// the webhooks are registered by any creator, so the players are only named by username, never by email.
+	if strings.Contains(e.Player, "@") {
+		e.Player = ""
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		d.logger.WithFields(log.Fields{"event": e.ID, "error": err}).Error("failed to encode event")
+		return
+	}
+
+	now := time.Now()
+	for _, hook := range d.hooks.List() {
+		if hook.Game != "" && hook.Game != e.Game {
+			continue
+		}
+		id, err := d.deliveries.Add(coin.Delivery{
+			Webhook:     hook.ID,
+			Event:       e.Kind,
+			Game:        e.Game,
+			Payload:     string(payload),
+			Status:      coin.DeliveryPending,
+			NextAttempt: now,
+			CreatedDate: now,
+			UpdatedDate: now,
+		})
+		if err != nil {
+			d.logger.WithFields(log.Fields{"webhook": hook.ID, "event": e.Kind, "error": err}).Error("failed to queue delivery")
+			continue
+		}
+		d.logger.WithFields(log.Fields{"webhook": hook.ID, "delivery": id, "event": e.Kind}).Debug("delivery queued")
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Retry schedules a delivery given up to be attempted again right away, with its attempts reset.
+func (d *Dispatcher) Retry(id string) error {
+	delivery, err := d.deliveries.Find(id)
+	if err != nil {
+		return err
+	}
+	if delivery.Status != coin.DeliveryFailed {
+		return ErrNotFailed
+	}
+	delivery.Status = coin.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttempt = time.Now()
+	delivery.UpdatedDate = time.Now()
+	if err := d.deliveries.Save(delivery); err != nil {
+		return err
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Deliver sends the deliveries that are due, in the order they were queued.
+// Once a webhook fails, its other deliveries wait for the next run rather than failing in turn.
+func (d *Dispatcher) Deliver(ctx context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	failing := make(map[string]bool)
+	for _, delivery := range d.deliveries.Pending() {
+		if delivery.NextAttempt.After(now) || failing[delivery.Webhook] {
+			continue
+		}
+
+		// the deliveries of removed webhooks are dropped.
+		hook, err := d.hooks.Find(delivery.Webhook)
+		if err != nil {
+			d.deliveries.Remove(delivery)
+			continue
+		}
+
+		code, err := d.send(ctx, hook, delivery)
+		if ctx.Err() != nil {
+			// the attempt was cut by the shutdown, and is made again on the next start.
+			return
+		}
+		delivery.Attempts++
+		delivery.LastCode = code
+		delivery.UpdatedDate = time.Now()
+		logger := d.logger.WithFields(log.Fields{"webhook": hook.ID, "delivery": delivery.ID, "event": delivery.Event, "attempts": delivery.Attempts, "code": code})
+
+		switch {
+		case err == nil:
+			delivery.Status = coin.DeliveryDelivered
+			delivery.LastError = ""
+			logger.Info("event delivered")
+		case delivery.Attempts >= d.config.MaxAttempts:
+			failing[hook.ID] = true
+			delivery.Status = coin.DeliveryFailed
+			delivery.LastError = err.Error()
+			logger.WithFields(log.Fields{"error": err}).Error("giving up delivery")
+		default:
+			failing[hook.ID] = true
+			delivery.LastError = err.Error()
+			delivery.NextAttempt = delivery.UpdatedDate.Add(d.backoff(delivery.Attempts))
+			logger.WithFields(log.Fields{"error": err, "next": delivery.NextAttempt}).Warn("delivery failed")
+		}
+		d.deliveries.Save(delivery)
+	}
+
+	d.prune(now)
+}
+
+// Run sends the deliveries every interval, and as soon as events are queued, until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	d.logger.WithFields(log.Fields{"interval": interval}).Info("delivering webhooks")
+	for {
+		d.Deliver(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// send posts the delivery to the webhook, returning the status code of the response, if any.
+func (d *Dispatcher) send(ctx context.Context, hook coin.Webhook, delivery coin.Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TreasureCoin-Webhooks")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns how long a delivery waits after its failed attempt, doubling on every failure.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.config.Backoff
+	for i := 1; i < attempts && wait < MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > MaxBackoff {
+		wait = MaxBackoff
+	}
+	return wait
+}
+
+// prune removes the deliveries done with for longer than Retention.
+func (d *Dispatcher) prune(now time.Time) {
+	var old []coin.Delivery
+	for _, delivery := range d.deliveries.List() {
+		if delivery.Status != coin.DeliveryPending && now.Sub(delivery.UpdatedDate) > Retention {
+			old = append(old, delivery)
+		}
+	}
+	if len(old) > 0 {
+		d.deliveries.Remove(old...)
+		d.logger.WithFields(log.Fields{"deliveries": len(old)}).Info("old deliveries removed")
+	}
+}
+
+// Sign returns the signature of a delivery: the hex HMAC-SHA256, keyed by the secret of the webhook,
+// of the timestamp header, a dot and the body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// publicOnly refuses to connect to the loopback, private, link-local and unspecified addresses.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// WebhookStore defines the interface to interact with the webhook persistence layer.
+type WebhookStore interface {
+	Find(id string) (coin.Webhook, error)
+	List() []coin.Webhook
+}
+
+// DeliveryStore defines the interface to interact with the delivery persistence layer.
+type DeliveryStore interface {
+	Add(d coin.Delivery) (string, error)
+	Find(id string) (coin.Delivery, error)
+	Save(d coin.Delivery) error
+	Remove(deliveries ...coin.Delivery) error
+	List() []coin.Delivery
+	Pending() []coin.Delivery
+}
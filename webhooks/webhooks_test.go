@@ -0,0 +1,220 @@
+package webhooks_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmdcosta/treasure-coin"
+	"github.com/pmdcosta/treasure-coin/events"
+	"github.com/pmdcosta/treasure-coin/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+// hooks is an in-memory webhook store.
+type hooks []coin.Webhook
+
+func (s hooks) List() []coin.Webhook { return s }
+func (s hooks) Find(id string) (coin.Webhook, error) {
+	for _, h := range s {
+		if h.ID == id {
+			return h, nil
+		}
+	}
+	return coin.Webhook{}, coin.Error("not found")
+}
+
+// deliveries is an in-memory delivery store.
+type deliveries struct {
+	list []coin.Delivery
+	seq  int
+}
+
+func (s *deliveries) Add(d coin.Delivery) (string, error) {
+	s.seq++
+	d.ID = strconv.Itoa(s.seq)
+	s.list = append(s.list, d)
+	return d.ID, nil
+}
+func (s *deliveries) Find(id string) (coin.Delivery, error) {
+	for _, d := range s.list {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return coin.Delivery{}, coin.Error("not found")
+}
+func (s *deliveries) Save(d coin.Delivery) error {
+	for i := range s.list {
+		if s.list[i].ID == d.ID {
+			s.list[i] = d
+		}
+	}
+	return nil
+}
+func (s *deliveries) Remove(ds ...coin.Delivery) error {
+	for _, d := range ds {
+		for i := range s.list {
+			if s.list[i].ID == d.ID {
+				s.list = append(s.list[:i], s.list[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+func (s *deliveries) List() []coin.Delivery { return append([]coin.Delivery(nil), s.list...) }
+func (s *deliveries) Pending() []coin.Delivery {
+	var pending []coin.Delivery
+	for _, d := range s.list {
+		if d.Status == coin.DeliveryPending {
+			pending = append(pending, d)
+		}
+	}
+	return pending
+}
+
+// receiver is a webhook recording the requests it receives, answering with its status code.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := ioutil.ReadAll(req.Body)
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+	w.WriteHeader(r.status)
+}
+
+// TestDispatcher_Deliver tests the events are delivered, signed, to the webhooks following their game.
+func TestDispatcher_Deliver(t *testing.T) {
+	rec := &receiver{status: http.StatusNoContent}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	store := &deliveries{}
+	d := webhooks.NewDispatcher(hooks{
+		{ID: "1", URL: server.URL + "/all", Secret: "secret"},
+		{ID: "2", URL: server.URL + "/game1", Secret: "secret", Game: "game1"},
+		{ID: "3", URL: server.URL + "/game2", Secret: "secret", Game: "game2"},
+	}, store, webhooks.Config{AllowPrivate: true})
+
+	d.Enqueue(events.TreasureFound(coin.Game{ID: "game1", Title: "Game 1"}, coin.Treasure{ID: "gold", Name: "Gold"}, "luffy"))
+	assert.Len(t, store.list, 2)
+
+	d.Deliver(context.Background())
+	assert.Len(t, rec.requests, 2)
+	assert.Equal(t, "/all", rec.requests[0].URL.Path)
+	assert.Equal(t, "/game1", rec.requests[1].URL.Path)
+
+	req := rec.requests[0]
+	assert.Equal(t, events.KindTreasureFound, req.Header.Get(webhooks.EventHeader))
+	assert.Equal(t, "1", req.Header.Get(webhooks.DeliveryHeader))
+	timestamp, err := strconv.ParseInt(req.Header.Get(webhooks.TimestampHeader), 10, 64)
+	assert.Nil(t, err)
+	assert.Equal(t, webhooks.Sign("secret", timestamp, []byte(rec.bodies[0])), req.Header.Get(webhooks.SignatureHeader))
+	assert.Contains(t, rec.bodies[0], `"treasure":"gold"`)
+	assert.Contains(t, rec.bodies[0], `"player":"luffy"`)
+
+	for _, dl := range store.list {
+		assert.Equal(t, coin.DeliveryDelivered, dl.Status)
+		assert.Equal(t, 1, dl.Attempts)
+		assert.Equal(t, http.StatusNoContent, dl.LastCode)
+	}
+}
+
+// TestDispatcher_Player tests the players are never named by email to the webhooks.
+func TestDispatcher_Player(t *testing.T) {
+	store := &deliveries{}
+	d := webhooks.NewDispatcher(hooks{{ID: "1", URL: "https://hooks.treasure.coin", Secret: "secret"}}, store, webhooks.Config{})
+
+	d.Enqueue(events.TreasureFound(coin.Game{ID: "game1"}, coin.Treasure{ID: "gold"}, "luffy@treasure.coin"))
+	assert.Len(t, store.list, 1)
+	assert.NotContains(t, store.list[0].Payload, "luffy")
+}
+
+// TestDispatcher_Retry tests the failed deliveries are retried with a backoff, given up, and retried on demand.
+func TestDispatcher_Retry(t *testing.T) {
+	rec := &receiver{status: http.StatusInternalServerError}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	store := &deliveries{}
+	d := webhooks.NewDispatcher(hooks{{ID: "1", URL: server.URL, Secret: "secret"}}, store, webhooks.Config{
+		AllowPrivate: true,
+		MaxAttempts:  2,
+		Backoff:      time.Minute,
+	})
+	d.Enqueue(events.GameCreated(coin.Game{ID: "game1"}))
+	d.Enqueue(events.GameCreated(coin.Game{ID: "game2"}))
+
+	// the other deliveries of a failing webhook wait.
+	d.Deliver(context.Background())
+	assert.Len(t, rec.requests, 1)
+	dl := store.list[0]
+	assert.Equal(t, coin.DeliveryPending, dl.Status)
+	assert.Equal(t, http.StatusInternalServerError, dl.LastCode)
+	assert.Equal(t, "unexpected response 500 Internal Server Error", dl.LastError)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), dl.NextAttempt, 5*time.Second)
+
+	// deliveries are not attempted before their backoff.
+	d.Deliver(context.Background())
+	assert.Len(t, rec.requests, 2)
+	assert.Equal(t, "2", rec.requests[1].Header.Get(webhooks.DeliveryHeader))
+
+	dl.NextAttempt = time.Time{}
+	store.Save(dl)
+	d.Deliver(context.Background())
+	dl, _ = store.Find("1")
+	assert.Equal(t, coin.DeliveryFailed, dl.Status)
+	assert.Equal(t, 2, dl.Attempts)
+
+	rec.status = http.StatusOK
+	assert.Equal(t, webhooks.ErrNotFailed, d.Retry("2"))
+	assert.Nil(t, d.Retry("1"))
+	d.Deliver(context.Background())
+	dl, _ = store.Find("1")
+	assert.Equal(t, coin.DeliveryDelivered, dl.Status)
+	assert.Equal(t, 1, dl.Attempts)
+	assert.Empty(t, dl.LastError)
+}
+
+// TestDispatcher_Private tests the webhooks cannot reach the server network unless allowed.
+func TestDispatcher_Private(t *testing.T) {
+	rec := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	store := &deliveries{}
+	d := webhooks.NewDispatcher(hooks{{ID: "1", URL: server.URL, Secret: "secret"}}, store, webhooks.Config{})
+	d.Enqueue(events.GameCreated(coin.Game{ID: "game1"}))
+	d.Deliver(context.Background())
+
+	assert.Empty(t, rec.requests)
+	assert.Contains(t, store.list[0].LastError, webhooks.ErrPrivateAddress.Error())
+}
+
+// TestDispatcher_RemovedWebhook tests the deliveries of removed webhooks are dropped.
+func TestDispatcher_RemovedWebhook(t *testing.T) {
+	store := &deliveries{}
+	store.Add(coin.Delivery{Webhook: "1", Status: coin.DeliveryPending})
+
+	d := webhooks.NewDispatcher(hooks{}, store, webhooks.Config{})
+	d.Deliver(context.Background())
+	assert.Empty(t, store.list)
+}
+
+// TestSign tests signing the deliveries.
+func TestSign(t *testing.T) {
+	assert.Equal(t, "sha256=9354eadf8df414f7148cf1e21362aee2e395ad208a9e883688fb35149efc42c3", webhooks.Sign("secret", 1528000000, []byte(`{"kind":"game_created"}`)))
+}